@@ -0,0 +1,254 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package a11yaudit checks a serialized HPSA accessibility tree for problems.
+// It only depends on the standard library so the rules run on any Linux host.
+package a11yaudit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Severity is how badly a violation blocks assistive technology users
+type Severity string
+
+const (
+	// Critical blocks the user from finishing the flow
+	Critical Severity = "critical"
+	// Serious makes the control very hard to use
+	Serious Severity = "serious"
+	// Moderate is confusing but can be worked around
+	Moderate Severity = "moderate"
+	// Minor is a best practice violation
+	Minor Severity = "minor"
+)
+
+// Rect is the bounds of a node in screen DIPs
+type Rect struct {
+	Left   int `json:"left"`
+	Top    int `json:"top"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Node is one serialized accessibility node of a HPSA page
+type Node struct {
+	Role      string `json:"role"`
+	Name      string `json:"name"`
+	ClassName string `json:"className"`
+	HTMLID    string `json:"htmlId,omitempty"`
+	// Checked is the checked state exposed to assistive technology: "true", "false", "mixed" or empty.
+	Checked string `json:"checked,omitempty"`
+	// AriaChecked is the aria-checked attribute of the element, empty when it is not set.
+	AriaChecked string `json:"ariaChecked,omitempty"`
+	Invisible   bool   `json:"invisible,omitempty"`
+	Location    Rect   `json:"location"`
+}
+
+// Tree is the serialized accessibility tree of one HPSA page
+type Tree struct {
+	Page  string `json:"page"`
+	Nodes []Node `json:"nodes"`
+}
+
+// Violation is one rule failure on a node
+type Violation struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Node     Node     `json:"node"`
+	Message  string   `json:"message"`
+}
+
+// Rule checks the tree and returns its violations
+type Rule func(t Tree) []Violation
+
+// Rules are the rules run by Audit
+var Rules = []Rule{
+	ButtonName,
+	ImageAlt,
+	DuplicateID,
+	MissingRole,
+	CheckboxState,
+}
+
+// Audit runs all rules on the tree, most severe violations first
+func Audit(t Tree) []Violation {
+	var violations []Violation
+	for _, rule := range Rules {
+		violations = append(violations, rule(t)...)
+	}
+	SortBySeverity(violations)
+	return violations
+}
+
+// SortBySeverity puts the most severe violations first and keeps the order within a severity
+func SortBySeverity(violations []Violation) {
+	sort.SliceStable(violations, func(i, j int) bool {
+		return severityRank(violations[i].Severity) < severityRank(violations[j].Severity)
+	})
+}
+
+// ButtonName reports buttons and links without an accessible name
+func ButtonName(t Tree) []Violation {
+	var violations []Violation
+	for _, n := range visible(t.Nodes) {
+		if (n.Role == "button" || n.Role == "link") && strings.TrimSpace(n.Name) == "" {
+			violations = append(violations, Violation{
+				Rule:     "button-name",
+				Severity: Critical,
+				Node:     n,
+				Message:  fmt.Sprintf("%v .%v has no accessible name", n.Role, n.ClassName),
+			})
+		}
+	}
+	return violations
+}
+
+// ImageAlt reports images without alt text
+func ImageAlt(t Tree) []Violation {
+	var violations []Violation
+	for _, n := range visible(t.Nodes) {
+		if n.Role == "image" && strings.TrimSpace(n.Name) == "" {
+			violations = append(violations, Violation{
+				Rule:     "image-alt",
+				Severity: Serious,
+				Node:     n,
+				Message:  fmt.Sprintf("image .%v has no alt text", n.ClassName),
+			})
+		}
+	}
+	return violations
+}
+
+// DuplicateID reports HTML ids used by more than one element
+func DuplicateID(t Tree) []Violation {
+	seen := make(map[string]int)
+	for _, n := range t.Nodes {
+		if n.HTMLID != "" {
+			seen[n.HTMLID]++
+		}
+	}
+	var violations []Violation
+	for _, n := range t.Nodes {
+		if count := seen[n.HTMLID]; n.HTMLID != "" && count > 1 {
+			violations = append(violations, Violation{
+				Rule:     "duplicate-id",
+				Severity: Moderate,
+				Node:     n,
+				Message:  fmt.Sprintf("id %q is used by %d elements", n.HTMLID, count),
+			})
+		}
+	}
+	return violations
+}
+
+// interactiveClasses are the HPSA style classes of clickable elements
+var interactiveClasses = []string{"btn", "hp-link", "hp-button-primary", "hp-button-secondary", "icon-button-primary", "hp-checkbox", "hp-dropdown"}
+
+// interactiveRoles are the roles a clickable element may expose
+var interactiveRoles = map[string]bool{
+	"button": true, "link": true, "checkBox": true, "radioButton": true, "comboBoxSelect": true,
+	"popUpButton": true, "menuItem": true, "listBoxOption": true, "textField": true, "tab": true,
+}
+
+// MissingRole reports elements styled as controls which do not expose an interactive role
+func MissingRole(t Tree) []Violation {
+	var violations []Violation
+	for _, n := range visible(t.Nodes) {
+		if interactiveRoles[n.Role] || !hasInteractiveClass(n.ClassName) {
+			continue
+		}
+		violations = append(violations, Violation{
+			Rule:     "missing-role",
+			Severity: Serious,
+			Node:     n,
+			Message:  fmt.Sprintf(".%v looks like a control but has role %q", n.ClassName, n.Role),
+		})
+	}
+	return violations
+}
+
+// CheckboxState reports checkboxes whose checked state is missing or disagrees with aria-checked
+func CheckboxState(t Tree) []Violation {
+	var violations []Violation
+	for _, n := range visible(t.Nodes) {
+		if n.Role != "checkBox" {
+			continue
+		}
+		switch {
+		case n.Checked == "":
+			violations = append(violations, Violation{
+				Rule:     "checkbox-state",
+				Severity: Critical,
+				Node:     n,
+				Message:  fmt.Sprintf("checkbox %q does not expose a checked state", n.Name),
+			})
+		case n.AriaChecked != "" && n.AriaChecked != n.Checked:
+			violations = append(violations, Violation{
+				Rule:     "checkbox-state",
+				Severity: Serious,
+				Node:     n,
+				Message:  fmt.Sprintf("checkbox %q is announced as %v but aria-checked is %v", n.Name, n.Checked, n.AriaChecked),
+			})
+		}
+	}
+	return violations
+}
+
+// ReadTree reads a tree written by WriteTree
+func ReadTree(path string) (Tree, error) {
+	var t Tree
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return t, err
+	}
+	err = json.Unmarshal(b, &t)
+	return t, err
+}
+
+// WriteTree writes the tree so it can be audited again off the device
+func WriteTree(path string, t Tree) error {
+	b, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+func visible(nodes []Node) []Node {
+	var shown []Node
+	for _, n := range nodes {
+		if !n.Invisible {
+			shown = append(shown, n)
+		}
+	}
+	return shown
+}
+
+func hasInteractiveClass(className string) bool {
+	for _, c := range strings.Fields(className) {
+		for _, ic := range interactiveClasses {
+			if c == ic {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func severityRank(s Severity) int {
+	switch s {
+	case Critical:
+		return 0
+	case Serious:
+		return 1
+	case Moderate:
+		return 2
+	}
+	return 3
+}
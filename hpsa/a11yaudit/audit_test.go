@@ -0,0 +1,116 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package a11yaudit
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRules(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		rule  Rule
+		nodes []Node
+		// want are the names, or classes for unnamed nodes, of the reported nodes
+		want []string
+	}{
+		{"button without name", ButtonName, []Node{
+			{Role: "button", Name: "Run", ClassName: "btn"},
+			{Role: "button", Name: " ", ClassName: "back"},
+			{Role: "link", ClassName: "hp-link"},
+			{Role: "button", ClassName: "hidden", Invisible: true},
+		}, []string{"back", "hp-link"}},
+		{"image without alt", ImageAlt, []Node{
+			{Role: "image", Name: "Passed", ClassName: "icon-Passed"},
+			{Role: "image", ClassName: "icon-Failed"},
+			{Role: "staticText", ClassName: "text"},
+		}, []string{"icon-Failed"}},
+		{"duplicate id", DuplicateID, []Node{
+			{Role: "button", Name: "a", HTMLID: "run"},
+			{Role: "button", Name: "b", HTMLID: "run"},
+			{Role: "button", Name: "c", HTMLID: "cancel"},
+			{Role: "button", Name: "d"},
+			{Role: "button", Name: "e"},
+		}, []string{"a", "b"}},
+		{"styled control without role", MissingRole, []Node{
+			{Role: "button", Name: "ok", ClassName: "btn flex-row-center hp-button-primary"},
+			{Role: "genericContainer", Name: "div", ClassName: "btn flex-row-center hp-button-secondary"},
+			{Role: "staticText", Name: "span", ClassName: "title hp-link againstVa"},
+			{Role: "genericContainer", Name: "plain", ClassName: "hp-linked"},
+		}, []string{"div", "span"}},
+		{"checkbox state", CheckboxState, []Node{
+			{Role: "checkBox", Name: "ok", Checked: "true", AriaChecked: "true"},
+			{Role: "checkBox", Name: "no aria", Checked: "false"},
+			{Role: "checkBox", Name: "no state"},
+			{Role: "checkBox", Name: "mismatch", Checked: "false", AriaChecked: "true"},
+		}, []string{"no state", "mismatch"}},
+	} {
+		var got []string
+		for _, v := range tc.rule(Tree{Nodes: tc.nodes}) {
+			if v.Node.Name != "" && v.Node.Name != " " {
+				got = append(got, v.Node.Name)
+			} else {
+				got = append(got, v.Node.ClassName)
+			}
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%v: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAuditOrder(t *testing.T) {
+	tree := Tree{Nodes: []Node{
+		{Role: "button", Name: "a", HTMLID: "x"},
+		{Role: "button", Name: "b", HTMLID: "x"},
+		{Role: "image", ClassName: "logo"},
+		{Role: "button", ClassName: "back"},
+	}}
+	var got []Severity
+	for _, v := range Audit(tree) {
+		got = append(got, v.Severity)
+	}
+	want := []Severity{Critical, Serious, Moderate, Moderate}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Audit() severities = %v, want %v", got, want)
+	}
+}
+
+func TestSortBySeverity(t *testing.T) {
+	violations := []Violation{
+		{Rule: "a", Severity: Moderate},
+		{Rule: "contrast", Severity: Serious},
+		{Rule: "b", Severity: Critical},
+		{Rule: "c", Severity: Moderate},
+		{Rule: "contrast", Severity: Critical},
+	}
+	SortBySeverity(violations)
+	var got []string
+	for _, v := range violations {
+		got = append(got, v.Rule)
+	}
+	if want := []string{"b", "contrast", "contrast", "a", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SortBySeverity() = %q, want %q", got, want)
+	}
+}
+
+func TestTreeRoundTrip(t *testing.T) {
+	want := Tree{Page: "dashboard", Nodes: []Node{
+		{Role: "checkBox", Name: "agree", ClassName: "hp-checkbox", HTMLID: "agree", Checked: "true", Location: Rect{1, 2, 3, 4}},
+	}}
+	path := filepath.Join(t.TempDir(), "tree.json")
+	if err := WriteTree(path, want); err != nil {
+		t.Fatal("WriteTree failed: ", err)
+	}
+	got, err := ReadTree(path)
+	if err != nil {
+		t.Fatal("ReadTree failed: ", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadTree() = %+v, want %+v", got, want)
+	}
+}
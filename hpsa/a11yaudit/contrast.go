@@ -0,0 +1,116 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package a11yaudit
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+const (
+	// minContrast is the WCAG AA contrast for normal text
+	minContrast = 4.5
+	// minLargeContrast is the WCAG AA contrast for large text
+	minLargeContrast = 3.0
+	// largeTextHeight is the line height in DIPs from which text counts as large
+	largeTextHeight = 24
+	// minForegroundShare is the part of the text bounds the text color has to cover, anti-aliased edges are ignored
+	minForegroundShare = 0.03
+)
+
+// Contrast estimates the contrast of every visible text node from a screenshot.
+// The background is the most common color in the node bounds and the text is
+// the color contrasting most with it. scale converts DIPs to screenshot pixels.
+func Contrast(t Tree, img image.Image, scale float64) []Violation {
+	var violations []Violation
+	for _, n := range visible(t.Nodes) {
+		if n.Role != "staticText" || n.Name == "" || n.Location.Width <= 0 || n.Location.Height <= 0 {
+			continue
+		}
+		r := image.Rect(
+			int(float64(n.Location.Left)*scale),
+			int(float64(n.Location.Top)*scale),
+			int(float64(n.Location.Left+n.Location.Width)*scale),
+			int(float64(n.Location.Top+n.Location.Height)*scale),
+		).Intersect(img.Bounds())
+		if r.Empty() {
+			continue
+		}
+		ratio, ok := textContrast(img, r)
+		if !ok {
+			continue
+		}
+		want := minContrast
+		if n.Location.Height >= largeTextHeight {
+			want = minLargeContrast
+		}
+		if ratio < want {
+			violations = append(violations, Violation{
+				Rule:     "color-contrast",
+				Severity: Serious,
+				Node:     n,
+				Message:  fmt.Sprintf("text %q has contrast %.2f:1, want at least %.1f:1", n.Name, ratio, want),
+			})
+		}
+	}
+	return violations
+}
+
+// textContrast returns the contrast between the background and the text color in r.
+// It returns false when the area has a single color, e.g. text rendered outside its bounds.
+func textContrast(img image.Image, r image.Rectangle) (float64, bool) {
+	counts := make(map[color.RGBA]int)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			counts[quantize(img.At(x, y))]++
+		}
+	}
+	var bg color.RGBA
+	for c, n := range counts {
+		if n > counts[bg] {
+			bg = c
+		}
+	}
+	total := r.Dx() * r.Dy()
+	best, found := 0.0, false
+	for c, n := range counts {
+		if c == bg || float64(n)/float64(total) < minForegroundShare {
+			continue
+		}
+		if ratio := ContrastRatio(c, bg); ratio > best {
+			best, found = ratio, true
+		}
+	}
+	return best, found
+}
+
+// ContrastRatio is the WCAG contrast ratio of two colors
+func ContrastRatio(a, b color.Color) float64 {
+	la, lb := luminance(a), luminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+func luminance(c color.Color) float64 {
+	r, g, b, _ := c.RGBA()
+	channel := func(v uint32) float64 {
+		s := float64(v) / 0xffff
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(r) + 0.7152*channel(g) + 0.0722*channel(b)
+}
+
+// quantize drops the low bits so anti-aliasing noise does not split one color in many
+func quantize(c color.Color) color.RGBA {
+	r, g, b, _ := c.RGBA()
+	return color.RGBA{R: uint8(r>>8) &^ 0x7, G: uint8(g>>8) &^ 0x7, B: uint8(b>>8) &^ 0x7, A: 0xff}
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package a11yaudit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+)
+
+func TestContrastRatio(t *testing.T) {
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	black := color.RGBA{0, 0, 0, 0xff}
+	for _, tc := range []struct {
+		name string
+		a, b color.Color
+		want float64
+	}{
+		{"black on white", black, white, 21},
+		{"white on black", white, black, 21},
+		{"same color", white, white, 1},
+		// #777777 is the darkest gray which still fails AA on white, #767676 the lightest which passes.
+		{"777777 on white", color.RGBA{0x77, 0x77, 0x77, 0xff}, white, 4.48},
+		{"767676 on white", color.RGBA{0x76, 0x76, 0x76, 0xff}, white, 4.54},
+		{"red on white", color.RGBA{0xff, 0, 0, 0xff}, white, 4.00},
+		{"blue on white", color.RGBA{0, 0, 0xff, 0xff}, white, 8.59},
+	} {
+		if got := ContrastRatio(tc.a, tc.b); math.Abs(got-tc.want) > 0.01 {
+			t.Errorf("%v: ContrastRatio() = %.3f, want %.2f", tc.name, got, tc.want)
+		}
+	}
+}
+
+// textImage draws a white image with one text-like bar per node in the given color.
+// The bar covers the middle third of the node bounds, as glyphs do.
+func textImage(w, h int, nodes []Node, colors []color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	for i, n := range nodes {
+		if colors[i] == nil {
+			continue
+		}
+		l := n.Location
+		bar := image.Rect(l.Left, l.Top+l.Height/3, l.Left+l.Width, l.Top+2*l.Height/3)
+		draw.Draw(img, bar, image.NewUniform(colors[i]), image.Point{}, draw.Src)
+	}
+	return img
+}
+
+func TestContrast(t *testing.T) {
+	nodes := []Node{
+		{Role: "staticText", Name: "black", Location: Rect{10, 10, 60, 12}},
+		{Role: "staticText", Name: "light gray", Location: Rect{10, 30, 60, 12}},
+		{Role: "staticText", Name: "large gray", Location: Rect{10, 50, 60, 30}},
+		{Role: "staticText", Name: "large light gray", Location: Rect{10, 90, 60, 30}},
+		{Role: "staticText", Name: "no text drawn", Location: Rect{100, 10, 60, 12}},
+		{Role: "staticText", Name: "hidden", Invisible: true, Location: Rect{100, 30, 60, 12}},
+		{Role: "button", Name: "not text", Location: Rect{100, 50, 60, 12}},
+	}
+	colors := []color.Color{
+		color.Black,
+		color.RGBA{0xc0, 0xc0, 0xc0, 0xff},
+		// About 3.9:1, enough for large text only.
+		color.RGBA{0x80, 0x80, 0x80, 0xff},
+		color.RGBA{0xc0, 0xc0, 0xc0, 0xff},
+		nil,
+		color.RGBA{0xc0, 0xc0, 0xc0, 0xff},
+		color.RGBA{0xc0, 0xc0, 0xc0, 0xff},
+	}
+	img := textImage(200, 130, nodes, colors)
+	got := Contrast(Tree{Nodes: nodes}, img, 1)
+	want := []string{"light gray", "large light gray"}
+	if len(got) != len(want) {
+		t.Fatalf("Contrast() returned %d violations, want %d: %+v", len(got), len(want), got)
+	}
+	for i, v := range got {
+		if v.Node.Name != want[i] || v.Rule != "color-contrast" {
+			t.Errorf("violation %d is %v on %q, want color-contrast on %q", i, v.Rule, v.Node.Name, want[i])
+		}
+	}
+}
+
+func TestContrastScale(t *testing.T) {
+	// The screenshot has twice the resolution of the DIP bounds.
+	nodes := []Node{{Role: "staticText", Name: "light gray", Location: Rect{10, 10, 60, 12}}}
+	scaled := []Node{{Location: Rect{20, 20, 120, 24}}}
+	img := textImage(200, 100, scaled, []color.Color{color.RGBA{0xc0, 0xc0, 0xc0, 0xff}})
+	if got := Contrast(Tree{Nodes: nodes}, img, 2); len(got) != 1 {
+		t.Errorf("Contrast() with scale 2 returned %d violations, want 1", len(got))
+	}
+	// Bounds outside the screenshot are ignored.
+	nodes[0].Location = Rect{300, 300, 60, 12}
+	if got := Contrast(Tree{Nodes: nodes}, img, 2); len(got) != 0 {
+		t.Errorf("Contrast() outside the screenshot returned %+v, want none", got)
+	}
+}
@@ -28,10 +28,10 @@ func init() {
 	testing.AddTest(&testing.Test{
 		Func:         Common,
 		LacrosStatus: testing.LacrosVariantExists,
-		Desc:         "POC for HPSA Tast",
+		Desc:         "Checks the HPSA ITG extension opens in a browser window with an accessible web view",
 		Contacts:     []string{"xinyang.li@hp.com"},
 		BugComponent: "",
-		Attr:         []string{"group:mainline"},
+		Attr:         []string{"group:hpsa", "hpsa_smoke"},
 		SoftwareDeps: []string{"chrome"},
 	})
 }
@@ -60,6 +60,8 @@ func Common(ctx context.Context, s *testing.State) {
 	cleanupCtx := ctx
 	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
 	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
 
 	br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 	if err != nil {
@@ -0,0 +1,72 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/bundles/cros/hpsa/a11yaudit"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/display"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/chrome/uiauto/role"
+	"chromiumos/tast/local/chrome/uiauto/state"
+	"chromiumos/tast/local/screenshot"
+	"context"
+
+	"go.chromium.org/tast/core/errors"
+)
+
+// CollectA11yTree serializes every node of the HPSA page for the a11y audit
+func CollectA11yTree(ctx context.Context, ui *uiauto.Context, page string) (a11yaudit.Tree, error) {
+	tree := a11yaudit.Tree{Page: page}
+	rootWebArea := nodewith.Role(role.RootWebArea).First()
+	if err := ui.WaitUntilExists(rootWebArea)(ctx); err != nil {
+		return tree, errors.Wrap(err, "failed to find the HPSA page")
+	}
+	infos, err := ui.NodesInfo(ctx, nodewith.Ancestor(rootWebArea))
+	if err != nil {
+		return tree, errors.Wrap(err, "failed to get the a11y nodes")
+	}
+	for _, info := range infos {
+		tree.Nodes = append(tree.Nodes, a11yaudit.Node{
+			Role:        string(info.Role),
+			Name:        info.Name,
+			ClassName:   info.ClassName,
+			HTMLID:      info.HTMLAttributes["id"],
+			Checked:     string(info.Checked),
+			AriaChecked: info.HTMLAttributes["aria-checked"],
+			Invisible:   info.State[state.Invisible],
+			Location: a11yaudit.Rect{
+				Left:   info.Location.Left,
+				Top:    info.Location.Top,
+				Width:  info.Location.Width,
+				Height: info.Location.Height,
+			},
+		})
+	}
+	return tree, nil
+}
+
+// AuditPage collects the page tree and runs the a11y rules and the contrast check on it, most severe violations first
+func AuditPage(ctx context.Context, cr *chrome.Chrome, tconn *chrome.TestConn, ui *uiauto.Context, page string) (a11yaudit.Tree, []a11yaudit.Violation, error) {
+	tree, err := CollectA11yTree(ctx, ui, page)
+	if err != nil {
+		return tree, nil, err
+	}
+	violations := a11yaudit.Audit(tree)
+	img, err := screenshot.GrabScreenshot(ctx, cr)
+	if err != nil {
+		return tree, violations, errors.Wrap(err, "failed to grab the screenshot for the contrast check")
+	}
+	info, err := display.GetPrimaryInfo(ctx, tconn)
+	if err != nil {
+		return tree, violations, errors.Wrap(err, "failed to get the primary display info")
+	}
+	// The a11y bounds are in DIPs, the screenshot is in physical pixels.
+	scale := float64(img.Bounds().Dx()) / float64(info.Bounds.Width)
+	violations = append(violations, a11yaudit.Contrast(tree, img, scale)...)
+	a11yaudit.SortBySeverity(violations)
+	return tree, violations, nil
+}
@@ -0,0 +1,291 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/chrome"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mafredri/cdp/protocol/page"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+// recordRequestsJS wraps fetch and XMLHttpRequest in the HPSA page so every request sent to the backend is kept.
+// Requests matching a stub in window.hpsaStubs are answered by the stub and never leave the page.
+const recordRequestsJS = `(() => {
+	if (window.hpsaRequests !== undefined) {
+		return;
+	}
+	window.hpsaRequests = [];
+	window.hpsaStubs = window.hpsaStubs || [];
+	const record = (method, url, body) => {
+		const entry = {method: (method || 'GET').toUpperCase(), url: String(url), body: typeof body === 'string' ? body : '', status: 0, failed: false, stubbed: false};
+		window.hpsaRequests.push(entry);
+		return entry;
+	};
+	const matches = (stub, method, url) => {
+		if (stub.method && stub.method !== method) {
+			return false;
+		}
+		if (stub.path) {
+			return new URL(url, location.href).pathname.endsWith(stub.path);
+		}
+		return url.includes(stub.urlPart);
+	};
+	const findStub = (entry) => window.hpsaStubs.find((stub) => matches(stub, entry.method, entry.url));
+	const fetch = window.fetch;
+	window.fetch = function(input, init) {
+		const url = input instanceof Request ? input.url : input;
+		const method = (init && init.method) || (input instanceof Request ? input.method : 'GET');
+		const entry = record(method, url, init && init.body);
+		const stub = findStub(entry);
+		if (stub) {
+			entry.stubbed = true;
+			if (stub.fail) {
+				entry.failed = true;
+				return Promise.reject(new TypeError('Failed to fetch'));
+			}
+			entry.status = stub.status;
+			return Promise.resolve(new Response(stub.body, {status: stub.status, headers: {'Content-Type': 'application/json'}}));
+		}
+		return fetch.call(window, input, init).then((response) => {
+			entry.status = response.status;
+			return response;
+		}, (err) => {
+			entry.failed = true;
+			throw err;
+		});
+	};
+	const open = XMLHttpRequest.prototype.open;
+	const send = XMLHttpRequest.prototype.send;
+	XMLHttpRequest.prototype.open = function(method, url, ...rest) {
+		this.hpsaRequest = {method, url};
+		return open.call(this, method, url, ...rest);
+	};
+	XMLHttpRequest.prototype.send = function(body) {
+		if (!this.hpsaRequest) {
+			return send.call(this, body);
+		}
+		const entry = record(this.hpsaRequest.method, this.hpsaRequest.url, body);
+		const stub = findStub(entry);
+		if (!stub) {
+			this.addEventListener('loadend', () => {
+				entry.status = this.status;
+				entry.failed = this.status === 0;
+			});
+			return send.call(this, body);
+		}
+		entry.stubbed = true;
+		setTimeout(() => {
+			if (stub.fail) {
+				entry.failed = true;
+				this.dispatchEvent(new ProgressEvent('error'));
+				this.dispatchEvent(new ProgressEvent('loadend'));
+				return;
+			}
+			entry.status = stub.status;
+			const response = this.responseType === 'json' ? JSON.parse(stub.body) : stub.body;
+			Object.defineProperty(this, 'readyState', {value: 4});
+			Object.defineProperty(this, 'status', {value: stub.status});
+			Object.defineProperty(this, 'statusText', {value: stub.status < 400 ? 'OK' : 'Error'});
+			Object.defineProperty(this, 'responseURL', {value: entry.url});
+			Object.defineProperty(this, 'responseText', {value: stub.body});
+			Object.defineProperty(this, 'response', {value: response});
+			this.getAllResponseHeaders = () => 'content-type: application/json\r\n';
+			this.getResponseHeader = (name) => name.toLowerCase() === 'content-type' ? 'application/json' : null;
+			this.dispatchEvent(new Event('readystatechange'));
+			this.dispatchEvent(new ProgressEvent('load'));
+			this.dispatchEvent(new ProgressEvent('loadend'));
+		}, 0);
+	};
+})()`
+
+// CapturedRequest is one request the HPSA page sent
+type CapturedRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body"`
+	// Status is the HTTP status of the response, 0 while it is pending or when the request failed.
+	Status int `json:"status"`
+	// Failed is set when the request got no response at all.
+	Failed bool `json:"failed"`
+	// Stubbed is set when the request was answered by a BackendStub.
+	Stubbed bool `json:"stubbed"`
+}
+
+// BackendStub stands in for the HPSA backend on the requests it matches.
+// A stub with a Path matches the requests whose URL path is Path or ends with it,
+// one without matches the requests whose URL contains URLPart.
+type BackendStub struct {
+	// Method is the upper case HTTP method, empty matches any method.
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	URLPart string `json:"urlPart"`
+	Status  int    `json:"status"`
+	Body    string `json:"body"`
+	// Fail makes the request fail like a network error instead of answering it.
+	Fail bool `json:"fail"`
+}
+
+// Matches tells if the stub answers a request, it is the Go side of matches in recordRequestsJS
+func (s BackendStub) Matches(method, rawURL string) bool {
+	if s.Method != "" && !strings.EqualFold(s.Method, method) {
+		return false
+	}
+	if s.Path != "" {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return false
+		}
+		return strings.HasSuffix(u.Path, s.Path)
+	}
+	return strings.Contains(rawURL, s.URLPart)
+}
+
+// NetworkRecorder records the requests sent by the HPSA page
+type NetworkRecorder struct {
+	conn  *chrome.Conn
+	dt    *DevTools
+	stubs []BackendStub
+	// script is the hook DevTools runs on every new document of the page, empty until it is added.
+	script page.ScriptIdentifier
+}
+
+// StartNetworkRecorder connects to the HPSA page and starts recording its requests.
+// The hooks run again before the scripts of the page each time it loads, so Reload
+// sees and stubs the requests sent while the page starts.
+func StartNetworkRecorder(ctx context.Context, cr *chrome.Chrome) (*NetworkRecorder, error) {
+	conn, err := cr.NewConnForTarget(ctx, chrome.MatchTargetURLPrefix(AppURLITG))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to the HPSA page")
+	}
+	dt, err := NewDevTools(ctx, AppURLITG)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "failed to open a DevTools session on the HPSA page")
+	}
+	r := &NetworkRecorder{conn: conn, dt: dt}
+	if err := r.addHooks(ctx); err != nil {
+		r.Close()
+		return nil, err
+	}
+	if err := conn.Eval(ctx, recordRequestsJS, nil); err != nil {
+		r.Close()
+		return nil, errors.Wrap(err, "failed to hook the HPSA requests")
+	}
+	return r, nil
+}
+
+// addHooks replaces the hook run on new documents with one carrying the current stubs
+func (r *NetworkRecorder) addHooks(ctx context.Context) error {
+	if r.script != "" {
+		if err := r.dt.Client.Page.RemoveScriptToEvaluateOnNewDocument(ctx, page.NewRemoveScriptToEvaluateOnNewDocumentArgs(r.script)); err != nil {
+			return errors.Wrap(err, "failed to remove the request hooks")
+		}
+		r.script = ""
+	}
+	stubs, err := json.Marshal(r.stubs)
+	if err != nil {
+		return err
+	}
+	if r.stubs == nil {
+		stubs = []byte("[]")
+	}
+	source := fmt.Sprintf("window.hpsaStubs = %s;\n%s;", stubs, recordRequestsJS)
+	reply, err := r.dt.Client.Page.AddScriptToEvaluateOnNewDocument(ctx, page.NewAddScriptToEvaluateOnNewDocumentArgs(source))
+	if err != nil {
+		return errors.Wrap(err, "failed to add the request hooks to new documents")
+	}
+	r.script = reply.Identifier
+	return nil
+}
+
+// Requests returns every request recorded so far
+func (r *NetworkRecorder) Requests(ctx context.Context) ([]CapturedRequest, error) {
+	var requests []CapturedRequest
+	if err := r.conn.Eval(ctx, "window.hpsaRequests.slice()", &requests); err != nil {
+		return nil, errors.Wrap(err, "failed to read the HPSA requests")
+	}
+	return requests, nil
+}
+
+// Reset forgets the requests recorded so far
+func (r *NetworkRecorder) Reset(ctx context.Context) error {
+	return r.conn.Eval(ctx, "window.hpsaRequests.length = 0", nil)
+}
+
+// WaitForRequest waits until a request matched by the stub has been answered, whether or not it was stubbed
+func (r *NetworkRecorder) WaitForRequest(ctx context.Context, match BackendStub, timeout time.Duration) (CapturedRequest, error) {
+	var found CapturedRequest
+	if err := testing.Poll(ctx, func(ctx context.Context) error {
+		requests, err := r.Requests(ctx)
+		if err != nil {
+			return testing.PollBreak(err)
+		}
+		for _, req := range requests {
+			if match.Matches(req.Method, req.URL) && (req.Status != 0 || req.Failed) {
+				found = req
+				return nil
+			}
+		}
+		return errors.Errorf("no %v request to %q yet", match.Method, match.Path+match.URLPart)
+	}, &testing.PollOptions{Interval: 500 * time.Millisecond, Timeout: timeout}); err != nil {
+		return found, err
+	}
+	return found, nil
+}
+
+// Stub answers the matching requests with the stub from now on, and after every reload.
+// Stubs are checked in the order they were added.
+func (r *NetworkRecorder) Stub(ctx context.Context, stub BackendStub) error {
+	if stub.Path == "" && stub.URLPart == "" {
+		return errors.New("a stub needs a path or a URL part")
+	}
+	if err := r.conn.Call(ctx, nil, `(stub) => { window.hpsaStubs.push(stub); }`, stub); err != nil {
+		return errors.Wrapf(err, "failed to stub %q", stub.Path+stub.URLPart)
+	}
+	r.stubs = append(r.stubs, stub)
+	return r.addHooks(ctx)
+}
+
+// ClearStubs lets every request reach the real backend again
+func (r *NetworkRecorder) ClearStubs(ctx context.Context) error {
+	r.stubs = nil
+	if err := r.conn.Eval(ctx, "window.hpsaStubs.length = 0", nil); err != nil {
+		return errors.Wrap(err, "failed to clear the stubs")
+	}
+	return r.addHooks(ctx)
+}
+
+// Reload reloads the HPSA page. The hooks and the current stubs are in place
+// before the page runs its own scripts, so the requests it sends while it
+// starts, e.g. the warranty lookup, are recorded and stubbed.
+func (r *NetworkRecorder) Reload(ctx context.Context) error {
+	// The marker is gone once the old document is replaced.
+	if err := r.conn.Eval(ctx, "window.hpsaReloading = true", nil); err != nil {
+		return errors.Wrap(err, "failed to mark the HPSA page")
+	}
+	if err := r.dt.Client.Page.Reload(ctx, page.NewReloadArgs().SetIgnoreCache(true)); err != nil {
+		return errors.Wrap(err, "failed to reload the HPSA page")
+	}
+	if err := r.conn.WaitForExpr(ctx, `window.hpsaReloading === undefined && document.readyState === "complete"`); err != nil {
+		return errors.Wrap(err, "failed to wait for the HPSA page")
+	}
+	return nil
+}
+
+// Close removes the hooks from new documents and disconnects from the HPSA page.
+// The current page keeps its hooks until it reloads.
+func (r *NetworkRecorder) Close() error {
+	r.dt.Close()
+	return r.conn.Close()
+}
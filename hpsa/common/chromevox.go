@@ -0,0 +1,119 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/a11y"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/uiauto"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"go.chromium.org/tast/core/errors"
+)
+
+// SpokenFeedback is ChromeVox with a monitor of what it says
+type SpokenFeedback struct {
+	tconn  *chrome.TestConn
+	cvconn *a11y.ChromeVoxConn
+	sm     *a11y.SpeechMonitor
+}
+
+// StartSpokenFeedback turns on ChromeVox with the Google TTS voice of the locale and monitors its speech
+func StartSpokenFeedback(ctx context.Context, cr *chrome.Chrome, tconn *chrome.TestConn, locale string) (*SpokenFeedback, error) {
+	if err := a11y.SetFeatureEnabled(ctx, tconn, a11y.SpokenFeedback, true); err != nil {
+		return nil, errors.Wrap(err, "failed to enable spoken feedback")
+	}
+	cvconn, err := a11y.NewChromeVoxConn(ctx, cr)
+	if err != nil {
+		a11y.SetFeatureEnabled(ctx, tconn, a11y.SpokenFeedback, false)
+		return nil, errors.Wrap(err, "failed to connect to ChromeVox")
+	}
+	sm, err := a11y.RelevantSpeechMonitor(ctx, cr, tconn, a11y.TTSEngineData{ExtID: a11y.GoogleTTSExtensionID, UseOnSpeakWithAudioStream: false})
+	if err != nil {
+		cvconn.Close()
+		a11y.SetFeatureEnabled(ctx, tconn, a11y.SpokenFeedback, false)
+		return nil, errors.Wrap(err, "failed to connect to the TTS engine")
+	}
+	f := &SpokenFeedback{tconn: tconn, cvconn: cvconn, sm: sm}
+	if err := cvconn.SetVoice(ctx, a11y.VoiceData{ExtID: a11y.GoogleTTSExtensionID, Locale: locale}); err != nil {
+		f.Close(ctx)
+		return nil, errors.Wrapf(err, "failed to set the ChromeVox voice for %v", locale)
+	}
+	return f, nil
+}
+
+// ExpectSpeech waits until ChromeVox says an utterance matching the phrase.
+// Without a phrase for the locale it waits for the accessible name of the node.
+func (f *SpokenFeedback) ExpectSpeech(ctx context.Context, info *uiauto.NodeInfo, phrase *regexp.Regexp) error {
+	expectation := a11y.NewStringExpectation(info.Name)
+	if phrase != nil {
+		expectation = a11y.NewRegexExpectation(phrase.String())
+	}
+	return f.sm.Consume(ctx, []a11y.SpeechExpectation{expectation})
+}
+
+// Close stops monitoring and turns spoken feedback off
+func (f *SpokenFeedback) Close(ctx context.Context) error {
+	f.sm.Close()
+	f.cvconn.Close()
+	return a11y.SetFeatureEnabled(ctx, f.tconn, a11y.SpokenFeedback, false)
+}
+
+// GetSpeechPhrasesJSON reads the expected ChromeVox phrases of the locale from the path, keyed by element name.
+// Phrases are patterns matched against what ChromeVox says.
+// It returns nil when the file has no phrases for the locale, only the spoken names are checked then.
+func GetSpeechPhrasesJSON(locale, path string) (map[string]*regexp.Regexp, error) {
+	jsonData, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not read json from : %q ", path)
+	}
+	var phrases map[string]map[string]string
+	if err := json.Unmarshal(jsonData, &phrases); err != nil {
+		return nil, errors.Wrapf(err, "can not parse json from : %q ", path)
+	}
+	if phrases[locale] == nil {
+		return nil, nil
+	}
+	patterns := make(map[string]*regexp.Regexp)
+	for element, phrase := range phrases[locale] {
+		re, err := regexp.Compile(phrase)
+		if err != nil {
+			return nil, errors.Wrapf(err, "bad phrase for %v in %v", element, locale)
+		}
+		patterns[element] = re
+	}
+	return patterns, nil
+}
+
+// LocaleFromLanguage returns the locale of a --lang= Chrome argument such as Language
+func LocaleFromLanguage(lang string) string {
+	return strings.TrimPrefix(lang, "--lang=")
+}
+
+// CheckAccessibleName verifies the accessible name ChromeVox reads for a control.
+// It reports a missing name and a name made of the CSS classes of the element.
+func CheckAccessibleName(info *uiauto.NodeInfo) []string {
+	name := strings.TrimSpace(info.Name)
+	if name == "" {
+		return []string{fmt.Sprintf("%v has no accessible name", info.Role)}
+	}
+	classes := make(map[string]bool)
+	for _, class := range strings.Fields(info.ClassName) {
+		classes[class] = true
+	}
+	fromClasses := true
+	for _, word := range strings.Fields(name) {
+		fromClasses = fromClasses && classes[word]
+	}
+	if fromClasses {
+		return []string{fmt.Sprintf("accessible name %q is made of the CSS classes %q", name, info.ClassName)}
+	}
+	return nil
+}
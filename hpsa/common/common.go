@@ -106,7 +106,8 @@ func TakeScreenshot(ctx context.Context, s *testing.State, screenshotName, scree
 	}
 	screenshotFile := filepath.Join(screenshotPath, screenshotName)
 	s.Log("Save screenshot to ", screenshotFile)
-	if err := screenshot.Capture(ctx, screenshotFile); err != nil {
+	step := StartStep(ctx, "screenshot", screenshotName, "")
+	if err := step.Done(screenshot.Capture(ctx, screenshotFile)); err != nil {
 		testing.ContextLog(ctx, "Failed to take screenshot: ", err)
 		return "Take screenshot Fail", errors.Wrap(err, "failed to take screenshot")
 	}
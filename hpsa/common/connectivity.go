@@ -0,0 +1,109 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/chrome/uiauto/role"
+	"chromiumos/tast/local/coords"
+	"context"
+	"regexp"
+	"time"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+// ConnectivityStatus is the result of one row of the connectivity check
+type ConnectivityStatus string
+
+const (
+	// ConnectivityPassed is a row with the pass image
+	ConnectivityPassed ConnectivityStatus = "passed"
+	// ConnectivityFailed is a row without the pass image
+	ConnectivityFailed ConnectivityStatus = "failed"
+	// ConnectivityMissing is a row which is not shown
+	ConnectivityMissing ConnectivityStatus = ""
+)
+
+// Keys of the row labels of the connectivity check in the diagnostic messages
+const (
+	ConnectivityWifiRow     = "wifi"
+	ConnectivityEthernetRow = "ethernet"
+	ConnectivityInternetRow = "internet"
+)
+
+// ConnectivityResult is what the connectivity check shows after a run
+type ConnectivityResult struct {
+	Wifi     ConnectivityStatus `json:"wifi"`
+	Ethernet ConnectivityStatus `json:"ethernet"`
+	Internet ConnectivityStatus `json:"internet"`
+}
+
+// connectivityRows are the rows in the order of ConnectivityResult
+var connectivityRows = []string{ConnectivityWifiRow, ConnectivityEthernetRow, ConnectivityInternetRow}
+
+// sameLine tells whether the two rectangles overlap vertically
+func sameLine(a, b coords.Rect) bool {
+	return a.Top < b.Bottom() && b.Top < a.Bottom()
+}
+
+// ReadConnectivityResult reads the rows of the finished connectivity check.
+// A row is found by its label, labels are the diagnostic messages of the locale.
+// It passed when a pass image is on the line of the label, a row which is not shown is ConnectivityMissing.
+func ReadConnectivityResult(ctx context.Context, ui *uiauto.Context, dashboardPath string, labels map[string]*regexp.Regexp) (*ConnectivityResult, error) {
+	passClass, _, err := GetJSONDashboard(CPUCheckPassImage, dashboardPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not get the json data for %v", CPUCheckPassImage)
+	}
+	images, err := ui.NodesInfo(ctx, nodewith.HasClass(passClass))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the pass images")
+	}
+	statuses := make([]ConnectivityStatus, len(connectivityRows))
+	for i, row := range connectivityRows {
+		pattern, ok := labels[row]
+		if !ok {
+			return nil, errors.Errorf("no label for the %v row", row)
+		}
+		finder := nodewith.Role(role.StaticText).NameRegex(pattern).First()
+		found, err := ui.IsNodeFound(ctx, finder)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to find the %v row", row)
+		}
+		if !found {
+			continue
+		}
+		label, err := ui.Info(ctx, finder)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the %v row", row)
+		}
+		statuses[i] = ConnectivityFailed
+		for _, image := range images {
+			if sameLine(label.Location, image.Location) {
+				statuses[i] = ConnectivityPassed
+				break
+			}
+		}
+	}
+	return &ConnectivityResult{Wifi: statuses[0], Ethernet: statuses[1], Internet: statuses[2]}, nil
+}
+
+// RunConnectivityCheck runs the connectivity check and reads its result before it goes back to the dashboard
+func RunConnectivityCheck(ctx context.Context, ui *uiauto.Context, dashboardPath string, labels map[string]*regexp.Regexp, timeout time.Duration) (*ConnectivityResult, error) {
+	var result *ConnectivityResult
+	err := runAndRead(ctx, ui, CheckConnectivity, dashboardPath, timeout, func(ctx context.Context) error {
+		var err error
+		if result, err = ReadConnectivityResult(ctx, ui, dashboardPath, labels); err != nil {
+			return testing.PollBreak(err)
+		}
+		if result.Wifi == ConnectivityMissing && result.Ethernet == ConnectivityMissing && result.Internet == ConnectivityMissing {
+			return errors.New("no row of the connectivity check is shown")
+		}
+		return nil
+	})
+	return result, err
+}
@@ -92,6 +92,8 @@ const (
 	FeedbackLink = "FeedbackLink"
 	//FeedbackCancel is the cancel button on feedback
 	FeedbackCancel = "FeedbackCancel"
+	//FeedbackSubmit is the submit button on feedback
+	FeedbackSubmit = "FeedbackSubmit"
 	//Network is the name in specification list
 	Network = "Network"
 	//Audio is the name in specification list
@@ -100,6 +102,12 @@ const (
 	Battery = "Battery"
 	//Video is the name in specification list
 	Video = "Video"
+	//Processor is the name in specification list
+	Processor = "Processor"
+	//Memory is the name in specification list
+	Memory = "Memory"
+	//Storage is the name in specification list
+	Storage = "Storage"
 	//DeviceName is the title of the device
 	DeviceName = "DeviceName"
 	//SerialNumber is the sn on dashboard
@@ -122,6 +130,8 @@ const (
 	WarrantyCardGetDetail = "WarrantyCardGetDetail"
 	//WarrantyCardGetDetailYES is the yes button in warranty popup
 	WarrantyCardGetDetailYES = "WarrantyCardGetDetailYES"
+	//ComponentItemBack is the back button from a component sub-test to the list
+	ComponentItemBack = "ComponentItemBack"
 	//VirtualAgent is the button of the VA pop
 	VirtualAgent = "VirtualAgent"
 	//VirtualAgentDown is the expend down button in va popup
@@ -135,7 +145,9 @@ const (
 // ClickDashboardBtns is using to click all element in welcome
 func ClickDashboardBtns(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, element, elementClass string) (string, error) {
 	s.Logf("Asserting that mouse click works on the %v button in %v browser", element, bt)
-	if err := testing.Poll(ctx, func(ctx context.Context) error {
+	step := StartStep(ctx, "click", element, Locator(elementClass, 0))
+	if err := step.Done(testing.Poll(ctx, func(ctx context.Context) error {
+		step.Attempt()
 		if err := uiauto.Combine(
 			fmt.Sprintf("Click the %v button in %v browser", element, bt),
 			ui.WaitUntilExists(nodewith.HasClass(elementClass).First()),
@@ -145,7 +157,7 @@ func ClickDashboardBtns(ctx context.Context, s *testing.State, bt browser.Type,
 			return err
 		}
 		return nil
-	}, &testing.PollOptions{Timeout: 3 * time.Minute}); err != nil {
+	}, &testing.PollOptions{Timeout: 3 * time.Minute})); err != nil {
 		s.Logf("Failed to find and click the %v button in 3 mins : %v", element, err)
 	}
 	return "Sucessfully clicked", nil
@@ -154,7 +166,9 @@ func ClickDashboardBtns(ctx context.Context, s *testing.State, bt browser.Type,
 // ClickDashboardBtnsNTH is using to click all element in welcome
 func ClickDashboardBtnsNTH(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, element, elementClass string, nth int) (string, error) {
 	s.Logf("Asserting that mouse click works on the %v button in %v browser", element, bt)
-	if err := testing.Poll(ctx, func(ctx context.Context) error {
+	step := StartStep(ctx, "click", element, Locator(elementClass, nth))
+	if err := step.Done(testing.Poll(ctx, func(ctx context.Context) error {
+		step.Attempt()
 		if err := uiauto.Combine(
 			fmt.Sprintf("Click the %v button in %v browser", element, bt),
 			ui.WaitUntilExists(nodewith.HasClass(elementClass).Nth(nth)),
@@ -164,7 +178,7 @@ func ClickDashboardBtnsNTH(ctx context.Context, s *testing.State, bt browser.Typ
 			return err
 		}
 		return nil
-	}, &testing.PollOptions{Timeout: 3 * time.Minute}); err != nil {
+	}, &testing.PollOptions{Timeout: 3 * time.Minute})); err != nil {
 		s.Logf("Failed to find and click the %v button in 3 mins : %v", element, err)
 	}
 	return "Sucessfully clicked", nil
@@ -173,9 +187,11 @@ func ClickDashboardBtnsNTH(ctx context.Context, s *testing.State, bt browser.Typ
 // InputDashboardText is using to click all element in welcome
 func InputDashboardText(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, element, elementClass, inputContext string) (string, error) {
 	s.Logf("Asserting that mouse click works on the %v button in %v browser", element, bt)
+	step := StartStep(ctx, "type", element, Locator(elementClass, 0))
 	kb, _ := input.Keyboard(ctx)
 	// Poll for a minute to make sure DUT connection is ready.
-	if err := testing.Poll(ctx, func(ctx context.Context) error {
+	if err := step.Done(testing.Poll(ctx, func(ctx context.Context) error {
+		step.Attempt()
 
 		if err := uiauto.Combine(
 			fmt.Sprintf("Click the %v button in %v browser", element, bt),
@@ -187,7 +203,7 @@ func InputDashboardText(ctx context.Context, s *testing.State, bt browser.Type,
 		}
 		return nil
 	}, &testing.PollOptions{Interval: 30 * time.Second,
-		Timeout: time.Minute}); err != nil {
+		Timeout: time.Minute})); err != nil {
 		logging.Infof(ctx, "Can not finish the action %v", err)
 	}
 	return "Sucessfully clicked", nil
@@ -0,0 +1,189 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mafredri/cdp"
+	"github.com/mafredri/cdp/devtool"
+	"github.com/mafredri/cdp/protocol/heapprofiler"
+	"github.com/mafredri/cdp/rpcc"
+
+	"go.chromium.org/tast/core/errors"
+)
+
+// devToolsActivePort is written by Chrome with the port of the remote debugging server on its first line
+const devToolsActivePort = "/home/chronos/DevToolsActivePort"
+
+// DevTools is a DevTools protocol session on the HPSA page or one of its service workers.
+// It is a separate session from the chrome.Conn of the test, so the domains it enables do not interfere with it.
+type DevTools struct {
+	conn *rpcc.Conn
+	// Client sends the DevTools commands.
+	Client *cdp.Client
+	// TargetID is the DevTools id of the page or the worker.
+	TargetID string
+	// TargetURL is the URL of the page when the session was opened.
+	TargetURL string
+}
+
+// NewDevTools opens a DevTools session on the first page whose URL starts with urlPrefix
+func NewDevTools(ctx context.Context, urlPrefix string) (*DevTools, error) {
+	targets, err := listTargets(ctx, devtool.Page, urlPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, errors.Errorf("no page starts with %v", urlPrefix)
+	}
+	return dialTarget(ctx, targets[0])
+}
+
+// NewWorkerDevTools opens a DevTools session on every running service worker whose URL starts with urlPrefix.
+// The requests a service worker sends for the page are its own, the emulation of the page does not reach them.
+// The skip targets already have a session, it is not opened again.
+func NewWorkerDevTools(ctx context.Context, urlPrefix string, skip map[string]bool) ([]*DevTools, error) {
+	targets, err := listTargets(ctx, devtool.ServiceWorker, urlPrefix)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*DevTools
+	for _, t := range targets {
+		if skip[t.ID] {
+			continue
+		}
+		d, err := dialTarget(ctx, t)
+		if err != nil {
+			for _, d := range sessions {
+				d.Close()
+			}
+			return nil, err
+		}
+		sessions = append(sessions, d)
+	}
+	return sessions, nil
+}
+
+// listTargets lists the DevTools targets of the type whose URL starts with urlPrefix
+func listTargets(ctx context.Context, typ devtool.Type, urlPrefix string) ([]*devtool.Target, error) {
+	b, err := ioutil.ReadFile(devToolsActivePort)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the DevTools port")
+	}
+	port, err := strconv.Atoi(strings.SplitN(strings.TrimSpace(string(b)), "\n", 2)[0])
+	if err != nil {
+		return nil, errors.Wrapf(err, "bad DevTools port in %v", devToolsActivePort)
+	}
+	targets, err := devtool.New(fmt.Sprintf("http://127.0.0.1:%d", port)).List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the DevTools targets")
+	}
+	var found []*devtool.Target
+	for _, t := range targets {
+		if t.Type == typ && strings.HasPrefix(t.URL, urlPrefix) {
+			found = append(found, t)
+		}
+	}
+	return found, nil
+}
+
+func dialTarget(ctx context.Context, t *devtool.Target) (*DevTools, error) {
+	conn, err := rpcc.DialContext(ctx, t.WebSocketDebuggerURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to %v", t.URL)
+	}
+	return &DevTools{conn: conn, Client: cdp.NewClient(conn), TargetID: t.ID, TargetURL: t.URL}, nil
+}
+
+// Close closes the session, what it enabled on the page is reset by Chrome
+func (d *DevTools) Close() error {
+	return d.conn.Close()
+}
+
+// HeapUsage collects the garbage and returns the used and total JS heap of the page in bytes
+func (d *DevTools) HeapUsage(ctx context.Context) (used, total float64, err error) {
+	if err := d.Client.HeapProfiler.CollectGarbage(ctx); err != nil {
+		return 0, 0, errors.Wrap(err, "failed to collect the garbage")
+	}
+	reply, err := d.Client.Runtime.GetHeapUsage(ctx)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to get the heap usage")
+	}
+	return reply.UsedSize, reply.TotalSize, nil
+}
+
+// DOMCounters returns the number of documents, nodes and event listeners of the page
+func (d *DevTools) DOMCounters(ctx context.Context) (documents, nodes, listeners int, err error) {
+	reply, err := d.Client.Memory.GetDOMCounters(ctx)
+	if err != nil {
+		return 0, 0, 0, errors.Wrap(err, "failed to get the DOM counters")
+	}
+	return reply.Documents, reply.Nodes, reply.JsEventListeners, nil
+}
+
+// TakeHeapSnapshot writes a heap snapshot of the page to path, it opens in the Memory panel of DevTools
+func (d *DevTools) TakeHeapSnapshot(ctx context.Context, path string) error {
+	step := StartStep(ctx, "snapshot", "TakeHeapSnapshot", "")
+	step.Attempt()
+	return step.Done(d.takeHeapSnapshot(ctx, path))
+}
+
+func (d *DevTools) takeHeapSnapshot(ctx context.Context, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the snapshot file")
+	}
+	defer f.Close()
+	if err := d.Client.HeapProfiler.Enable(ctx); err != nil {
+		return errors.Wrap(err, "failed to enable the heap profiler")
+	}
+	defer d.Client.HeapProfiler.Disable(ctx)
+	chunks, err := d.Client.HeapProfiler.AddHeapSnapshotChunk(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to listen for the snapshot chunks")
+	}
+	defer chunks.Close()
+	done := make(chan error, 1)
+	go func() {
+		done <- d.Client.HeapProfiler.TakeHeapSnapshot(ctx, heapprofiler.NewTakeHeapSnapshotArgs().SetReportProgress(false))
+	}()
+	write := func() error {
+		reply, err := chunks.Recv()
+		if err != nil {
+			return errors.Wrap(err, "failed to receive a snapshot chunk")
+		}
+		_, err = f.WriteString(reply.Chunk)
+		return err
+	}
+	for {
+		select {
+		case <-chunks.Ready():
+			if err := write(); err != nil {
+				return err
+			}
+		case err := <-done:
+			if err != nil {
+				return errors.Wrap(err, "failed to take the heap snapshot")
+			}
+			// Every chunk is sent before the reply, write the ones still buffered.
+			for {
+				select {
+				case <-chunks.Ready():
+					if err := write(); err != nil {
+						return err
+					}
+				default:
+					return f.Close()
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/chrome/uiauto/role"
+	"chromiumos/tast/local/coords"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"time"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+// diagnosticStartTimeout is how long the run button may take to turn disabled after the click
+const diagnosticStartTimeout = 30 * time.Second
+
+// diagnosticBack is the back button of every diagnostic page
+var diagnosticBack = map[string]string{
+	BatteryCheck:      BatteryCheckBack,
+	CheckCPU:          CheckCPUBack,
+	CheckSystemMemory: CheckSystemMemoryBack,
+	CheckConnectivity: CheckConnectivityBack,
+	ComponentTest:     ComponentTestBack,
+	CheckStorage:      CheckStorageBack,
+}
+
+// ExceptionShown tells whether the exception popup is shown now, unlike FindException it does not wait for it
+func ExceptionShown(ctx context.Context, ui *uiauto.Context, dashboardPath string) (bool, error) {
+	finder, err := Finder(ExceptionBtn, dashboardPath, "dashboard")
+	if err != nil {
+		return false, err
+	}
+	return ui.IsNodeFound(ctx, finder)
+}
+
+// DismissException closes the exception popup
+func DismissException(ctx context.Context, ui *uiauto.Context, dashboardPath string) error {
+	finder, err := Finder(ExceptionBtn, dashboardPath, "dashboard")
+	if err != nil {
+		return err
+	}
+	return TraceAction("click", ExceptionBtn, finder.Pretty(), uiauto.Combine("dismiss the exception popup",
+		ui.LeftClick(finder),
+		ui.WithTimeout(10*time.Second).WaitUntilGone(finder),
+	))(ctx)
+}
+
+// OpenDiagnostic opens the page of the diagnostic from the dashboard, name is e.g. BatteryCheck or CheckCPU
+func OpenDiagnostic(ctx context.Context, ui *uiauto.Context, name, dashboardPath string) error {
+	finder, err := Finder(name, dashboardPath, "dashboard")
+	if err != nil {
+		return err
+	}
+	return TraceAction("click", name, finder.Pretty(), uiauto.Combine("open the "+name+" diagnostic",
+		ui.WithTimeout(time.Minute).WaitUntilExists(finder),
+		ui.LeftClick(finder),
+	))(ctx)
+}
+
+// CloseDiagnostic goes back from the page of the diagnostic to the dashboard
+func CloseDiagnostic(ctx context.Context, ui *uiauto.Context, name, dashboardPath string) error {
+	back, ok := diagnosticBack[name]
+	if !ok {
+		return errors.Errorf("unknown diagnostic %v", name)
+	}
+	finder, err := Finder(back, dashboardPath, "dashboard")
+	if err != nil {
+		return err
+	}
+	return TraceAction("click", back, finder.Pretty(), uiauto.Combine("go back from the "+name+" diagnostic",
+		ui.WaitUntilExists(finder),
+		ui.LeftClick(finder),
+	))(ctx)
+}
+
+// StartDiagnostic clicks the run button of the opened diagnostic page and waits for the run to start
+func StartDiagnostic(ctx context.Context, ui *uiauto.Context, dashboardPath string) error {
+	run, err := Finder(RunBatteryCheck, dashboardPath, "dashboard")
+	if err != nil {
+		return err
+	}
+	running, err := Finder(RunBatteryCheckDisabled, dashboardPath, "dashboard")
+	if err != nil {
+		return err
+	}
+	return TraceAction("click", RunBatteryCheck, run.Pretty(), uiauto.Combine("start the diagnostic",
+		ui.WithTimeout(time.Minute).WaitUntilExists(run),
+		ui.LeftClick(run),
+		ui.WithTimeout(diagnosticStartTimeout).WaitUntilExists(running),
+	))(ctx)
+}
+
+// WaitDiagnostic waits for the run button of the diagnostic page to be enabled again
+func WaitDiagnostic(ctx context.Context, ui *uiauto.Context, dashboardPath string, timeout time.Duration) error {
+	running, err := Finder(RunBatteryCheckDisabled, dashboardPath, "dashboard")
+	if err != nil {
+		return err
+	}
+	return TraceAction("wait", RunBatteryCheckDisabled, running.Pretty(),
+		ui.WithTimeout(timeout).WaitUntilGone(running),
+	)(ctx)
+}
+
+// DiagnosticRunning tells whether the run button of the diagnostic page is disabled now
+func DiagnosticRunning(ctx context.Context, ui *uiauto.Context, dashboardPath string) (bool, error) {
+	running, err := Finder(RunBatteryCheckDisabled, dashboardPath, "dashboard")
+	if err != nil {
+		return false, err
+	}
+	return ui.IsNodeFound(ctx, running)
+}
+
+// RunDiagnostic opens the diagnostic, runs it until the run button is enabled again and goes back to the dashboard.
+// It returns how long the run took, from the click on the run button.
+func RunDiagnostic(ctx context.Context, ui *uiauto.Context, name, dashboardPath string, timeout time.Duration) (elapsed time.Duration, err error) {
+	step := StartStep(ctx, "diagnostic", name, "")
+	step.Attempt()
+	defer func() { step.Done(err) }()
+	if err := OpenDiagnostic(ctx, ui, name, dashboardPath); err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	if err := StartDiagnostic(ctx, ui, dashboardPath); err != nil {
+		return 0, errors.Wrapf(err, "failed to start %v", name)
+	}
+	if err := WaitDiagnostic(ctx, ui, dashboardPath, timeout); err != nil {
+		return time.Since(start), errors.Wrapf(err, "%v did not finish in %v", name, timeout)
+	}
+	elapsed = time.Since(start)
+	if err := CloseDiagnostic(ctx, ui, name, dashboardPath); err != nil {
+		return elapsed, err
+	}
+	return elapsed, nil
+}
+
+// runAndRead runs the diagnostic like RunDiagnostic, but reads its result with read before it goes back to the dashboard.
+// read is polled for 10 seconds, the result may be shown a little after the run button is enabled again.
+func runAndRead(ctx context.Context, ui *uiauto.Context, name, dashboardPath string, timeout time.Duration, read func(context.Context) error) (err error) {
+	step := StartStep(ctx, "diagnostic", name, "")
+	step.Attempt()
+	defer func() { step.Done(err) }()
+	if err := OpenDiagnostic(ctx, ui, name, dashboardPath); err != nil {
+		return err
+	}
+	if err := StartDiagnostic(ctx, ui, dashboardPath); err != nil {
+		return errors.Wrapf(err, "failed to start %v", name)
+	}
+	if err := WaitDiagnostic(ctx, ui, dashboardPath, timeout); err != nil {
+		return errors.Wrapf(err, "%v did not finish in %v", name, timeout)
+	}
+	if err := testing.Poll(ctx, read, &testing.PollOptions{Interval: time.Second, Timeout: 10 * time.Second}); err != nil {
+		return errors.Wrapf(err, "failed to read the result of %v", name)
+	}
+	return CloseDiagnostic(ctx, ui, name, dashboardPath)
+}
+
+// Keys of the messages of the diagnostic pages in the diagnostic messages
+const (
+	// DiagnosticCancelled is shown when the run was cancelled
+	DiagnosticCancelled = "cancelled"
+	// DiagnosticFailed is shown when a component sub-test failed
+	DiagnosticFailed = "failed"
+)
+
+// GetDiagnosticMessagesJSON reads the message patterns of the locale from the path, keyed by message.
+// It returns nil when the file has nothing for the locale.
+func GetDiagnosticMessagesJSON(locale, path string) (map[string]*regexp.Regexp, error) {
+	jsonData, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not read json from : %q ", path)
+	}
+	var messages map[string]map[string]string
+	if err := json.Unmarshal(jsonData, &messages); err != nil {
+		return nil, errors.Wrapf(err, "can not parse json from : %q ", path)
+	}
+	if messages[locale] == nil {
+		return nil, nil
+	}
+	patterns := make(map[string]*regexp.Regexp)
+	for key, pattern := range messages[locale] {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "bad pattern for %v in %v", key, locale)
+		}
+		patterns[key] = re
+	}
+	return patterns, nil
+}
+
+// DiagnosticResultArea returns the part of the opened diagnostic page where it shows the result of the run.
+// It is the page below the back button of the diagnostic, which leaves out the header and the navigation of HPSA.
+func DiagnosticResultArea(ctx context.Context, ui *uiauto.Context, name, dashboardPath string) (coords.Rect, error) {
+	back, ok := diagnosticBack[name]
+	if !ok {
+		return coords.Rect{}, errors.Errorf("unknown diagnostic %v", name)
+	}
+	finder, err := Finder(back, dashboardPath, "dashboard")
+	if err != nil {
+		return coords.Rect{}, err
+	}
+	backLoc, err := ui.Location(ctx, finder)
+	if err != nil {
+		return coords.Rect{}, errors.Wrapf(err, "failed to get the location of %v", back)
+	}
+	page, err := ui.Location(ctx, nodewith.Role(role.RootWebArea).First())
+	if err != nil {
+		return coords.Rect{}, errors.Wrap(err, "failed to get the page bounds")
+	}
+	return coords.NewRect(page.Left, backLoc.Bottom(), page.Width, page.Bottom()-backLoc.Bottom()), nil
+}
+
+// CenteredIn tells whether the center of the rectangle is in the area
+func CenteredIn(area, r coords.Rect) bool {
+	c := r.CenterPoint()
+	return c.X >= area.Left && c.X < area.Right() && c.Y >= area.Top && c.Y < area.Bottom()
+}
+
+// DiagnosticPassed tells whether the opened diagnostic shows a pass image in its result area.
+// Every diagnostic page uses the image of CPUCheckPassImage.
+func DiagnosticPassed(ctx context.Context, ui *uiauto.Context, name, dashboardPath string) (bool, error) {
+	area, err := DiagnosticResultArea(ctx, ui, name, dashboardPath)
+	if err != nil {
+		return false, err
+	}
+	passClass, _, err := GetJSONDashboard(CPUCheckPassImage, dashboardPath)
+	if err != nil {
+		return false, errors.Wrapf(err, "can not get the json data for %v", CPUCheckPassImage)
+	}
+	images, err := ui.NodesInfo(ctx, nodewith.HasClass(passClass))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to read the pass images")
+	}
+	for _, image := range images {
+		if CenteredIn(area, image.Location) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// WaitDiagnosticMessage waits for a text in the result area of the opened diagnostic matching the pattern and returns it
+func WaitDiagnosticMessage(ctx context.Context, ui *uiauto.Context, name, dashboardPath string, pattern *regexp.Regexp, timeout time.Duration) (string, error) {
+	var message string
+	err := TraceAction("wait", "DiagnosticMessage", pattern.String(), func(ctx context.Context) error {
+		return testing.Poll(ctx, func(ctx context.Context) error {
+			area, err := DiagnosticResultArea(ctx, ui, name, dashboardPath)
+			if err != nil {
+				return err
+			}
+			texts, err := ui.NodesInfo(ctx, nodewith.Role(role.StaticText).NameRegex(pattern))
+			if err != nil {
+				return errors.Wrap(err, "failed to read the texts")
+			}
+			for _, text := range texts {
+				if CenteredIn(area, text.Location) {
+					message = text.Name
+					return nil
+				}
+			}
+			return errors.Errorf("no text matching %v in the result area of %v", pattern, name)
+		}, &testing.PollOptions{Interval: time.Second, Timeout: timeout})
+	})(ctx)
+	return message, err
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"bufio"
+	"chromiumos/tast/local/chrome/ashproc"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// EnvironmentFile is the environment of the test written to OutDir next to the trace
+const EnvironmentFile = "hpsa_env.json"
+
+// Environment is what the test ran on, a field is empty when it could not be read
+type Environment struct {
+	HPSAVersion   string `json:"hpsaVersion"`
+	ChromeVersion string `json:"chromeVersion"`
+	Board         string `json:"board"`
+	OSVersion     string `json:"osVersion"`
+	Locale        string `json:"locale"`
+}
+
+// ReadEnvironment reads the environment of the running Chrome and HPSA
+func ReadEnvironment(ctx context.Context) Environment {
+	var env Environment
+	if b, err := ioutil.ReadFile(filepath.Join(ExtensionDir, "manifest.json")); err == nil {
+		var manifest struct {
+			Version string `json:"version"`
+		}
+		if json.Unmarshal(b, &manifest) == nil {
+			env.HPSAVersion = manifest.Version
+		}
+	}
+	// It prints e.g. "Google Chrome 114.0.5735.90".
+	if out, err := exec.CommandContext(ctx, "/opt/google/chrome/chrome", "--version").Output(); err == nil {
+		fields := strings.Fields(string(out))
+		if len(fields) > 0 {
+			env.ChromeVersion = fields[len(fields)-1]
+		}
+	}
+	lsb := readLSBRelease()
+	env.Board = lsb["CHROMEOS_RELEASE_BOARD"]
+	env.OSVersion = lsb["CHROMEOS_RELEASE_VERSION"]
+	// The tests start Chrome with the language of the test, e.g. --lang=de-DE.
+	if proc, err := ashproc.Root(); err == nil {
+		if args, err := proc.CmdlineSlice(); err == nil {
+			for _, arg := range args {
+				if strings.HasPrefix(arg, "--lang=") {
+					env.Locale = LocaleFromLanguage(arg)
+				}
+			}
+		}
+	}
+	return env
+}
+
+// WriteEnvironment writes the environment to the directory
+func WriteEnvironment(ctx context.Context, dir string) error {
+	b, err := json.MarshalIndent(ReadEnvironment(ctx), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, EnvironmentFile), b, 0644)
+}
+
+func readLSBRelease() map[string]string {
+	kv := make(map[string]string)
+	f, err := os.Open("/etc/lsb-release")
+	if err != nil {
+		return kv
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if i := strings.Index(sc.Text(), "="); i > 0 {
+			kv[sc.Text()[:i]] = sc.Text()[i+1:]
+		}
+	}
+	return kv
+}
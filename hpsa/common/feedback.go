@@ -0,0 +1,274 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/input"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.chromium.org/tast/core/errors"
+)
+
+const (
+	// feedbackPath is the end of the URL path the feedback is posted to.
+	// It is a whole path segment, so page assets like feedback.svg or feedback-form.js never match.
+	feedbackPath = "/feedback"
+	// privacyURLPart is part of the URL opened by the privacy statement link
+	privacyURLPart = "privacy"
+	// feedbackTimeout is how long the dialog has to react
+	feedbackTimeout = 30 * time.Second
+)
+
+// FeedbackEndpoint matches the POST of the feedback to the backend
+var FeedbackEndpoint = BackendStub{Method: "POST", Path: feedbackPath}
+
+// feedbackStars are the star buttons, the first one is one star
+var feedbackStars = []string{OneStar, TwoStars, ThreeStars, FourStars, FiveStars}
+
+// ratingKey matches the whole JSON or form key the rating is sent under, so e.g. duration never counts as a rating
+var ratingKey = regexp.MustCompile(`(?i)^(rating|stars|starRating|score)$`)
+
+type locator struct {
+	class string
+	nth   int
+}
+
+// FeedbackDialog drives the feedback popup opened from the menu bar
+type FeedbackDialog struct {
+	cr       *chrome.Chrome
+	ui       *uiauto.Context
+	kb       *input.KeyboardEventWriter
+	locators map[string]locator
+}
+
+// OpenFeedbackDialog clicks the feedback button on the dashboard and waits for the rating stars
+func OpenFeedbackDialog(ctx context.Context, cr *chrome.Chrome, ui *uiauto.Context, dashboardPath string) (*FeedbackDialog, error) {
+	locators := make(map[string]locator)
+	for _, name := range append([]string{Feedback, FeedbackTextboxunselect, FeedbackLink, FeedbackSubmit, FeedbackCancel}, feedbackStars...) {
+		class, nth, err := GetJSONDashboard(name, dashboardPath)
+		if err != nil || class == "" {
+			return nil, errors.Wrapf(err, "can not get the json data for %v", name)
+		}
+		locators[name] = locator{class, nth}
+	}
+	kb, err := input.Keyboard(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the keyboard")
+	}
+	d := &FeedbackDialog{cr: cr, ui: ui, kb: kb, locators: locators}
+	if err := uiauto.Combine("open the feedback dialog",
+		d.click(Feedback),
+		ui.WithTimeout(feedbackTimeout).WaitUntilExists(d.finder(OneStar)),
+	)(ctx); err != nil {
+		kb.Close(ctx)
+		return nil, err
+	}
+	return d, nil
+}
+
+// Release closes the keyboard of the dialog
+func (d *FeedbackDialog) Release(ctx context.Context) error {
+	return d.kb.Close(ctx)
+}
+
+func (d *FeedbackDialog) finder(name string) *nodewith.Finder {
+	l := d.locators[name]
+	return nodewith.HasClass(l.class).Nth(l.nth)
+}
+
+func (d *FeedbackDialog) click(name string) uiauto.Action {
+	l := d.locators[name]
+	return TraceAction("click", name, Locator(l.class, l.nth), uiauto.Combine(fmt.Sprintf("click the %v button", name),
+		d.ui.WithTimeout(feedbackTimeout).WaitUntilExists(d.finder(name)),
+		d.ui.LeftClick(d.finder(name)),
+	))
+}
+
+// SetRating clicks the star button for the rating, from 1 to 5
+func (d *FeedbackDialog) SetRating(ctx context.Context, stars int) error {
+	if stars < 1 || stars > len(feedbackStars) {
+		return errors.Errorf("rating %d is not between 1 and %d", stars, len(feedbackStars))
+	}
+	return d.click(feedbackStars[stars-1])(ctx)
+}
+
+// textbox is the comment box, only the feedback form has one
+func (d *FeedbackDialog) textbox() *nodewith.Finder {
+	// The text box changes its class once it is touched, only the first class is stable.
+	return nodewith.HasClass(d.locators[FeedbackTextboxunselect].class).First()
+}
+
+// formButton finds the button of the feedback form with the class of the locator.
+// The primary and secondary button classes are used all over the dashboard, the
+// buttons of the form are the closest ones below its comment box.
+func (d *FeedbackDialog) formButton(ctx context.Context, name string) (*nodewith.Finder, error) {
+	box, err := d.ui.Info(ctx, d.textbox())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the feedback comment box")
+	}
+	class := d.locators[name].class
+	nodes, err := d.ui.NodesInfo(ctx, nodewith.HasClass(class))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the %v buttons", name)
+	}
+	nth := -1
+	for i, n := range nodes {
+		if n.Location.Top < box.Location.Bottom() || n.Location.Left > box.Location.Right() || n.Location.Right() < box.Location.Left {
+			continue
+		}
+		if nth < 0 || n.Location.Top < nodes[nth].Location.Top {
+			nth = i
+		}
+	}
+	if nth < 0 {
+		return nil, errors.Errorf("no %v button below the feedback comment box", name)
+	}
+	return nodewith.HasClass(class).Nth(nth), nil
+}
+
+// clickFormButton clicks a button of the feedback form, see formButton
+func (d *FeedbackDialog) clickFormButton(name string) uiauto.Action {
+	return func(ctx context.Context) error {
+		step := StartStep(ctx, "click", name, Locator(d.locators[name].class, d.locators[name].nth))
+		step.Attempt()
+		button, err := d.formButton(ctx, name)
+		if err != nil {
+			return step.Done(err)
+		}
+		return step.Done(d.ui.LeftClick(button)(ctx))
+	}
+}
+
+// TypeComment clicks the text box and types the comment
+func (d *FeedbackDialog) TypeComment(ctx context.Context, comment string) error {
+	textbox := d.textbox()
+	return uiauto.Combine("type the feedback comment",
+		d.ui.WithTimeout(feedbackTimeout).WaitUntilExists(textbox),
+		d.ui.LeftClick(textbox),
+		d.kb.TypeAction(comment),
+	)(ctx)
+}
+
+// OpenPrivacyLink clicks the privacy statement link and waits for the new tab.
+// It returns the URL of the tab and closes it.
+func (d *FeedbackDialog) OpenPrivacyLink(ctx context.Context) (string, error) {
+	isPrivacy := func(t *chrome.Target) bool {
+		return t.Type == "page" && strings.Contains(strings.ToLower(t.URL), privacyURLPart)
+	}
+	before, err := d.cr.FindTargets(ctx, isPrivacy)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list the tabs")
+	}
+	opened := make(map[string]bool)
+	for _, t := range before {
+		opened[string(t.TargetID)] = true
+	}
+	if err := d.click(FeedbackLink)(ctx); err != nil {
+		return "", err
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, feedbackTimeout)
+	defer cancel()
+	conn, err := d.cr.NewConnForTarget(waitCtx, func(t *chrome.Target) bool {
+		return isPrivacy(t) && !opened[string(t.TargetID)]
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "the privacy statement did not open in a new tab")
+	}
+	defer conn.Close()
+	var href string
+	if err := conn.Eval(ctx, "location.href", &href); err != nil {
+		return "", errors.Wrap(err, "failed to read the privacy statement URL")
+	}
+	if err := conn.CloseTarget(ctx); err != nil {
+		return href, errors.Wrap(err, "failed to close the privacy statement tab")
+	}
+	return href, nil
+}
+
+// Submit clicks the submit button of the form and waits for the dialog to close
+func (d *FeedbackDialog) Submit(ctx context.Context) error {
+	return uiauto.Combine("submit the feedback",
+		d.clickFormButton(FeedbackSubmit),
+		d.ui.WithTimeout(feedbackTimeout).WaitUntilGone(d.textbox()),
+	)(ctx)
+}
+
+// Cancel clicks the cancel button of the form and waits for the dialog to close
+func (d *FeedbackDialog) Cancel(ctx context.Context) error {
+	return uiauto.Combine("cancel the feedback",
+		d.clickFormButton(FeedbackCancel),
+		d.ui.WithTimeout(feedbackTimeout).WaitUntilGone(d.textbox()),
+	)(ctx)
+}
+
+// CheckFeedbackRequest verifies the posted feedback carries the rating and the comment.
+// The body is read as JSON, or as a form when it is not JSON.
+func CheckFeedbackRequest(req CapturedRequest, rating int, comment string) error {
+	if !strings.EqualFold(req.Method, "POST") && !strings.EqualFold(req.Method, "PUT") {
+		return errors.Errorf("feedback was sent with %v, want POST or PUT", req.Method)
+	}
+	if req.Status < 200 || req.Status >= 300 {
+		return errors.Errorf("feedback request to %v got status %d", req.URL, req.Status)
+	}
+	values := make(map[string][]string)
+	var body interface{}
+	if err := json.Unmarshal([]byte(req.Body), &body); err == nil {
+		flatten("", body, values)
+	} else if form, err := url.ParseQuery(req.Body); err == nil {
+		for k, v := range form {
+			values[k] = append(values[k], v...)
+		}
+	} else {
+		return errors.Errorf("can not parse the feedback body %q", req.Body)
+	}
+	foundRating, foundComment := false, false
+	for key, vs := range values {
+		for _, v := range vs {
+			if n, err := strconv.Atoi(v); err == nil && n == rating && ratingKey.MatchString(key) {
+				foundRating = true
+			}
+			if strings.TrimSpace(v) == strings.TrimSpace(comment) {
+				foundComment = true
+			}
+		}
+	}
+	if !foundRating {
+		return errors.Errorf("feedback body %q has no rating %d", req.Body, rating)
+	}
+	if comment != "" && !foundComment {
+		return errors.Errorf("feedback body %q has no comment %q", req.Body, comment)
+	}
+	return nil
+}
+
+// flatten collects every scalar of a decoded JSON value under its innermost key
+func flatten(key string, v interface{}, values map[string][]string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			flatten(k, child, values)
+		}
+	case []interface{}:
+		for _, child := range v {
+			flatten(key, child, values)
+		}
+	case float64:
+		values[key] = append(values[key], strconv.FormatFloat(v, 'f', -1, 64))
+	case string:
+		values[key] = append(values[key], v)
+	case bool:
+		values[key] = append(values[key], strconv.FormatBool(v))
+	}
+}
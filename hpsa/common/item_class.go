@@ -6,6 +6,7 @@
 package common
 
 import (
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -100,3 +101,19 @@ func GetJSONDashboard(name, path string) (string, int, error) {
 	return "", 0, errors.Wrapf(err, "can not find json data : %q ", name)
 
 }
+
+// Finder gets the finder of the element from the json at path, key is "welcome" for hpsa.json and "dashboard" for dashboard.json
+func Finder(name, path, key string) (*nodewith.Finder, error) {
+	get := GetJSON
+	if key == "dashboard" {
+		get = GetJSONDashboard
+	}
+	class, nth, err := get(name, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not get the json data for %v", name)
+	}
+	if class == "" {
+		return nil, errors.Errorf("no locator for %v in %v", name, path)
+	}
+	return nodewith.HasClass(class).Nth(nth), nil
+}
@@ -0,0 +1,319 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/chrome/uiauto/restriction"
+	"chromiumos/tast/local/chrome/uiauto/role"
+	"chromiumos/tast/local/chrome/uiauto/state"
+	"chromiumos/tast/local/input"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+// Keys used by the keyboard navigator, nothing else is pressed
+const (
+	KeyTab      = "Tab"
+	KeyShiftTab = "Shift+Tab"
+	KeyEnter    = "Enter"
+	KeySpace    = "Space"
+	KeyUp       = "Up"
+	KeyDown     = "Down"
+	KeyLeft     = "Left"
+	KeyRight    = "Right"
+)
+
+// maxFocusStops is the number of Tab presses before the navigator gives up, HPSA pages have far fewer controls
+const maxFocusStops = 80
+
+// FocusOrder set up json for the expected focus order of each page
+type FocusOrder struct {
+	Welcome   []string `json:"welcome"`
+	Dashboard []string `json:"dashboard"`
+}
+
+// GetFocusOrderJSON reads the expected focus order from the path
+func GetFocusOrderJSON(path string) (*FocusOrder, error) {
+	jsonData, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not read json from : %q ", path)
+	}
+	var order FocusOrder
+	if err := json.Unmarshal(jsonData, &order); err != nil {
+		return nil, errors.Wrapf(err, "can not parse json from : %q ", path)
+	}
+	return &order, nil
+}
+
+// KeyboardNavigator moves through HPSA with Tab, Shift+Tab, Enter, Space and the arrow keys only
+type KeyboardNavigator struct {
+	kb   *input.KeyboardEventWriter
+	ui   *uiauto.Context
+	root *nodewith.Finder
+}
+
+// NewKeyboardNavigator opens a virtual keyboard for the navigator
+func NewKeyboardNavigator(ctx context.Context, ui *uiauto.Context) (*KeyboardNavigator, error) {
+	kb, err := input.Keyboard(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the keyboard")
+	}
+	return &KeyboardNavigator{kb: kb, ui: ui, root: nodewith.Role(role.RootWebArea).First()}, nil
+}
+
+// Close releases the keyboard
+func (k *KeyboardNavigator) Close(ctx context.Context) error {
+	return k.kb.Close(ctx)
+}
+
+// Press presses one of the navigation keys
+func (k *KeyboardNavigator) Press(ctx context.Context, key string) error {
+	switch key {
+	case KeyTab, KeyShiftTab, KeyEnter, KeySpace, KeyUp, KeyDown, KeyLeft, KeyRight:
+	default:
+		return errors.Errorf("%q is not a navigation key", key)
+	}
+	if err := k.kb.Accel(ctx, key); err != nil {
+		return errors.Wrapf(err, "failed to press %v", key)
+	}
+	// Give the page time to move the focus before it is read back.
+	return testing.Sleep(ctx, 200*time.Millisecond)
+}
+
+// Focused returns the node which has the keyboard focus in the HPSA page
+func (k *KeyboardNavigator) Focused(ctx context.Context) (*uiauto.NodeInfo, error) {
+	return k.ui.Info(ctx, nodewith.Focused().Ancestor(k.root).First())
+}
+
+// FocusOn presses Tab until the target has the focus.
+// Nodes are matched by their bounds so Nth finders land on the right instance of a shared class.
+func (k *KeyboardNavigator) FocusOn(ctx context.Context, target *nodewith.Finder) error {
+	if err := k.ui.WaitUntilExists(target)(ctx); err != nil {
+		return errors.Wrap(err, "failed to find the target")
+	}
+	want, err := k.ui.Info(ctx, target)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the target info")
+	}
+	for i := 0; i < maxFocusStops; i++ {
+		if focused, err := k.Focused(ctx); err == nil && sameNode(*focused, *want) {
+			return nil
+		}
+		if err := k.Press(ctx, KeyTab); err != nil {
+			return err
+		}
+	}
+	return errors.Errorf("%v .%v is not reachable with %d Tab presses", want.Role, want.ClassName, maxFocusStops)
+}
+
+// Activate focuses the target and presses Enter, or Space for checkboxes
+func (k *KeyboardNavigator) Activate(ctx context.Context, target *nodewith.Finder) error {
+	if err := k.FocusOn(ctx, target); err != nil {
+		return err
+	}
+	focused, err := k.Focused(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the focused node")
+	}
+	if focused.Role == role.CheckBox {
+		return k.Press(ctx, KeySpace)
+	}
+	return k.Press(ctx, KeyEnter)
+}
+
+// SelectOption presses Down in the open dropdown until the option has the focus and picks it with Enter.
+// Options are matched by name too, as some dropdowns keep the focus on the list and only move the active option.
+func (k *KeyboardNavigator) SelectOption(ctx context.Context, option *nodewith.Finder) error {
+	want, err := k.ui.Info(ctx, option)
+	if err != nil {
+		return errors.Wrap(err, "failed to find the option")
+	}
+	for i := 0; i < maxFocusStops; i++ {
+		if focused, err := k.Focused(ctx); err == nil && (sameNode(*focused, *want) || (want.Name != "" && focused.Name == want.Name)) {
+			return k.Press(ctx, KeyEnter)
+		}
+		if err := k.Press(ctx, KeyDown); err != nil {
+			return err
+		}
+	}
+	return errors.Errorf("option %q is not reachable with %d Down presses", want.Name, maxFocusStops)
+}
+
+// TabCycle presses Tab, or Shift+Tab, until the focus comes back to where it started and returns every focus stop in order
+func (k *KeyboardNavigator) TabCycle(ctx context.Context, key string) ([]uiauto.NodeInfo, error) {
+	if key != KeyTab && key != KeyShiftTab {
+		return nil, errors.Errorf("%q does not move the focus", key)
+	}
+	var stops []uiauto.NodeInfo
+	for i := 0; i < maxFocusStops; i++ {
+		if err := k.Press(ctx, key); err != nil {
+			return nil, err
+		}
+		focused, err := k.Focused(ctx)
+		if err != nil {
+			// The focus is on the browser frame between the last and the first control.
+			continue
+		}
+		if len(stops) > 0 && sameNode(*focused, stops[0]) {
+			return stops, nil
+		}
+		stops = append(stops, *focused)
+	}
+	return stops, errors.Errorf("focus did not cycle within %d Tab presses", maxFocusStops)
+}
+
+// Unreachable returns the enabled interactive controls of the page which are not in the focus stops
+func (k *KeyboardNavigator) Unreachable(ctx context.Context, stops []uiauto.NodeInfo) ([]uiauto.NodeInfo, error) {
+	var missing []uiauto.NodeInfo
+	for _, r := range layoutRoles {
+		nodes, err := k.ui.NodesInfo(ctx, nodewith.Role(r).Ancestor(k.root))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the %v nodes", r)
+		}
+		for _, n := range nodes {
+			if n.State[state.Invisible] || n.Restriction == restriction.Disabled {
+				continue
+			}
+			if !containsNode(stops, n) {
+				missing = append(missing, n)
+			}
+		}
+	}
+	return missing, nil
+}
+
+// FocusTarget is a control expected in the focus order, Name is its name in the locator json
+type FocusTarget struct {
+	Name string
+	Node uiauto.NodeInfo
+}
+
+// Target finds the nth control of the class, so controls sharing a class are told apart by their bounds and name
+func (k *KeyboardNavigator) Target(ctx context.Context, name, class string, nth int) (FocusTarget, error) {
+	info, err := k.ui.Info(ctx, nodewith.HasClass(class).Nth(nth))
+	if err != nil {
+		return FocusTarget{}, errors.Wrapf(err, "failed to find %v", name)
+	}
+	return FocusTarget{Name: name, Node: *info}, nil
+}
+
+// CheckFocusOrder verifies the expected controls appear in the focus stops in the same order.
+// Controls which are not in the expected list may sit between them.
+func CheckFocusOrder(stops []uiauto.NodeInfo, expected []FocusTarget) error {
+	next := 0
+	for _, stop := range stops {
+		if next < len(expected) && sameNode(stop, expected[next].Node) {
+			next++
+		}
+	}
+	if next < len(expected) {
+		var got []string
+		for _, stop := range stops {
+			got = append(got, nodeLabel(stop))
+		}
+		return errors.Errorf("focus order stops before %v, got %v", expected[next].Name, strings.Join(got, " -> "))
+	}
+	return nil
+}
+
+// CheckReverseOrder verifies Shift+Tab visits the same focus stops as Tab in the opposite direction
+func CheckReverseOrder(forward, backward []uiauto.NodeInfo) error {
+	if len(forward) != len(backward) {
+		return errors.Errorf("Tab reaches %d controls but Shift+Tab reaches %d", len(forward), len(backward))
+	}
+	if len(forward) == 0 {
+		return nil
+	}
+	// Both cycles are rotations of the same ring, align them on the first forward stop.
+	start := -1
+	for i, n := range backward {
+		if sameNode(n, forward[0]) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return errors.Errorf("Shift+Tab never reaches %v .%v", forward[0].Role, forward[0].ClassName)
+	}
+	for i := range forward {
+		n := backward[(start-i+len(backward))%len(backward)]
+		if !sameNode(n, forward[i]) {
+			return errors.Errorf("Shift+Tab reaches %v .%v where Tab reaches %v .%v", n.Role, n.ClassName, forward[i].Role, forward[i].ClassName)
+		}
+	}
+	return nil
+}
+
+func sameNode(a, b uiauto.NodeInfo) bool {
+	return a.Role == b.Role && a.ClassName == b.ClassName && a.Name == b.Name && a.Location == b.Location
+}
+
+func containsNode(nodes []uiauto.NodeInfo, n uiauto.NodeInfo) bool {
+	for _, node := range nodes {
+		if sameNode(node, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyboardWelcomeBtnsNTH is using to activate the element in welcome with the keyboard
+func KeyboardWelcomeBtnsNTH(ctx context.Context, s *testing.State, bt browser.Type, kn *KeyboardNavigator, element, elementClass string, nth int) (string, error) {
+	s.Logf("Asserting that keyboard works on the %v button in %v browser", element, bt)
+	step := StartStep(ctx, "key", element, Locator(elementClass, nth))
+	step.Attempt()
+	if err := step.Done(kn.Activate(ctx, nodewith.HasClass(elementClass).Nth(nth))); err != nil {
+		return fmt.Sprintf("Failed to reach %v with the keyboard", element), err
+	}
+	return "Sucessfully activated", nil
+}
+
+// KeyboardWelcomeBeforeConsent are the welcome steps up to the data consent page
+var KeyboardWelcomeBeforeConsent = []string{Letsstart, LaunchHPSupportAssistant, SelectRegion, SelectRegionUS, ContinueBTN, DonotShowAgain, ContinueAsGuest}
+
+// KeyboardWelcomeConsent are the welcome steps from the data consent page to the dashboard
+var KeyboardWelcomeConsent = []string{WarrantyOption, UsageData, ImproveMyExperience, ClosePinPopup}
+
+// KeyboardWelcomeSteps runs the welcome steps with the keyboard only
+func KeyboardWelcomeSteps(ctx context.Context, s *testing.State, bt browser.Type, kn *KeyboardNavigator, path string, elements []string) (string, error) {
+	for _, element := range elements {
+		class, nth, err := GetJSON(element, path)
+		if err != nil {
+			return "Can not get the json data for " + element, err
+		}
+		if element == SelectRegionUS {
+			// The region dropdown is open, the arrow keys move to the US option.
+			if err := kn.SelectOption(ctx, nodewith.HasClass(class).Nth(nth)); err != nil {
+				return "Failed to select the US region", err
+			}
+			continue
+		}
+		if tips, err := KeyboardWelcomeBtnsNTH(ctx, s, bt, kn, element, class, nth); err != nil {
+			return tips, err
+		}
+	}
+	return "", nil
+}
+
+// PreTestKeyboard is a function to navigate to dashboard for HPSA with the keyboard only
+func PreTestKeyboard(ctx context.Context, s *testing.State, bt browser.Type, kn *KeyboardNavigator, path string) (string, error) {
+	if tips, err := KeyboardWelcomeSteps(ctx, s, bt, kn, path, KeyboardWelcomeBeforeConsent); err != nil {
+		return tips, err
+	}
+	if tips, err := KeyboardWelcomeSteps(ctx, s, bt, kn, path, KeyboardWelcomeConsent); err != nil {
+		return tips, err
+	}
+	return "Successful navigate to dashboard", nil
+}
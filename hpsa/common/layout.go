@@ -0,0 +1,189 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/apps"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/ash"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/chrome/uiauto/role"
+	"chromiumos/tast/local/chrome/uiauto/state"
+	"chromiumos/tast/local/coords"
+	"context"
+	"fmt"
+	"strings"
+
+	"go.chromium.org/tast/core/errors"
+)
+
+// WindowLayout is the HPSA window size used by the layout matrix
+type WindowLayout string
+
+const (
+	// WindowMaximized is the maximized HPSA window
+	WindowMaximized WindowLayout = "maximized"
+	// WindowSnappedHalf is the HPSA window snapped to the left half
+	WindowSnappedHalf WindowLayout = "snapped_half"
+	// WindowMinimumSize is the HPSA window shrunk to its minimum size
+	WindowMinimumSize WindowLayout = "minimum_size"
+)
+
+// LayoutIssue is a control which is clipped by the window or overlaps another control
+type LayoutIssue struct {
+	Kind    string      `json:"kind"`
+	Node    string      `json:"node"`
+	Other   string      `json:"other,omitempty"`
+	Bounds  coords.Rect `json:"bounds"`
+	Visible coords.Rect `json:"visible"`
+}
+
+func (i LayoutIssue) String() string {
+	if i.Other != "" {
+		return fmt.Sprintf("%v: %q and %q at %v", i.Kind, i.Node, i.Other, i.Bounds)
+	}
+	return fmt.Sprintf("%v: %q at %v, visible area %v", i.Kind, i.Node, i.Bounds, i.Visible)
+}
+
+// layoutRoles are the roles of the controls the user has to see and click
+var layoutRoles = []role.Role{role.Button, role.Link, role.CheckBox, role.ComboBoxSelect, role.TextField}
+
+// FindHPSAWindow returns the HPSA app window
+func FindHPSAWindow(ctx context.Context, tconn *chrome.TestConn) (*ash.Window, error) {
+	return ash.FindWindow(ctx, tconn, func(w *ash.Window) bool {
+		return strings.Contains(w.Title, apps.HPSA.Name)
+	})
+}
+
+// SetWindowLayout resizes the HPSA window to the layout
+func SetWindowLayout(ctx context.Context, tconn *chrome.TestConn, layout WindowLayout) error {
+	w, err := FindHPSAWindow(ctx, tconn)
+	if err != nil {
+		return errors.Wrap(err, "failed to find the HPSA window")
+	}
+	switch layout {
+	case WindowMaximized:
+		return ash.SetWindowStateAndWait(ctx, tconn, w.ID, ash.WindowStateMaximized)
+	case WindowSnappedHalf:
+		return ash.SetWindowStateAndWait(ctx, tconn, w.ID, ash.WindowStatePrimarySnapped)
+	case WindowMinimumSize:
+		if err := ash.SetWindowStateAndWait(ctx, tconn, w.ID, ash.WindowStateNormal); err != nil {
+			return err
+		}
+		// Ash clamps the bounds to the minimum size of the window.
+		bounds := coords.NewRect(w.BoundsInRoot.Left, w.BoundsInRoot.Top, 1, 1)
+		if _, _, err := ash.SetWindowBounds(ctx, tconn, w.ID, bounds, w.DisplayID); err != nil {
+			return errors.Wrap(err, "failed to shrink the HPSA window")
+		}
+		return nil
+	}
+	return errors.Errorf("unknown window layout %q", layout)
+}
+
+// CollectLayoutNodes gets the interactive controls of the HPSA page and the bounds of the page.
+// Controls inside a scroll container are left out, the container clips them by design and the user scrolls to them.
+func CollectLayoutNodes(ctx context.Context, ui *uiauto.Context) ([]uiauto.NodeInfo, coords.Rect, error) {
+	rootWebArea := nodewith.Role(role.RootWebArea).First()
+	viewport, err := ui.Location(ctx, rootWebArea)
+	if err != nil {
+		return nil, coords.Rect{}, errors.Wrap(err, "failed to get the page bounds")
+	}
+	scrollable := nodewith.Attribute("scrollable", true).Ancestor(rootWebArea)
+	containers, err := ui.NodesInfo(ctx, scrollable)
+	if err != nil {
+		return nil, coords.Rect{}, errors.Wrap(err, "failed to get the scroll containers")
+	}
+	var nodes []uiauto.NodeInfo
+	for _, r := range layoutRoles {
+		infos, err := ui.NodesInfo(ctx, nodewith.Role(r).Ancestor(rootWebArea))
+		if err != nil {
+			return nil, coords.Rect{}, errors.Wrapf(err, "failed to get the %v nodes", r)
+		}
+		scrolled := make(map[string]bool)
+		for i := range containers {
+			inside, err := ui.NodesInfo(ctx, nodewith.Role(r).Ancestor(scrollable.Nth(i)))
+			if err != nil {
+				return nil, coords.Rect{}, errors.Wrapf(err, "failed to get the %v nodes of scroll container %d", r, i)
+			}
+			for _, n := range inside {
+				scrolled[nodeKey(n)] = true
+			}
+		}
+		for _, n := range infos {
+			if !scrolled[nodeKey(n)] {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	return nodes, *viewport, nil
+}
+
+// CheckLayout finds controls clipped by the viewport and controls overlapping each other.
+// Invisible, offscreen and zero sized nodes are ignored because they are not rendered in the viewport.
+func CheckLayout(nodes []uiauto.NodeInfo, viewport coords.Rect) []LayoutIssue {
+	var issues []LayoutIssue
+	var shown []uiauto.NodeInfo
+	for _, n := range nodes {
+		if n.Location.Width <= 0 || n.Location.Height <= 0 || n.State[state.Invisible] || n.State[state.Offscreen] {
+			continue
+		}
+		visible := intersect(n.Location, viewport)
+		if visible != n.Location {
+			issues = append(issues, LayoutIssue{Kind: "clipped", Node: nodeLabel(n), Bounds: n.Location, Visible: visible})
+		}
+		shown = append(shown, n)
+	}
+	for i := 0; i < len(shown); i++ {
+		for j := i + 1; j < len(shown); j++ {
+			a, b := shown[i].Location, shown[j].Location
+			// Nested controls (e.g. a link inside a button) share their bounds by design.
+			if contains(a, b) || contains(b, a) {
+				continue
+			}
+			if overlap := intersect(a, b); overlap.Width > 0 && overlap.Height > 0 {
+				issues = append(issues, LayoutIssue{Kind: "overlap", Node: nodeLabel(shown[i]), Other: nodeLabel(shown[j]), Bounds: overlap})
+			}
+		}
+	}
+	return issues
+}
+
+// nodeKey identifies a node among the results of two queries, the tree has no node ids
+func nodeKey(n uiauto.NodeInfo) string {
+	return fmt.Sprintf("%v|%v|%v|%v", n.Role, n.Name, n.ClassName, n.Location)
+}
+
+func nodeLabel(n uiauto.NodeInfo) string {
+	if n.Name != "" {
+		return fmt.Sprintf("%v %q", n.Role, n.Name)
+	}
+	return fmt.Sprintf("%v .%v", n.Role, n.ClassName)
+}
+
+func intersect(a, b coords.Rect) coords.Rect {
+	left, top := a.Left, a.Top
+	if b.Left > left {
+		left = b.Left
+	}
+	if b.Top > top {
+		top = b.Top
+	}
+	right, bottom := a.Left+a.Width, a.Top+a.Height
+	if b.Left+b.Width < right {
+		right = b.Left + b.Width
+	}
+	if b.Top+b.Height < bottom {
+		bottom = b.Top + b.Height
+	}
+	if right <= left || bottom <= top {
+		return coords.Rect{}
+	}
+	return coords.NewRect(left, top, right-left, bottom-top)
+}
+
+func contains(outer, inner coords.Rect) bool {
+	return intersect(outer, inner) == inner
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"bufio"
+	"chromiumos/tast/local/bundles/cros/hpsa/storage"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"context"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.chromium.org/tast/core/errors"
+)
+
+// MemoryTotalLabel is the key of the label of the total memory in the diagnostic messages
+const MemoryTotalLabel = "memory_total"
+
+// memTotalRange is how far the total HPSA shows may be from MemTotal.
+// MemTotal leaves out what the firmware and the kernel reserve, so HPSA may show the installed memory a little above it.
+var memTotalRange = [2]float64{0.85, 1.2}
+
+// ReadMemInfo reads /proc/meminfo into bytes by field, e.g. MemTotal
+func ReadMemInfo() (map[string]int64, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open meminfo")
+	}
+	defer f.Close()
+	info := make(map[string]int64)
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		// e.g. "MemTotal:       16223340 kB"
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		v, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) == 3 && fields[2] == "kB" {
+			v <<= 10
+		}
+		info[strings.TrimSuffix(fields[0], ":")] = v
+	}
+	return info, sc.Err()
+}
+
+// CheckMemTotal checks the total HPSA shows, e.g. "16 GB", against MemTotal in bytes.
+// A GB may be decimal or binary in the text, either reading is accepted.
+func CheckMemTotal(text string, memTotal int64) error {
+	shown, err := storage.ParseSize(text)
+	if err != nil {
+		return err
+	}
+	for _, v := range []float64{float64(shown), float64(shown) * (1 << 30) / 1e9} {
+		if v >= memTotalRange[0]*float64(memTotal) && v <= memTotalRange[1]*float64(memTotal) {
+			return nil
+		}
+	}
+	return errors.Errorf("total memory is %q, MemTotal is %v", text, storage.FormatSize(memTotal))
+}
+
+// RunMemoryCheck runs the memory check and reads the total memory it shows before it goes back to the dashboard.
+// The total is found by its label, labels are the diagnostic messages of the locale.
+func RunMemoryCheck(ctx context.Context, ui *uiauto.Context, dashboardPath string, labels map[string]*regexp.Regexp, timeout time.Duration) (string, error) {
+	pattern, ok := labels[MemoryTotalLabel]
+	if !ok {
+		return "", errors.Errorf("no label for %v", MemoryTotalLabel)
+	}
+	var total string
+	err := runAndRead(ctx, ui, CheckSystemMemory, dashboardPath, timeout, func(ctx context.Context) error {
+		var err error
+		if total, err = readLabelledSize(ctx, ui, pattern); err != nil {
+			return errors.Wrapf(err, "failed to read %v", MemoryTotalLabel)
+		}
+		return nil
+	})
+	return total, err
+}
+
+// diagnosticCancel is the cancel link of the running diagnostic, of every diagnostic page.
+// The class of CPUCheckCancel starts with the ng-tns token of the Angular component instance,
+// which changes with the page and the build, so only the stable "cancel hp-link" part is matched.
+var diagnosticCancel = nodewith.ClassNameRegex(regexp.MustCompile(`(^|\s)cancel hp-link(\s|$)`)).First()
+
+// CancelDiagnostic clicks the cancel button of the running diagnostic and waits for the run button to be enabled again
+func CancelDiagnostic(ctx context.Context, ui *uiauto.Context, dashboardPath string, timeout time.Duration) error {
+	if err := TraceAction("click", CPUCheckCancel, diagnosticCancel.Pretty(), uiauto.Combine("cancel the diagnostic",
+		ui.WaitUntilExists(diagnosticCancel),
+		ui.LeftClick(diagnosticCancel),
+	))(ctx); err != nil {
+		return err
+	}
+	return WaitDiagnostic(ctx, ui, dashboardPath, timeout)
+}
+
+// DiagnosticIdle checks the opened diagnostic page is idle: the run button is enabled and neither the cancel button nor the pass image is shown
+func DiagnosticIdle(ctx context.Context, ui *uiauto.Context, dashboardPath string) error {
+	for _, c := range []struct {
+		name  string
+		shown bool
+	}{{RunBatteryCheck, true}, {RunBatteryCheckDisabled, false}, {CPUCheckCancel, false}, {CPUCheckPassImage, false}} {
+		finder := diagnosticCancel
+		if c.name != CPUCheckCancel {
+			var err error
+			if finder, err = Finder(c.name, dashboardPath, "dashboard"); err != nil {
+				return err
+			}
+		}
+		found, err := ui.IsNodeFound(ctx, finder)
+		if err != nil {
+			return errors.Wrapf(err, "failed to find %v", c.name)
+		}
+		if found != c.shown {
+			return errors.Errorf("%v is shown %v, want %v", c.name, found, c.shown)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"context"
+	"math/rand"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mafredri/cdp/protocol/fetch"
+	"github.com/mafredri/cdp/protocol/network"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+// NetworkCondition is the network the HPSA page sees
+type NetworkCondition struct {
+	Name    string
+	Offline bool
+	// Latency is added to every request.
+	Latency time.Duration
+	// DownloadKbps and UploadKbps limit the throughput, 0 does not limit it.
+	DownloadKbps float64
+	UploadKbps   float64
+	// Blocked are URL patterns whose requests always fail, * matches anything.
+	Blocked []string
+	// Drop are URL patterns whose requests fail with the probability DropRate, from 0 to 1.
+	Drop     []string
+	DropRate float64
+}
+
+// Network condition presets
+var (
+	// NetworkNormal is the network of the DUT without emulation.
+	NetworkNormal = NetworkCondition{Name: "normal"}
+	// NetworkOffline has no connection at all.
+	NetworkOffline = NetworkCondition{Name: "offline", Offline: true}
+	// Network2G is the 2G preset of WebPageTest.
+	Network2G = NetworkCondition{Name: "2g", Latency: 800 * time.Millisecond, DownloadKbps: 280, UploadKbps: 256}
+	// NetworkHighLatency is a fast connection far away, e.g. a satellite link.
+	NetworkHighLatency = NetworkCondition{Name: "high-latency", Latency: 2 * time.Second}
+)
+
+// NetworkFlaky drops the share rate of the requests to the endpoints, an endpoint is a URL pattern
+func NetworkFlaky(rate float64, endpoints ...string) NetworkCondition {
+	return NetworkCondition{Name: "flaky", Drop: endpoints, DropRate: rate}
+}
+
+// kbpsToBytes converts a throughput for DevTools, which takes bytes per second and -1 for no limit
+func kbpsToBytes(kbps float64) float64 {
+	if kbps <= 0 {
+		return -1
+	}
+	return kbps * 1000 / 8
+}
+
+// NetworkController applies network conditions to the HPSA page and its service workers through DevTools.
+// A worker fetches for the page with its own network stack, so it gets the condition of the page too.
+type NetworkController struct {
+	// sessions are on the page first and then on the workers.
+	sessions  []*DevTools
+	condition NetworkCondition
+	stopDrop  context.CancelFunc
+	dropDone  sync.WaitGroup
+
+	mu      sync.Mutex
+	dropped int
+	passed  int
+}
+
+// NewNetworkController opens a DevTools session on the HPSA page and its running service workers, the network is normal until Apply
+func NewNetworkController(ctx context.Context) (*NetworkController, error) {
+	dt, err := NewDevTools(ctx, AppURLITG)
+	if err != nil {
+		return nil, err
+	}
+	n := &NetworkController{sessions: []*DevTools{dt}, condition: NetworkNormal}
+	if err := dt.Client.Network.Enable(ctx, network.NewEnableArgs()); err != nil {
+		n.closeSessions()
+		return nil, errors.Wrap(err, "failed to enable the network domain")
+	}
+	if err := n.addWorkers(ctx); err != nil {
+		n.closeSessions()
+		return nil, err
+	}
+	return n, nil
+}
+
+// addWorkers opens a session on the workers started since the last call.
+// A worker stops when it is idle and starts again for the next request, Apply calls this each time.
+func (n *NetworkController) addWorkers(ctx context.Context) error {
+	open := make(map[string]bool)
+	for _, dt := range n.sessions {
+		open[dt.TargetID] = true
+	}
+	workers, err := NewWorkerDevTools(ctx, AppURLITG, open)
+	if err != nil {
+		return errors.Wrap(err, "failed to open the HPSA service workers")
+	}
+	for _, dt := range workers {
+		if err := dt.Client.Network.Enable(ctx, network.NewEnableArgs()); err != nil {
+			dt.Close()
+			return errors.Wrapf(err, "failed to enable the network domain of the worker %v", dt.TargetURL)
+		}
+		testing.ContextLogf(ctx, "Applying the network conditions to the service worker %v too", dt.TargetURL)
+		n.sessions = append(n.sessions, dt)
+	}
+	return nil
+}
+
+// Apply replaces the current condition with the new one
+func (n *NetworkController) Apply(ctx context.Context, c NetworkCondition) (err error) {
+	step := StartStep(ctx, "network", c.Name, "")
+	step.Attempt()
+	defer func() { step.Done(err) }()
+	if err := n.stopDropping(ctx); err != nil {
+		return err
+	}
+	if err := n.addWorkers(ctx); err != nil {
+		return err
+	}
+	blocked := c.Blocked
+	if blocked == nil {
+		blocked = []string{}
+	}
+	for _, dt := range n.sessions {
+		if err := dt.Client.Network.EmulateNetworkConditions(ctx, network.NewEmulateNetworkConditionsArgs(
+			c.Offline, float64(c.Latency/time.Millisecond), kbpsToBytes(c.DownloadKbps), kbpsToBytes(c.UploadKbps))); err != nil {
+			return errors.Wrapf(err, "failed to emulate the %v network on %v", c.Name, dt.TargetURL)
+		}
+		if err := dt.Client.Network.SetBlockedURLs(ctx, network.NewSetBlockedURLsArgs(blocked)); err != nil {
+			return errors.Wrapf(err, "failed to block the URLs on %v", dt.TargetURL)
+		}
+	}
+	if len(c.Drop) > 0 && c.DropRate > 0 {
+		if err := n.startDropping(ctx, c.Drop, c.DropRate); err != nil {
+			return err
+		}
+	}
+	n.condition = c
+	testing.ContextLogf(ctx, "HPSA network is %v", c.Name)
+	return nil
+}
+
+// Reset brings the normal network back
+func (n *NetworkController) Reset(ctx context.Context) error {
+	return n.Apply(ctx, NetworkNormal)
+}
+
+// Condition is the applied condition
+func (n *NetworkController) Condition() NetworkCondition {
+	return n.condition
+}
+
+// Dropped returns how many requests to the drop endpoints were dropped and let through
+func (n *NetworkController) Dropped() (dropped, passed int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.dropped, n.passed
+}
+
+// Close resets the network and closes the DevTools sessions
+func (n *NetworkController) Close(ctx context.Context) error {
+	err := n.Reset(ctx)
+	if closeErr := n.closeSessions(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (n *NetworkController) closeSessions() error {
+	var firstErr error
+	for _, dt := range n.sessions {
+		if err := dt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	n.sessions = nil
+	return firstErr
+}
+
+// startDropping pauses every request to the endpoints and fails the share rate of them
+func (n *NetworkController) startDropping(ctx context.Context, endpoints []string, rate float64) error {
+	var patterns []fetch.RequestPattern
+	for _, e := range endpoints {
+		e := e
+		if !strings.Contains(e, "*") {
+			e = "*" + e + "*"
+		}
+		patterns = append(patterns, fetch.RequestPattern{URLPattern: &e})
+	}
+	// The interception outlives the call, it stops in stopDropping.
+	dropCtx, cancel := context.WithCancel(context.Background())
+	n.stopDrop = cancel
+	for _, dt := range n.sessions {
+		if err := dt.Client.Fetch.Enable(ctx, fetch.NewEnableArgs().SetPatterns(patterns)); err != nil {
+			n.stopDropping(ctx)
+			return errors.Wrapf(err, "failed to intercept the requests on %v", dt.TargetURL)
+		}
+		paused, err := dt.Client.Fetch.RequestPaused(dropCtx)
+		if err != nil {
+			n.stopDropping(ctx)
+			return errors.Wrapf(err, "failed to listen for the paused requests on %v", dt.TargetURL)
+		}
+		n.dropDone.Add(1)
+		go n.drop(ctx, dropCtx, dt, paused, rate)
+	}
+	return nil
+}
+
+// drop fails the share rate of the requests paused in the session and lets the others through
+func (n *NetworkController) drop(ctx, dropCtx context.Context, dt *DevTools, paused fetch.RequestPausedClient, rate float64) {
+	defer n.dropDone.Done()
+	defer paused.Close()
+	for {
+		ev, err := paused.Recv()
+		if err != nil {
+			return
+		}
+		if rand.Float64() < rate {
+			err = dt.Client.Fetch.FailRequest(dropCtx, fetch.NewFailRequestArgs(ev.RequestID, network.ErrorReasonConnectionFailed))
+			n.count(true)
+		} else {
+			err = dt.Client.Fetch.ContinueRequest(dropCtx, fetch.NewContinueRequestArgs(ev.RequestID))
+			n.count(false)
+		}
+		if err != nil {
+			testing.ContextLogf(ctx, "Failed to handle the paused request to %v: %v", ev.Request.URL, err)
+		}
+	}
+}
+
+func (n *NetworkController) count(dropped bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if dropped {
+		n.dropped++
+	} else {
+		n.passed++
+	}
+}
+
+// stopDropping stops the interception, the paused requests are let through by Chrome
+func (n *NetworkController) stopDropping(ctx context.Context) error {
+	if n.stopDrop == nil {
+		return nil
+	}
+	n.stopDrop()
+	n.dropDone.Wait()
+	n.stopDrop = nil
+	for _, dt := range n.sessions {
+		if err := dt.Client.Fetch.Disable(ctx); err != nil {
+			return errors.Wrapf(err, "failed to stop intercepting the requests on %v", dt.TargetURL)
+		}
+	}
+	return nil
+}
+
+// LookupPatterns turns the requests HPSA sent for a lookup into URL patterns for Blocked and Drop.
+// A pattern is the host and the path of a request, so it matches the request whatever its query.
+func LookupPatterns(requests []CapturedRequest) []string {
+	var patterns []string
+	seen := make(map[string]bool)
+	for _, req := range requests {
+		u, err := url.Parse(req.URL)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		p := "*" + u.Host + u.Path + "*"
+		if !seen[p] {
+			seen[p] = true
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+const (
+	// rtcWakeAlarm wakes the DUT up at the second it is set to
+	rtcWakeAlarm = "/sys/class/rtc/rtc0/wakealarm"
+	// lidSuspendTimeout is how long powerd may take to suspend after the lid is closed
+	lidSuspendTimeout = time.Minute
+
+	// uinput ioctls and event codes, see linux/uinput.h and linux/input-event-codes.h
+	uiSetEvBit   = 0x40045564
+	uiSetSwBit   = 0x4004556d
+	uiDevCreate  = 0x5501
+	uiDevDestroy = 0x5502
+	evSyn        = 0x00
+	evSw         = 0x05
+	synReport    = 0x00
+	swLid        = 0x00
+)
+
+// Suspend suspends the DUT with powerd and returns once it resumed after the duration
+func Suspend(ctx context.Context, duration time.Duration) (err error) {
+	step := StartStep(ctx, "suspend", "Suspend", "")
+	step.Attempt()
+	defer func() { step.Done(err) }()
+	out, err := exec.CommandContext(ctx, "powerd_dbus_suspend",
+		fmt.Sprintf("--suspend_for_sec=%d", int(duration.Seconds())),
+		"--timeout=60",
+	).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "failed to suspend: %s", out)
+	}
+	return nil
+}
+
+// uinputUserDev is struct uinput_user_dev of linux/uinput.h
+type uinputUserDev struct {
+	Name         [80]byte
+	Bustype      uint16
+	Vendor       uint16
+	Product      uint16
+	Version      uint16
+	FFEffectsMax uint32
+	Absmax       [64]int32
+	Absmin       [64]int32
+	Absfuzz      [64]int32
+	Absflat      [64]int32
+}
+
+// inputEvent is struct input_event of linux/input.h on 64-bit kernels
+type inputEvent struct {
+	Sec   int64
+	Usec  int64
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// LidSwitch is a virtual lid switch. powerd watches every input device with a lid
+// switch, so closing it suspends the DUT like closing the real lid.
+type LidSwitch struct {
+	f *os.File
+}
+
+// NewLidSwitch creates the switch with the lid open
+func NewLidSwitch(ctx context.Context) (*LidSwitch, error) {
+	f, err := os.OpenFile("/dev/uinput", os.O_WRONLY, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open uinput")
+	}
+	l := &LidSwitch{f: f}
+	dev := uinputUserDev{Bustype: 0x06 /* BUS_VIRTUAL */, Version: 1}
+	copy(dev.Name[:], "hpsa virtual lid switch")
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, &dev)
+	for _, step := range []func() error{
+		func() error { return l.ioctl(uiSetEvBit, evSw) },
+		func() error { return l.ioctl(uiSetSwBit, swLid) },
+		func() error { _, err := f.Write(b.Bytes()); return err },
+		func() error { return l.ioctl(uiDevCreate, 0) },
+	} {
+		if err := step(); err != nil {
+			f.Close()
+			return nil, errors.Wrap(err, "failed to create the virtual lid switch")
+		}
+	}
+	// Give udev and powerd time to pick the device up.
+	if err := testing.Sleep(ctx, 2*time.Second); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, l.set(false)
+}
+
+func (l *LidSwitch) ioctl(req, arg uintptr) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, l.f.Fd(), req, arg); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// set reports the lid closed or open
+func (l *LidSwitch) set(closed bool) error {
+	value := int32(0)
+	if closed {
+		value = 1
+	}
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, []inputEvent{
+		{Type: evSw, Code: swLid, Value: value},
+		{Type: evSyn, Code: synReport},
+	})
+	_, err := l.f.Write(b.Bytes())
+	return err
+}
+
+// Close opens the lid and removes the switch
+func (l *LidSwitch) Close() error {
+	l.set(false)
+	l.ioctl(uiDevDestroy, 0)
+	return l.f.Close()
+}
+
+// SuspendByLid closes the lid, waits for powerd to suspend and opens the lid again as soon as the
+// RTC alarm set to duration wakes the DUT up. Without opening it powerd would suspend again.
+func (l *LidSwitch) SuspendByLid(ctx context.Context, duration time.Duration) (err error) {
+	step := StartStep(ctx, "suspend", "SuspendByLid", "")
+	step.Attempt()
+	defer func() { step.Done(err) }()
+	if err := ioutil.WriteFile(rtcWakeAlarm, []byte("0"), 0644); err != nil {
+		return errors.Wrap(err, "failed to clear the RTC wake alarm")
+	}
+	if err := ioutil.WriteFile(rtcWakeAlarm, []byte(fmt.Sprintf("+%d", int(duration.Seconds()))), 0644); err != nil {
+		return errors.Wrap(err, "failed to set the RTC wake alarm")
+	}
+	// The monotonic clock stops while the DUT is suspended and the wall clock does not,
+	// their difference grows by the time spent suspended.
+	start := time.Now()
+	suspended := func() time.Duration {
+		return time.Now().Round(0).Sub(start.Round(0)) - time.Since(start)
+	}
+	if err := l.set(true); err != nil {
+		return errors.Wrap(err, "failed to close the lid")
+	}
+	defer l.set(false)
+	return testing.Poll(ctx, func(ctx context.Context) error {
+		if suspended() < duration/2 {
+			return errors.New("the DUT has not suspended and resumed yet")
+		}
+		if err := l.set(false); err != nil {
+			return testing.PollBreak(errors.Wrap(err, "failed to open the lid"))
+		}
+		return nil
+	}, &testing.PollOptions{Interval: 100 * time.Millisecond, Timeout: lidSuspendTimeout + duration})
+}
@@ -0,0 +1,263 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/coords"
+	"chromiumos/tast/local/input"
+	"context"
+	"time"
+
+	"go.chromium.org/tast/core/errors"
+)
+
+// ScrollMethod is the input device used to scroll
+type ScrollMethod string
+
+const (
+	// ScrollByWheel scrolls with the mouse wheel over the container
+	ScrollByWheel ScrollMethod = "wheel"
+	// ScrollByKeyboard focuses the container and presses the arrow keys
+	ScrollByKeyboard ScrollMethod = "keyboard"
+	// ScrollByTouch swipes inside the container
+	ScrollByTouch ScrollMethod = "touch"
+)
+
+// ScrollDirection is the direction the content of the container moves to
+type ScrollDirection int
+
+const (
+	// ScrollDown shows the content below
+	ScrollDown ScrollDirection = 1
+	// ScrollUp shows the content above
+	ScrollUp ScrollDirection = -1
+)
+
+const (
+	// maxScrollSteps is the number of steps before the scroller gives up
+	maxScrollSteps = 100
+	// scrollTicks is the number of wheel ticks or key presses in one step
+	scrollTicks = 3
+	// swipeDuration is the duration of one swipe, slow enough not to fling
+	swipeDuration = 300 * time.Millisecond
+)
+
+// Scroller scrolls HPSA containers with one input device, create it once per test
+type Scroller struct {
+	ui     *uiauto.Context
+	method ScrollMethod
+	mew    *input.MouseEventWriter
+	kb     *input.KeyboardEventWriter
+	touch  *Touch
+}
+
+// NewScroller opens the input device of the method
+func NewScroller(ctx context.Context, tconn *chrome.TestConn, ui *uiauto.Context, method ScrollMethod) (*Scroller, error) {
+	sc := &Scroller{ui: ui, method: method}
+	var err error
+	switch method {
+	case ScrollByWheel:
+		sc.mew, err = input.Mouse(ctx)
+	case ScrollByKeyboard:
+		sc.kb, err = input.Keyboard(ctx)
+	case ScrollByTouch:
+		sc.touch, err = NewTouch(ctx, tconn, ui)
+	default:
+		return nil, errors.Errorf("unknown scroll method %q", method)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open the input device for %v scrolling", method)
+	}
+	return sc, nil
+}
+
+// Close releases the input device
+func (sc *Scroller) Close(ctx context.Context) error {
+	switch {
+	case sc.mew != nil:
+		return sc.mew.Close(ctx)
+	case sc.kb != nil:
+		return sc.kb.Close(ctx)
+	case sc.touch != nil:
+		sc.touch.Close()
+	}
+	return nil
+}
+
+// ScrollIntoView scrolls the container in the direction until the target is fully inside it.
+// The target has to be in the tree already, its top tells the scroll position.
+// It returns how far the content moved in DIPs, positive when scrolled down.
+// It fails when the container stops moving before the target shows up.
+func (sc *Scroller) ScrollIntoView(ctx context.Context, container, target *nodewith.Finder, dir ScrollDirection) (int, error) {
+	step := StartStep(ctx, "scroll", "ScrollIntoView", target.Pretty())
+	moved, err := sc.scroll(ctx, step, container, target, target, dir)
+	return moved, step.Done(err)
+}
+
+// ScrollToEnd scrolls the container in the direction until it stops moving.
+// reference is a node of the content, e.g. its first item, whose top tells the scroll position.
+// It returns how far the content moved in DIPs, positive when scrolled down.
+func (sc *Scroller) ScrollToEnd(ctx context.Context, container, reference *nodewith.Finder, dir ScrollDirection) (int, error) {
+	step := StartStep(ctx, "scroll", "ScrollToEnd", container.Pretty())
+	moved, err := sc.scroll(ctx, step, container, reference, nil, dir)
+	return moved, step.Done(err)
+}
+
+// scroll counts every scroll step as an attempt of the traced step
+func (sc *Scroller) scroll(ctx context.Context, step *Step, container, reference, target *nodewith.Finder, dir ScrollDirection) (int, error) {
+	if err := sc.ui.WaitUntilExists(container)(ctx); err != nil {
+		return 0, errors.Wrap(err, "failed to find the container")
+	}
+	if err := sc.ui.WaitUntilExists(reference)(ctx); err != nil {
+		return 0, errors.Wrap(err, "failed to find the node which tells the scroll position")
+	}
+	start, err := sc.position(ctx, reference)
+	if err != nil {
+		return 0, err
+	}
+	previous := start
+	for i := 0; i < maxScrollSteps; i++ {
+		if target != nil {
+			if shown, err := sc.inside(ctx, container, target); err != nil {
+				return start - previous, err
+			} else if shown {
+				return start - previous, nil
+			}
+		}
+		step.Attempt()
+		if err := sc.step(ctx, container, dir); err != nil {
+			return start - previous, err
+		}
+		if err := sc.ui.WaitForLocation(reference)(ctx); err != nil {
+			return start - previous, errors.Wrap(err, "failed to wait for the container to settle")
+		}
+		current, err := sc.position(ctx, reference)
+		if err != nil {
+			return start - previous, err
+		}
+		if current == previous {
+			if target == nil {
+				return start - current, nil
+			}
+			return start - current, errors.Errorf("the container stopped after %d DIPs before the target was shown", start-current)
+		}
+		previous = current
+	}
+	return start - previous, errors.Errorf("the container did not stop within %d steps", maxScrollSteps)
+}
+
+// position is the top of the reference node
+func (sc *Scroller) position(ctx context.Context, reference *nodewith.Finder) (int, error) {
+	loc, err := sc.ui.Location(ctx, reference)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to get the scroll position")
+	}
+	return loc.Top, nil
+}
+
+// inside reports whether the target exists and is fully inside the container bounds
+func (sc *Scroller) inside(ctx context.Context, container, target *nodewith.Finder) (bool, error) {
+	if found, err := sc.ui.IsNodeFound(ctx, target); err != nil || !found {
+		return false, nil
+	}
+	outer, err := sc.ui.Location(ctx, container)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get the container location")
+	}
+	inner, err := sc.ui.Location(ctx, target)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get the target location")
+	}
+	return contains(*outer, *inner), nil
+}
+
+// step scrolls once with the input device
+func (sc *Scroller) step(ctx context.Context, container *nodewith.Finder, dir ScrollDirection) error {
+	switch sc.method {
+	case ScrollByWheel:
+		if err := sc.ui.MouseMoveTo(container, 10*time.Millisecond)(ctx); err != nil {
+			return errors.Wrap(err, "failed to move the mouse to the container")
+		}
+		for i := 0; i < scrollTicks; i++ {
+			scroll := sc.mew.ScrollDown
+			if dir == ScrollUp {
+				scroll = sc.mew.ScrollUp
+			}
+			if err := scroll(); err != nil {
+				return errors.Wrap(err, "failed to scroll the mouse wheel")
+			}
+		}
+	case ScrollByKeyboard:
+		focus, err := sc.focusTarget(ctx, container)
+		if err != nil {
+			return err
+		}
+		if err := sc.ui.FocusAndWait(focus)(ctx); err != nil {
+			return errors.Wrap(err, "failed to focus the container")
+		}
+		key := KeyDown
+		if dir == ScrollUp {
+			key = KeyUp
+		}
+		for i := 0; i < scrollTicks; i++ {
+			if err := sc.kb.Accel(ctx, key); err != nil {
+				return errors.Wrapf(err, "failed to press %v", key)
+			}
+		}
+	case ScrollByTouch:
+		loc, err := sc.ui.Location(ctx, container)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the container location")
+		}
+		// Swipe across the middle half of the container, the finger moves against the scroll direction.
+		center := loc.CenterPoint()
+		from := coords.NewPoint(center.X, loc.Top+loc.Height*3/4)
+		to := coords.NewPoint(center.X, loc.Top+loc.Height/4)
+		if dir == ScrollUp {
+			from, to = to, from
+		}
+		if err := sc.touch.Swipe(ctx, from, to, swipeDuration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// focusTarget is what gets the keyboard focus so the arrow keys scroll the container:
+// the container itself when it is focusable, else the first focusable node in it.
+func (sc *Scroller) focusTarget(ctx context.Context, container *nodewith.Finder) (*nodewith.Finder, error) {
+	if found, err := sc.ui.IsNodeFound(ctx, container.Focusable()); err != nil {
+		return nil, errors.Wrap(err, "failed to check the container is focusable")
+	} else if found {
+		return container.Focusable(), nil
+	}
+	inner := nodewith.Ancestor(container).Focusable().First()
+	if found, err := sc.ui.IsNodeFound(ctx, inner); err != nil {
+		return nil, errors.Wrap(err, "failed to look for a focusable node in the container")
+	} else if !found {
+		return nil, errors.New("nothing in the container takes the focus, the keyboard can not scroll it")
+	}
+	return inner, nil
+}
+
+// ScrollToElement scrolls the list down until the element is shown and selects it
+func ScrollToElement(ctx context.Context, sc *Scroller, scrollbarElement, targetElement *nodewith.Finder) error {
+	if _, err := sc.ScrollIntoView(ctx, scrollbarElement, targetElement, ScrollDown); err != nil {
+		return errors.Wrap(err, "failed to scroll to the element")
+	}
+	return SelectCollectionNode(sc.ui, targetElement)(ctx)
+}
+
+// SelectCollectionNode is collection node
+func SelectCollectionNode(ui *uiauto.Context, collectionNode *nodewith.Finder) uiauto.Action {
+	return uiauto.Combine("select collection node",
+		ui.WaitUntilExists(collectionNode),
+		ui.MakeVisible(collectionNode),
+		ui.DoDefault(collectionNode),
+	)
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/ash"
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/browser/browserfixt"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/faillog"
+	"context"
+	"path/filepath"
+	"time"
+
+	"go.chromium.org/tast/core/ctxutil"
+	"go.chromium.org/tast/core/testing"
+)
+
+// DefaultCleanupTime is the time SetUpSession reserves for the cleanup
+const DefaultCleanupTime = 10 * time.Second
+
+// Session is HPSA installed in a fresh Chrome with the test traced, set up by SetUpSession
+type Session struct {
+	Cr          *chrome.Chrome
+	Browser     *browser.Browser
+	BrowserType browser.Type
+	Tconn       *chrome.TestConn
+	UI          *uiauto.Context
+	// AppID is the id of the installed HPSA app.
+	AppID string
+	// Path and DashboardPath are the json files of the welcome and the dashboard locators.
+	Path          string
+	DashboardPath string
+	// CleanupCtx still has the time reserved for the cleanup, deferred cleanup of the test uses it.
+	CleanupCtx context.Context
+	// cleanups are run by Close in reverse order.
+	cleanups []func()
+}
+
+// SetUpSession starts Chrome with the HPSA extension, the proxy and the language, installs HPSA and closes
+// the browser window the install leaves open. The returned context records the steps of the test; Close
+// writes the trace next to the UI tree dump on error. The test defers Close right after the call.
+// opts are added to the options of Chrome, e.g. extra arguments of the test.
+func SetUpSession(ctx context.Context, s *testing.State, lang string, cleanupTime time.Duration, opts ...chrome.Option) (context.Context, *Session) {
+	sess := &Session{BrowserType: browser.TypeAsh}
+	ready := false
+	defer func() {
+		// A fatal error in the setup skips the deferred Close of the test.
+		if !ready {
+			sess.Close()
+		}
+	}()
+	//Need copy the file to the path
+	extDir := filepath.Dir(ExtensionDir)
+	extID, err := chrome.ComputeExtensionID(extDir)
+	if err != nil {
+		s.Fatalf("Failed to compute extension ID for %v: %v", extDir, err)
+	}
+	s.Log("Extension ID is ", extID)
+	//Create the chrome with the extra arguments
+	cr, err := chrome.New(ctx, append([]chrome.Option{chrome.UnpackedExtension(extDir),
+		chrome.ExtraArgs(Proxy),
+		chrome.ExtraArgs(lang),
+	}, opts...)...)
+	if err != nil {
+		s.Fatal("Chrome login failed: ", err)
+	}
+	sess.Cr = cr
+	sess.onClose(func() { cr.Close(ctx) })
+
+	sess.CleanupCtx = ctx
+	ctx, cancel := ctxutil.Shorten(ctx, cleanupTime)
+	sess.onClose(cancel)
+	ctx, writeTrace := TraceTest(ctx, s)
+	sess.onClose(writeTrace)
+	br, closeBrowser, err := browserfixt.SetUp(ctx, cr, sess.BrowserType)
+	if err != nil {
+		s.Fatal("Failed to set up browser: ", err)
+	}
+	sess.Browser = br
+	sess.onClose(func() { closeBrowser(sess.CleanupCtx) })
+	if sess.Tconn, err = cr.TestAPIConn(ctx); err != nil {
+		s.Fatal("Failed to create Test API connection: ", err)
+	}
+	sess.UI = uiauto.New(sess.Tconn)
+	SetUpBrowser(ctx, sess.UI, br, s, lang)
+	const tabletMode = false
+	cleanup, err := ash.EnsureTabletModeEnabled(ctx, sess.Tconn, tabletMode)
+	if err != nil {
+		s.Fatalf("Failed to ensure the tablet mode is set to %v: %v", tabletMode, err)
+	}
+	sess.onClose(func() { cleanup(sess.CleanupCtx) })
+	if sess.AppID, err = ManualInstallHPSA(ctx, sess.Tconn, cr, sess.BrowserType, AppURLITG); err != nil {
+		s.Fatal("Failed to manually install HPSA: ", err)
+	}
+	sess.onClose(func() { faillog.DumpUITreeOnError(sess.CleanupCtx, s.OutDir(), s.HasError, sess.Tconn) })
+	sess.Path = s.DataPath("hpsa.json")
+	sess.DashboardPath = s.DataPath("dashboard.json")
+	CloseLastBrowser(ctx, "BrowserFrame", s, sess.BrowserType, sess.UI)
+	ready = true
+	return ctx, sess
+}
+
+func (sess *Session) onClose(f func()) {
+	sess.cleanups = append(sess.cleanups, f)
+}
+
+// Close dumps the UI tree on error, writes the trace and closes Chrome
+func (sess *Session) Close() {
+	for i := len(sess.cleanups) - 1; i >= 0; i-- {
+		sess.cleanups[i]()
+	}
+	sess.cleanups = nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/chrome/uiauto/role"
+	"chromiumos/tast/local/coords"
+	"context"
+	"sort"
+	"strings"
+
+	"go.chromium.org/tast/core/errors"
+)
+
+// SpecEntry is one key/value line of a specification section
+type SpecEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// SpecSection is one section of the specification list, e.g. Network or Battery
+type SpecSection struct {
+	Title   string      `json:"title"`
+	Entries []SpecEntry `json:"entries"`
+}
+
+// Value returns the value of the key in the section
+func (s SpecSection) Value(key string) (string, bool) {
+	for _, e := range s.Entries {
+		if strings.EqualFold(e.Key, key) {
+			return e.Value, true
+		}
+	}
+	return "", false
+}
+
+// SpecList is the sections of the specification list keyed by their title
+type SpecList map[string]SpecSection
+
+// specText is a text of the specification list with where it is shown
+type specText struct {
+	text string
+	rect coords.Rect
+}
+
+// SpecificationsReader reads the specification list opened from the dashboard
+type SpecificationsReader struct {
+	ui   *uiauto.Context
+	sc   *Scroller
+	list *nodewith.Finder
+	// Order is the section titles in the order of the last Read.
+	Order []string
+}
+
+// NewSpecificationsReader gets the locator of the specification list from the json at path.
+// The list is scrolled with the scroller of the test.
+func NewSpecificationsReader(ui *uiauto.Context, sc *Scroller, path string) (*SpecificationsReader, error) {
+	class, nth, err := GetJSON(SpecificationsList, path)
+	if err != nil || class == "" {
+		return nil, errors.Wrapf(err, "can not get the json data for %v", SpecificationsList)
+	}
+	return &SpecificationsReader{ui: ui, sc: sc, list: nodewith.HasClass(class).Nth(nth)}, nil
+}
+
+// Read scrolls through the whole specification list and returns every section.
+// Scrolling to the end renders the lazy sections of long lists.
+// A key and its value are the two texts on one line, a text alone on its line is a section title.
+func (r *SpecificationsReader) Read(ctx context.Context) (SpecList, error) {
+	texts := nodewith.Role(role.StaticText).Ancestor(r.list)
+	if _, err := r.sc.ScrollToEnd(ctx, r.list, texts.First(), ScrollDown); err != nil {
+		return nil, errors.Wrap(err, "failed to scroll to the end of the specification list")
+	}
+	infos, err := r.ui.NodesInfo(ctx, texts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the specification list")
+	}
+	var shown []specText
+	for _, info := range infos {
+		shown = append(shown, specText{info.Name, info.Location})
+	}
+	sections, err := pairSpecs(shown)
+	if err != nil {
+		return nil, err
+	}
+	specs := make(SpecList)
+	r.Order = nil
+	for _, s := range sections {
+		if _, ok := specs[s.Title]; ok {
+			return nil, errors.Errorf("section %v is shown twice", s.Title)
+		}
+		specs[s.Title] = s
+		r.Order = append(r.Order, s.Title)
+	}
+	return specs, nil
+}
+
+// pairSpecs groups the texts in lines from top to bottom and pairs the key and value of each line.
+// A text alone on its line starts a new section, unless it sits in the value column of the entry above,
+// then it is the wrapped rest of that value.
+func pairSpecs(texts []specText) ([]SpecSection, error) {
+	var shown []specText
+	for _, t := range texts {
+		if t.text = strings.TrimSpace(t.text); t.text != "" {
+			shown = append(shown, t)
+		}
+	}
+	sort.SliceStable(shown, func(i, j int) bool { return shown[i].rect.Top < shown[j].rect.Top })
+	var lines [][]specText
+	for _, t := range shown {
+		if n := len(lines); n > 0 && sameLine(lines[n-1][0].rect, t.rect) {
+			lines[n-1] = append(lines[n-1], t)
+			continue
+		}
+		lines = append(lines, []specText{t})
+	}
+	var sections []SpecSection
+	// valueLeft is where the values of the current section start, 0 before its first entry.
+	valueLeft := 0
+	for _, line := range lines {
+		sort.SliceStable(line, func(i, j int) bool { return line[i].rect.Left < line[j].rect.Left })
+		switch len(line) {
+		case 1:
+			n := len(sections)
+			if n > 0 && valueLeft > 0 && line[0].rect.Left >= valueLeft {
+				entries := sections[n-1].Entries
+				entries[len(entries)-1].Value += " " + line[0].text
+				continue
+			}
+			sections = append(sections, SpecSection{Title: line[0].text})
+			valueLeft = 0
+		case 2:
+			if len(sections) == 0 {
+				return nil, errors.Errorf("%q: %q is shown before the first section title", line[0].text, line[1].text)
+			}
+			n := len(sections)
+			sections[n-1].Entries = append(sections[n-1].Entries, SpecEntry{Key: line[0].text, Value: line[1].text})
+			valueLeft = line[1].rect.Left
+		default:
+			var names []string
+			for _, t := range line {
+				names = append(names, t.text)
+			}
+			return nil, errors.Errorf("%d texts on one line, want a key and its value: %q", len(line), names)
+		}
+	}
+	return sections, nil
+}
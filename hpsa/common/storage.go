@@ -0,0 +1,91 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/chrome/uiauto/role"
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+// Keys of the labels of the storage check in the diagnostic messages
+const (
+	StorageTotalLabel = "storage_total"
+	StorageFreeLabel  = "storage_free"
+)
+
+// sizeText matches a text with a size in it, e.g. "58.3 GB"
+var sizeText = regexp.MustCompile(`(?i)[0-9]\s*[KMGT]?I?B\b`)
+
+// StorageResult is the text the storage check shows after a run
+type StorageResult struct {
+	Total string `json:"total"`
+	Free  string `json:"free"`
+}
+
+// readLabelledSize reads the size shown with the label.
+// The size is in the text of the label itself or in the closest text on its line.
+func readLabelledSize(ctx context.Context, ui *uiauto.Context, label *regexp.Regexp) (string, error) {
+	finder := nodewith.Role(role.StaticText).NameRegex(label).First()
+	info, err := ui.Info(ctx, finder)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to find the label %v", label)
+	}
+	if sizeText.MatchString(info.Name) {
+		return strings.TrimSpace(info.Name), nil
+	}
+	sizes, err := ui.NodesInfo(ctx, nodewith.Role(role.StaticText).NameRegex(sizeText))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the sizes")
+	}
+	text, distance := "", -1
+	for _, s := range sizes {
+		if !sameLine(info.Location, s.Location) {
+			continue
+		}
+		d := s.Location.Left - info.Location.Right()
+		if d < 0 {
+			d = info.Location.Left - s.Location.Right()
+		}
+		if distance < 0 || d < distance {
+			text, distance = strings.TrimSpace(s.Name), d
+		}
+	}
+	if text == "" {
+		return "", errors.Errorf("no size on the line of %q", info.Name)
+	}
+	return text, nil
+}
+
+// RunStorageCheck runs the storage check and reads the total and free space before it goes back to the dashboard.
+// The spaces are found by their labels, labels are the diagnostic messages of the locale.
+func RunStorageCheck(ctx context.Context, ui *uiauto.Context, dashboardPath string, labels map[string]*regexp.Regexp, timeout time.Duration) (*StorageResult, error) {
+	result := &StorageResult{}
+	err := runAndRead(ctx, ui, CheckStorage, dashboardPath, timeout, func(ctx context.Context) error {
+		for _, f := range []struct {
+			label string
+			text  *string
+		}{{StorageTotalLabel, &result.Total}, {StorageFreeLabel, &result.Free}} {
+			pattern, ok := labels[f.label]
+			if !ok {
+				return testing.PollBreak(errors.Errorf("no label for %v", f.label))
+			}
+			text, err := readLabelledSize(ctx, ui, pattern)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read %v", f.label)
+			}
+			*f.text = text
+		}
+		return nil
+	})
+	return result, err
+}
@@ -0,0 +1,185 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/display"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/coords"
+	"chromiumos/tast/local/input"
+	"context"
+	"fmt"
+	"time"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+// Touch is the touchscreen used by the tablet mode tests instead of the mouse
+type Touch struct {
+	tsw *input.TouchscreenEventWriter
+	stw *input.SingleTouchEventWriter
+	tcc *input.TouchCoordConverter
+	ui  *uiauto.Context
+}
+
+// NewTouch opens the touchscreen and maps it to the current rotation of the internal display
+func NewTouch(ctx context.Context, tconn *chrome.TestConn, ui *uiauto.Context) (*Touch, error) {
+	tsw, err := input.Touchscreen(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the touchscreen")
+	}
+	t := &Touch{tsw: tsw, ui: ui}
+	if err := t.Refresh(ctx, tconn); err != nil {
+		tsw.Close()
+		return nil, err
+	}
+	stw, err := tsw.NewSingleTouchWriter()
+	if err != nil {
+		tsw.Close()
+		return nil, errors.Wrap(err, "failed to create the single touch writer")
+	}
+	t.stw = stw
+	return t, nil
+}
+
+// Refresh maps the touchscreen again after the display is rotated
+func (t *Touch) Refresh(ctx context.Context, tconn *chrome.TestConn) error {
+	info, err := display.GetInternalInfo(ctx, tconn)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the internal display info")
+	}
+	orientation, err := display.GetOrientation(ctx, tconn)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the display orientation")
+	}
+	t.tsw.SetRotation(-orientation.Angle)
+	t.tcc = t.tsw.NewTouchCoordConverter(info.Bounds.Size())
+	return nil
+}
+
+// Close releases the touchscreen
+func (t *Touch) Close() {
+	if t.stw != nil {
+		t.stw.Close()
+	}
+	t.tsw.Close()
+}
+
+// Tap scrolls the element into view and taps its center.
+// The tablet layout stacks the dashboard cards, so the element may be below the fold.
+func (t *Touch) Tap(finder *nodewith.Finder) uiauto.Action {
+	return func(ctx context.Context) error {
+		if err := t.ui.MakeVisible(finder)(ctx); err != nil {
+			return errors.Wrap(err, "failed to make the element visible")
+		}
+		loc, err := t.ui.Location(ctx, finder)
+		if err != nil {
+			return errors.Wrap(err, "failed to get the element location")
+		}
+		x, y := t.tcc.ConvertLocation(loc.CenterPoint())
+		if err := t.stw.Move(x, y); err != nil {
+			return errors.Wrap(err, "failed to touch the element")
+		}
+		// Short hold so the web page gets a tap instead of a flick.
+		if err := testing.Sleep(ctx, 50*time.Millisecond); err != nil {
+			return err
+		}
+		return t.stw.End()
+	}
+}
+
+// Swipe drags a finger from one point to another in screen DIPs
+func (t *Touch) Swipe(ctx context.Context, from, to coords.Point, duration time.Duration) error {
+	x0, y0 := t.tcc.ConvertLocation(from)
+	x1, y1 := t.tcc.ConvertLocation(to)
+	if err := t.stw.Swipe(ctx, x0, y0, x1, y1, duration); err != nil {
+		return errors.Wrap(err, "failed to swipe")
+	}
+	return t.stw.End()
+}
+
+// TapDashboardBtns is using to tap the element in dashboard
+func TapDashboardBtns(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, tc *Touch, element, elementClass string) (string, error) {
+	return TapDashboardBtnsNTH(ctx, s, bt, ui, tc, element, elementClass, 0)
+}
+
+// TapDashboardBtnsNTH is using to tap the nth element in dashboard
+func TapDashboardBtnsNTH(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, tc *Touch, element, elementClass string, nth int) (string, error) {
+	s.Logf("Asserting that touch works on the %v button in %v browser", element, bt)
+	step := StartStep(ctx, "tap", element, Locator(elementClass, nth))
+	if err := step.Done(testing.Poll(ctx, func(ctx context.Context) error {
+		step.Attempt()
+		if err := uiauto.Combine(
+			fmt.Sprintf("Tap the %v button in %v browser", element, bt),
+			ui.WaitUntilExists(nodewith.HasClass(elementClass).Nth(nth)),
+			tc.Tap(nodewith.HasClass(elementClass).Nth(nth)),
+		)(ctx); err != nil {
+			s.Logf("Failed to find and tap the %v button in %v: %v", element, bt, err)
+			return err
+		}
+		return nil
+	}, &testing.PollOptions{Timeout: 3 * time.Minute})); err != nil {
+		return "Failed to tap " + element, err
+	}
+	return "Sucessfully tapped", nil
+}
+
+// TapWelcomeBtns is using to tap the element in welcome
+func TapWelcomeBtns(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, tc *Touch, element, elementClass string) (string, error) {
+	return TapWelcomeBtnsNTH(ctx, s, bt, ui, tc, element, elementClass, 0)
+}
+
+// TapWelcomeBtnsNTH is using to tap the nth element in welcome
+func TapWelcomeBtnsNTH(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, tc *Touch, element, elementClass string, nth int) (string, error) {
+	s.Logf("Asserting that touch works on the %v button in %v browser", element, bt)
+	step := StartStep(ctx, "tap", element, Locator(elementClass, nth))
+	if err := step.Done(testing.Poll(ctx, func(ctx context.Context) error {
+		step.Attempt()
+		return uiauto.Combine(
+			fmt.Sprintf("Tap the %v button in %v browser", element, bt),
+			ui.WaitUntilExists(nodewith.HasClass(elementClass).Nth(nth)),
+			tc.Tap(nodewith.HasClass(elementClass).Nth(nth)),
+		)(ctx)
+	}, &testing.PollOptions{Interval: 5 * time.Second,
+		Timeout: time.Minute})); err != nil {
+		return "Failed to tap " + element, err
+	}
+	return "Sucessfully tapped", nil
+}
+
+// PreTestTablet is a function to navigate to dashboard for HPSA with the touchscreen
+func PreTestTablet(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, tc *Touch, path string) (string, error) {
+	for _, element := range []string{Letsstart, LaunchHPSupportAssistant, SelectRegion} {
+		class, nth, err := GetJSON(element, path)
+		if err != nil {
+			return "Can not get the json data for " + element, err
+		}
+		if tips, err := TapWelcomeBtnsNTH(ctx, s, bt, ui, tc, element, class, nth); err != nil {
+			return tips, err
+		}
+	}
+	// The region menu is a popup, it is tapped directly instead of focusing the drop menu first.
+	selectRegionUSclass, _, err := GetJSON(SelectRegionUS, path)
+	if err != nil {
+		return "Can not get the json data for " + SelectRegionUS, err
+	}
+	if tips, err := TapWelcomeBtns(ctx, s, bt, ui, tc, SelectRegionUS, selectRegionUSclass); err != nil {
+		return tips, err
+	}
+	for _, element := range []string{ContinueBTN, DonotShowAgain, ContinueAsGuest, WarrantyOption, UsageData, ImproveMyExperience, ClosePinPopup} {
+		class, nth, err := GetJSON(element, path)
+		if err != nil {
+			return "Can not get the json data for " + element, err
+		}
+		if tips, err := TapWelcomeBtnsNTH(ctx, s, bt, ui, tc, element, class, nth); err != nil {
+			return tips, err
+		}
+	}
+	return "Successful navigate to dashboard", nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/chrome/uiauto"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.chromium.org/tast/core/testing"
+)
+
+const (
+	// TraceFile is the Chrome trace-event JSON written to OutDir, it opens in chrome://tracing and Perfetto
+	TraceFile = "hpsa_trace.json"
+	// TimelineFile is the human-readable timeline written to OutDir
+	TimelineFile = "hpsa_timeline.txt"
+	// slowStep is the duration from which a step is marked slow in the timeline
+	slowStep = 10 * time.Second
+	// environmentTimeout is how long reading the environment may take
+	environmentTimeout = 10 * time.Second
+)
+
+// Step is one traced helper action
+type Step struct {
+	Name     string    `json:"name"`
+	Kind     string    `json:"kind"`
+	Locator  string    `json:"locator,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Result   string    `json:"result"`
+	Retries  int       `json:"retries"`
+	tracer   *Tracer
+	attempts int
+}
+
+// Tracer collects the steps of one test
+type Tracer struct {
+	mu    sync.Mutex
+	start time.Time
+	steps []*Step
+}
+
+type tracerKey struct{}
+
+// StartTracing attaches a new tracer to the context, every helper called with the returned context records its steps
+func StartTracing(ctx context.Context) (context.Context, *Tracer) {
+	t := &Tracer{start: time.Now()}
+	return context.WithValue(ctx, tracerKey{}, t), t
+}
+
+// TraceTest starts tracing the test, the returned func writes the trace to the output directory of the test
+func TraceTest(ctx context.Context, s *testing.State) (context.Context, func()) {
+	ctx, t := StartTracing(ctx)
+	return ctx, func() {
+		if err := t.Write(s.OutDir()); err != nil {
+			s.Log("Failed to write the trace: ", err)
+		}
+	}
+}
+
+// StartStep starts a step on the tracer of the context.
+// kind is the action, e.g. click, wait, type, screenshot or diagnostic.
+// It returns a step which records nothing when the context has no tracer.
+func StartStep(ctx context.Context, kind, name, locator string) *Step {
+	t, _ := ctx.Value(tracerKey{}).(*Tracer)
+	return &Step{Name: name, Kind: kind, Locator: locator, Start: time.Now(), tracer: t}
+}
+
+// Attempt counts one attempt of the step, every attempt after the first is a retry
+func (st *Step) Attempt() {
+	st.attempts++
+	if st.attempts > 1 {
+		st.Retries++
+	}
+}
+
+// Done ends the step with the result of the action and returns err unchanged
+func (st *Step) Done(err error) error {
+	st.End = time.Now()
+	st.Result = "ok"
+	if err != nil {
+		st.Result = "error: " + err.Error()
+	}
+	if st.tracer != nil {
+		st.tracer.mu.Lock()
+		st.tracer.steps = append(st.tracer.steps, st)
+		st.tracer.mu.Unlock()
+	}
+	return err
+}
+
+// TraceAction wraps the action so every run of it is recorded as a step
+func TraceAction(kind, name, locator string, action uiauto.Action) uiauto.Action {
+	return func(ctx context.Context) error {
+		step := StartStep(ctx, kind, name, locator)
+		step.Attempt()
+		return step.Done(action(ctx))
+	}
+}
+
+// Steps returns the finished steps in the order they started
+func (t *Tracer) Steps() []Step {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	steps := make([]Step, len(t.steps))
+	for i, st := range t.steps {
+		steps[i] = *st
+	}
+	// Nested steps finish before their parent, order them by start.
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].Start.Before(steps[j].Start) })
+	return steps
+}
+
+// Locator describes a class locator of the json files for the trace
+func Locator(class string, nth int) string {
+	return fmt.Sprintf("class=%q nth=%d", class, nth)
+}
+
+// traceEvent is a complete event of the Chrome trace-event format
+type traceEvent struct {
+	Name string                 `json:"name"`
+	Cat  string                 `json:"cat"`
+	Ph   string                 `json:"ph"`
+	Ts   int64                  `json:"ts"`
+	Dur  int64                  `json:"dur"`
+	Pid  int                    `json:"pid"`
+	Tid  int                    `json:"tid"`
+	Args map[string]interface{} `json:"args"`
+}
+
+// Write writes the trace-event JSON, the timeline and the environment to the directory
+func (t *Tracer) Write(dir string) error {
+	steps := t.Steps()
+	events := make([]traceEvent, 0, len(steps))
+	for _, st := range steps {
+		events = append(events, traceEvent{
+			Name: st.Name,
+			Cat:  st.Kind,
+			Ph:   "X",
+			Ts:   st.Start.Sub(t.start).Microseconds(),
+			Dur:  st.End.Sub(st.Start).Microseconds(),
+			Pid:  1,
+			Tid:  1,
+			Args: map[string]interface{}{"locator": st.Locator, "result": st.Result, "retries": st.Retries},
+		})
+	}
+	b, err := json.MarshalIndent(map[string]interface{}{"traceEvents": events, "displayTimeUnit": "ms"}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, TraceFile), b, 0644); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, TimelineFile), []byte(t.timeline(steps)), 0644); err != nil {
+		return err
+	}
+	// Every test writes its trace, so the environment is written with it for the report.
+	// The context of the test may be done when the deferred Write runs.
+	ctx, cancel := context.WithTimeout(context.Background(), environmentTimeout)
+	defer cancel()
+	return WriteEnvironment(ctx, dir)
+}
+
+// timeline renders one line per step: offset, duration, kind, name, retries, locator and result
+func (t *Tracer) timeline(steps []Step) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Trace started at %v, %d steps\n", t.start.Format(time.RFC3339), len(steps))
+	fmt.Fprintf(&b, "%10s %10s %-4s %-10s %s\n", "offset", "duration", "", "kind", "step")
+	for _, st := range steps {
+		mark := ""
+		switch {
+		case st.Result != "ok":
+			mark = "FAIL"
+		case st.End.Sub(st.Start) >= slowStep:
+			mark = "SLOW"
+		}
+		fmt.Fprintf(&b, "%10s %10s %-4s %-10s %s", st.Start.Sub(t.start).Round(time.Millisecond), st.End.Sub(st.Start).Round(time.Millisecond), mark, st.Kind, st.Name)
+		if st.Retries > 0 {
+			fmt.Fprintf(&b, " (%d retries)", st.Retries)
+		}
+		if st.Locator != "" {
+			fmt.Fprintf(&b, " [%v]", st.Locator)
+		}
+		if st.Result != "ok" {
+			fmt.Fprintf(&b, " %v", st.Result)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package common
+
+import (
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/chrome/uiauto/role"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.chromium.org/tast/core/errors"
+)
+
+// warrantyDates are the Angular mediumDate formats of the supported locales and how to find them in a text
+var warrantyDates = map[string]struct {
+	layout  string
+	pattern *regexp.Regexp
+}{
+	"en-US": {"Jan 2, 2006", regexp.MustCompile(`\b[A-Z][a-z]{2} \d{1,2}, \d{4}\b`)},
+	"en-GB": {"2 Jan 2006", regexp.MustCompile(`\b\d{1,2} [A-Z][a-z]{2} \d{4}\b`)},
+	"de-DE": {"02.01.2006", regexp.MustCompile(`\b\d{2}\.\d{2}\.\d{4}\b`)},
+	"fr-FR": {"2 Jan 2006", regexp.MustCompile(`\b\d{1,2} [a-zéû]{3,5}\.? \d{4}\b`)},
+	"ja-JP": {"2006/01/02", regexp.MustCompile(`\b\d{4}/\d{2}/\d{2}\b`)},
+	"zh-CN": {"2006年1月2日", regexp.MustCompile(`\d{4}年\d{1,2}月\d{1,2}日`)},
+}
+
+// otherDatePatterns are date formats no supported locale uses, a page showing them is not localized
+var otherDatePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}\b`),
+	regexp.MustCompile(`\b\d{1,2}/\d{1,2}/\d{4}\b`),
+}
+
+// WarrantyPage is what the warranty page shows
+type WarrantyPage struct {
+	// Texts are the texts below the back button in display order.
+	Texts []string `json:"texts"`
+	// Requests are the requests HPSA sent while it looked the warranty up.
+	Requests []CapturedRequest `json:"requests,omitempty"`
+}
+
+// CheckWarrantyDates returns the dates of the texts which are not written the way the locale writes them
+func CheckWarrantyDates(texts []string, locale string) []string {
+	format, ok := warrantyDates[locale]
+	if !ok {
+		return []string{fmt.Sprintf("no date format for locale %q", locale)}
+	}
+	var problems []string
+	for _, text := range texts {
+		rest := format.pattern.ReplaceAllString(text, "")
+		for other, f := range warrantyDates {
+			if other == locale {
+				continue
+			}
+			for _, date := range f.pattern.FindAllString(rest, -1) {
+				problems = append(problems, fmt.Sprintf("%q is written like a %v date, want %v", date, other, format.layout))
+			}
+		}
+		for _, p := range otherDatePatterns {
+			for _, date := range p.FindAllString(rest, -1) {
+				problems = append(problems, fmt.Sprintf("%q is no %v date, want %v", date, locale, format.layout))
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// OpenWarrantyDetails asks for the warranty details when HPSA has no consent yet and opens the warranty page
+func OpenWarrantyDetails(ctx context.Context, ui *uiauto.Context, dashboardPath string) error {
+	getDetailClass, getDetailNTH, err := GetJSONDashboard(WarrantyCardGetDetail, dashboardPath)
+	if err != nil {
+		return errors.Wrapf(err, "can not get the json data for %v", WarrantyCardGetDetail)
+	}
+	yesClass, yesNTH, err := GetJSONDashboard(WarrantyCardGetDetailYES, dashboardPath)
+	if err != nil {
+		return errors.Wrapf(err, "can not get the json data for %v", WarrantyCardGetDetailYES)
+	}
+	cardClass, cardNTH, err := GetJSONDashboard(WarrantyCard, dashboardPath)
+	if err != nil {
+		return errors.Wrapf(err, "can not get the json data for %v", WarrantyCard)
+	}
+	getDetail := nodewith.HasClass(getDetailClass).Nth(getDetailNTH)
+	if found, err := ui.IsNodeFound(ctx, getDetail); err == nil && found {
+		yes := nodewith.HasClass(yesClass).Nth(yesNTH)
+		if err := uiauto.Combine("agree to get the warranty details",
+			ui.LeftClick(getDetail),
+			ui.WaitUntilExists(yes),
+			ui.LeftClick(yes),
+		)(ctx); err != nil {
+			return err
+		}
+	}
+	card := nodewith.HasClass(cardClass).Nth(cardNTH)
+	return uiauto.Combine("open the warranty page",
+		ui.WithTimeout(time.Minute).WaitUntilExists(card),
+		ui.LeftClick(card),
+	)(ctx)
+}
+
+// WaitForWarrantyPage waits until the warranty page has loaded, the additional information link is shown with the lookup result
+func WaitForWarrantyPage(ctx context.Context, ui *uiauto.Context, dashboardPath string, timeout time.Duration) error {
+	class, nth, err := GetJSONDashboard(AdditionalInformation, dashboardPath)
+	if err != nil {
+		return errors.Wrapf(err, "can not get the json data for %v", AdditionalInformation)
+	}
+	return ui.WithTimeout(timeout).WaitUntilExists(nodewith.HasClass(class).Nth(nth))(ctx)
+}
+
+// ReadWarrantyPage waits for the warranty page and reads the texts shown below its back button
+func ReadWarrantyPage(ctx context.Context, ui *uiauto.Context, dashboardPath string) (*WarrantyPage, error) {
+	if err := WaitForWarrantyPage(ctx, ui, dashboardPath, time.Minute); err != nil {
+		return nil, errors.Wrap(err, "the warranty page did not load")
+	}
+	backClass, backNTH, err := GetJSONDashboard(WarrantyBack, dashboardPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not get the json data for %v", WarrantyBack)
+	}
+	back, err := ui.Info(ctx, nodewith.HasClass(backClass).Nth(backNTH))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the back button of the warranty page")
+	}
+	infos, err := ui.NodesInfo(ctx, nodewith.Role(role.StaticText))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the texts of the warranty page")
+	}
+	sort.SliceStable(infos, func(i, j int) bool { return infos[i].Location.Top < infos[j].Location.Top })
+	page := &WarrantyPage{}
+	for _, info := range infos {
+		if text := strings.TrimSpace(info.Name); text != "" && info.Location.Top >= back.Location.Bottom() {
+			page.Texts = append(page.Texts, text)
+		}
+	}
+	return page, nil
+}
@@ -49,7 +49,9 @@ const (
 // ClickWelcomeBtns is using to click all element in welcome
 func ClickWelcomeBtns(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, element, elementClass string) (string, error) {
 	s.Logf("Asserting that mouse click works on the %v button in %v browser", element, bt)
-	if err := testing.Poll(ctx, func(ctx context.Context) error {
+	step := StartStep(ctx, "click", element, Locator(elementClass, 0))
+	if err := step.Done(testing.Poll(ctx, func(ctx context.Context) error {
+		step.Attempt()
 		if err := uiauto.Combine(
 			fmt.Sprintf("Click the %v button in %v browser", element, bt),
 			ui.WaitUntilExists(nodewith.HasClass(elementClass).First()),
@@ -60,7 +62,7 @@ func ClickWelcomeBtns(ctx context.Context, s *testing.State, bt browser.Type, ui
 		}
 		return nil
 	}, &testing.PollOptions{Interval: 1 * time.Minute,
-		Timeout: time.Minute}); err != nil {
+		Timeout: time.Minute})); err != nil {
 		s.Log("Can not finish the action: ", err)
 	}
 	return "Sucessfully clicked", nil
@@ -69,11 +71,12 @@ func ClickWelcomeBtns(ctx context.Context, s *testing.State, bt browser.Type, ui
 // ClickWelcomeBtnsNTH is using to click all element in welcome
 func ClickWelcomeBtnsNTH(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, element, elementClass string, nth int) (string, error) {
 	s.Logf("Asserting that mouse click works on the %v button in %v browser", element, bt)
-	if err := uiauto.Combine(
+	step := StartStep(ctx, "click", element, Locator(elementClass, nth))
+	if err := step.Done(uiauto.Combine(
 		fmt.Sprintf("Click the %v button in %v browser", element, bt),
 		ui.WaitUntilExists(nodewith.HasClass(elementClass).Nth(nth)),
 		ui.LeftClick(nodewith.HasClass(elementClass).Nth(nth)),
-	)(ctx); err != nil {
+	)(ctx)); err != nil {
 		s.Fatalf("Failed to find and click the %v button in %v: %v", element, bt, err)
 		return "Failed to click let's get start", err
 	}
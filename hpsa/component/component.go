@@ -0,0 +1,149 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package component runs the sub-tests of the HPSA component test and feeds them synthetic input.
+package component
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ResultsFile is every sub-test result written to OutDir
+const ResultsFile = "component_results.json"
+
+// Keys of the button labels of the sub-tests in the diagnostic messages.
+// The buttons share their classes with the run button and the exception popup, they are found by role and label.
+const (
+	// StartLabel is the button which starts a sub-test
+	StartLabel = "component_start"
+	// YesLabel and NoLabel answer the prompt of a sub-test, they are only shown while it asks
+	YesLabel = "component_yes"
+	NoLabel  = "component_no"
+)
+
+// Kind is the hardware a sub-test checks, it decides the input the driver feeds
+type Kind string
+
+const (
+	// KindKeyboard asks for key presses, fed by a virtual keyboard
+	KindKeyboard Kind = "keyboard"
+	// KindTouchpad asks for touches and swipes, fed by a uinput touchpad
+	KindTouchpad Kind = "touchpad"
+	// KindAudio plays a tone and asks whether it was heard, the tone is recorded from the loopback sink
+	KindAudio Kind = "audio"
+	// KindDisplay shows color screens and asks whether they look right
+	KindDisplay Kind = "display"
+	// KindCamera shows the camera preview and asks whether it is shown, the fake camera of Chrome feeds it
+	KindCamera Kind = "camera"
+	// KindOther needs no input, it only runs and shows its result
+	KindOther Kind = "other"
+)
+
+// kindWords are words of the sub-test names in the supported locales, the first match wins
+var kindWords = []struct {
+	kind  Kind
+	words []string
+}{
+	{KindTouchpad, []string{"touchpad", "trackpad", "pavé tactile"}},
+	{KindKeyboard, []string{"keyboard", "clavier"}},
+	{KindAudio, []string{"audio", "speaker", "haut-parleur"}},
+	{KindDisplay, []string{"display", "screen", "écran", "affichage"}},
+	{KindCamera, []string{"camera", "caméra"}},
+}
+
+// Classify tells the kind of the sub-test from the name HPSA shows
+func Classify(name string) Kind {
+	name = strings.ToLower(name)
+	for _, k := range kindWords {
+		for _, w := range k.words {
+			if strings.Contains(name, w) {
+				return k.kind
+			}
+		}
+	}
+	return KindOther
+}
+
+// Status is the result HPSA shows for a sub-test
+type Status string
+
+const (
+	// StatusPassed is the pass image
+	StatusPassed Status = "passed"
+	// StatusFailed is the failed message
+	StatusFailed Status = "failed"
+	// StatusNone is neither, the sub-test did not finish
+	StatusNone Status = ""
+)
+
+// Result is one sub-test run
+type Result struct {
+	Name string `json:"name"`
+	Kind Kind   `json:"kind"`
+	// PromptShown tells the sub-test asked the user, Answer is the answer given, nil when there was no prompt.
+	PromptShown bool  `json:"promptShown"`
+	Answer      *bool `json:"answer,omitempty"`
+	// PromptGone tells the prompt went away after the answer.
+	PromptGone bool          `json:"promptGone"`
+	Status     Status        `json:"status"`
+	Duration   time.Duration `json:"durationNs"`
+	// Evidence is what the driver saw of the output, e.g. the level of the recorded tone.
+	Evidence   string   `json:"evidence,omitempty"`
+	Screenshot string   `json:"screenshot,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	Problems   []string `json:"problems,omitempty"`
+}
+
+// Interactive tells whether the kind asks the user, every kind but KindOther does
+func (k Kind) Interactive() bool {
+	return k != KindOther
+}
+
+// Verifiable tells whether the driver sees the output of the kind, so its answer follows what the hardware did.
+// Nothing looks at the color screens and the camera preview, an answer to them would only echo the parameter.
+func (k Kind) Verifiable() bool {
+	return k != KindDisplay && k != KindCamera
+}
+
+// Check checks the prompt behaved: an interactive sub-test asks, the prompt goes away after the answer,
+// and the result follows the answer. It fills r.Problems.
+func (r *Result) Check() {
+	r.Problems = nil
+	if r.Error != "" {
+		r.Problems = append(r.Problems, r.Error)
+	}
+	if r.Kind.Interactive() && !r.PromptShown {
+		r.Problems = append(r.Problems, "no prompt was shown")
+	}
+	if !r.Kind.Interactive() && r.PromptShown {
+		r.Problems = append(r.Problems, "a prompt was shown but the sub-test should need no input")
+	}
+	if r.Answer != nil && !r.PromptGone {
+		r.Problems = append(r.Problems, "the prompt stayed after the answer")
+	}
+	switch {
+	case r.Status == StatusNone:
+		r.Problems = append(r.Problems, "no result was shown")
+	case r.Answer != nil && *r.Answer && r.Status != StatusPassed:
+		r.Problems = append(r.Problems, "the answer was yes but the result is "+string(r.Status))
+	case r.Answer != nil && !*r.Answer && r.Status != StatusFailed:
+		r.Problems = append(r.Problems, "the answer was no but the result is "+string(r.Status))
+	}
+}
+
+// Write writes the results to the directory
+func Write(dir string, results []Result) error {
+	if results == nil {
+		results = []Result{}
+	}
+	b, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, ResultsFile), b, 0644)
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package component
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		want Kind
+	}{
+		{"Keyboard test", KindKeyboard},
+		{"  TOUCHPAD ", KindTouchpad},
+		{"Trackpad", KindTouchpad},
+		{"Test du pavé tactile", KindTouchpad},
+		{"Clavier", KindKeyboard},
+		{"Speaker", KindAudio},
+		{"Audio playback", KindAudio},
+		{"Display colors", KindDisplay},
+		{"Écran", KindDisplay},
+		{"Camera", KindCamera},
+		{"Caméra", KindCamera},
+		{"USB ports", KindOther},
+		{"", KindOther},
+	} {
+		if got := Classify(tc.name); got != tc.want {
+			t.Errorf("Classify(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	yes, no := true, false
+	for _, tc := range []struct {
+		name string
+		r    Result
+		want []string
+	}{
+		{"yes passed", Result{Kind: KindKeyboard, PromptShown: true, Answer: &yes, PromptGone: true, Status: StatusPassed}, nil},
+		{"no failed", Result{Kind: KindAudio, PromptShown: true, Answer: &no, PromptGone: true, Status: StatusFailed}, nil},
+		{"other without prompt", Result{Kind: KindOther, Status: StatusPassed}, nil},
+		{"no prompt", Result{Kind: KindTouchpad, Status: StatusPassed}, []string{"no prompt was shown"}},
+		{"prompt for other", Result{Kind: KindOther, PromptShown: true, Answer: &yes, PromptGone: true, Status: StatusPassed},
+			[]string{"a prompt was shown but the sub-test should need no input"}},
+		{"prompt stayed", Result{Kind: KindDisplay, PromptShown: true, Answer: &yes, Status: StatusPassed},
+			[]string{"the prompt stayed after the answer"}},
+		{"no result", Result{Kind: KindOther}, []string{"no result was shown"}},
+		{"yes failed", Result{Kind: KindCamera, PromptShown: true, Answer: &yes, PromptGone: true, Status: StatusFailed},
+			[]string{"the answer was yes but the result is failed"}},
+		{"no passed", Result{Kind: KindKeyboard, PromptShown: true, Answer: &no, PromptGone: true, Status: StatusPassed},
+			[]string{"the answer was no but the result is passed"}},
+		{"error first", Result{Kind: KindAudio, Error: "no tone", Status: StatusNone},
+			[]string{"no tone", "no prompt was shown", "no result was shown"}},
+	} {
+		r := tc.r
+		r.Problems = []string{"stale"}
+		r.Check()
+		if !reflect.DeepEqual(r.Problems, tc.want) {
+			t.Errorf("%v: Check found %q, want %q", tc.name, r.Problems, tc.want)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package component
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"chromiumos/tast/local/audio"
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/chrome/uiauto/role"
+	"chromiumos/tast/local/input"
+	"chromiumos/tast/local/screenshot"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+const (
+	// promptTimeout is how long a sub-test may take to ask after the input
+	promptTimeout = 15 * time.Second
+	// resultTimeout is how long a sub-test may take to show its result after the answer
+	resultTimeout = 2 * time.Minute
+	// recordDuration is how much of the tone is recorded from the loopback sink
+	recordDuration = 5 * time.Second
+	// keyboardKeys are typed for the keyboard sub-test, keys which move the focus are left out
+	keyboardKeys = "abcdefghijklmnopqrstuvwxyz0123456789 "
+)
+
+// Driver runs the sub-tests on the opened component test page
+type Driver struct {
+	ui            *uiauto.Context
+	outDir        string
+	dashboardPath string
+	// failed is the message of a failed sub-test, start, yes and no are the buttons of a sub-test.
+	failed  *nodewith.Finder
+	start   *nodewith.Finder
+	yes     *nodewith.Finder
+	no      *nodewith.Finder
+	finders map[string]*nodewith.Finder
+
+	kb          *input.KeyboardEventWriter
+	tp          *input.TrackpadEventWriter
+	unloadAloop func(context.Context)
+}
+
+// NewDriver opens the virtual keyboard and touchpad and routes the audio output to the loopback sink.
+// messages are the diagnostic messages of the locale, they need the failed message and the button labels.
+func NewDriver(ctx context.Context, ui *uiauto.Context, dashboardPath string, messages map[string]*regexp.Regexp, outDir string) (d *Driver, err error) {
+	for _, key := range []string{common.DiagnosticFailed, StartLabel, YesLabel, NoLabel} {
+		if messages[key] == nil {
+			return nil, errors.Errorf("no %v message", key)
+		}
+	}
+	button := func(key string) *nodewith.Finder {
+		return nodewith.Role(role.Button).NameRegex(messages[key]).First()
+	}
+	d = &Driver{
+		ui:            ui,
+		outDir:        outDir,
+		dashboardPath: dashboardPath,
+		failed:        nodewith.Role(role.StaticText).NameRegex(messages[common.DiagnosticFailed]).First(),
+		start:         button(StartLabel),
+		yes:           button(YesLabel),
+		no:            button(NoLabel),
+		finders:       make(map[string]*nodewith.Finder),
+	}
+	defer func() {
+		if err != nil {
+			d.Close(ctx)
+		}
+	}()
+	for _, name := range []string{common.CPUCheckPassImage, common.ComponentItemBack} {
+		if d.finders[name], err = common.Finder(name, dashboardPath, "dashboard"); err != nil {
+			return d, err
+		}
+	}
+	if d.kb, err = input.Keyboard(ctx); err != nil {
+		return d, errors.Wrap(err, "failed to open the virtual keyboard")
+	}
+	if d.tp, err = input.Trackpad(ctx); err != nil {
+		return d, errors.Wrap(err, "failed to open the virtual touchpad")
+	}
+	if d.unloadAloop, err = audio.LoadAloop(ctx); err != nil {
+		return d, errors.Wrap(err, "failed to load the loopback sink")
+	}
+	cras, err := audio.NewCras(ctx)
+	if err != nil {
+		return d, errors.Wrap(err, "failed to connect to CRAS")
+	}
+	if err := cras.SetActiveNodeByType(ctx, "ALSA_LOOPBACK"); err != nil {
+		return d, errors.Wrap(err, "failed to route the output to the loopback sink")
+	}
+	return d, nil
+}
+
+// Close releases the input devices and unloads the loopback sink, CRAS goes back to the default output
+func (d *Driver) Close(ctx context.Context) {
+	if d.kb != nil {
+		d.kb.Close()
+	}
+	if d.tp != nil {
+		d.tp.Close()
+	}
+	if d.unloadAloop != nil {
+		d.unloadAloop(ctx)
+	}
+}
+
+// List returns the names of the sub-tests in the order HPSA shows them.
+// A sub-test is an item of the list HPSA shows below the back button of the component test, whatever its kind.
+func (d *Driver) List(ctx context.Context) ([]string, error) {
+	var names []string
+	if err := testing.Poll(ctx, func(ctx context.Context) error {
+		area, err := common.DiagnosticResultArea(ctx, d.ui, common.ComponentTest, d.dashboardPath)
+		if err != nil {
+			return err
+		}
+		nodes, err := d.ui.NodesInfo(ctx, nodewith.Focusable())
+		if err != nil {
+			return errors.Wrap(err, "failed to read the component sub-tests")
+		}
+		names = nil
+		for _, n := range nodes {
+			if name := strings.TrimSpace(n.Name); name != "" && common.CenteredIn(area, n.Location) {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			return errors.New("no component sub-test is shown")
+		}
+		return nil
+	}, &testing.PollOptions{Interval: time.Second, Timeout: time.Minute}); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// item is the sub-test in the list
+func item(name string) *nodewith.Finder {
+	return nodewith.Focusable().Name(name).First()
+}
+
+// Run runs the sub-test and goes back to the list, nth is its place in the list.
+// With yes the driver answers the prompt the way a user seeing working hardware would, without it always answers no.
+func (d *Driver) Run(ctx context.Context, nth int, name string, yes bool) Result {
+	r := Result{Name: name, Kind: Classify(name)}
+	start := time.Now()
+	if err := d.run(ctx, &r, yes); err != nil {
+		r.Error = err.Error()
+	}
+	r.Duration = time.Since(start)
+	r.Screenshot = fmt.Sprintf("component_%02d_%v.png", nth, r.Kind)
+	if err := screenshot.Capture(ctx, filepath.Join(d.outDir, r.Screenshot)); err != nil {
+		testing.ContextLog(ctx, "Failed to take the screenshot: ", err)
+		r.Screenshot = ""
+	}
+	if err := d.back(ctx, name); err != nil && r.Error == "" {
+		r.Error = err.Error()
+	}
+	r.Check()
+	return r
+}
+
+func (d *Driver) run(ctx context.Context, r *Result, yes bool) (err error) {
+	step := common.StartStep(ctx, "component", r.Name, item(r.Name).Pretty())
+	step.Attempt()
+	defer func() { step.Done(err) }()
+	if err := uiauto.Combine("start the "+r.Name+" sub-test",
+		d.ui.LeftClick(item(r.Name)),
+		d.ui.WithTimeout(30*time.Second).WaitUntilExists(d.start),
+	)(ctx); err != nil {
+		return err
+	}
+	// The recording has to run while the tone plays, so it starts before the sub-test.
+	var wait func() (float64, error)
+	if r.Kind == KindAudio {
+		if wait, err = d.record(ctx); err != nil {
+			return err
+		}
+	}
+	if err := d.ui.LeftClick(d.start)(ctx); err != nil {
+		return err
+	}
+	if err := d.feed(ctx, r.Kind); err != nil {
+		return errors.Wrapf(err, "failed to feed the %v input", r.Kind)
+	}
+	answer := yes
+	// silent is set when the sub-test should have played a tone but did not, the sub-test still runs to its result.
+	var silent error
+	if wait != nil {
+		level, err := wait()
+		if err != nil {
+			return err
+		}
+		r.Evidence = fmt.Sprintf("tone at %.1f dBFS", level)
+		if level < ToneThreshold {
+			// A user would not have heard anything.
+			answer = false
+			if yes {
+				silent = errors.Errorf("no tone on the loopback sink, the level is %.1f dBFS", level)
+			}
+		}
+	}
+
+	// The sub-test asks with a yes and a no button, the no button is only shown while it asks.
+	prompt := d.no
+	if r.Kind.Interactive() {
+		r.PromptShown = d.ui.WithTimeout(promptTimeout).WaitUntilExists(prompt)(ctx) == nil
+	} else {
+		r.PromptShown, _ = d.ui.IsNodeFound(ctx, prompt)
+	}
+	if r.PromptShown {
+		r.Answer = &answer
+		button := d.no
+		if answer {
+			button = d.yes
+		}
+		if err := d.ui.LeftClick(button)(ctx); err != nil {
+			return errors.Wrap(err, "failed to answer the prompt")
+		}
+		r.PromptGone = d.ui.WithTimeout(10*time.Second).WaitUntilGone(prompt)(ctx) == nil
+	}
+	if r.Status, err = d.status(ctx); err != nil {
+		return err
+	}
+	return silent
+}
+
+// feed gives the sub-test the input it asks for
+func (d *Driver) feed(ctx context.Context, kind Kind) error {
+	switch kind {
+	case KindKeyboard:
+		return d.kb.Type(ctx, keyboardKeys)
+	case KindTouchpad:
+		stw, err := d.tp.NewSingleTouchWriter()
+		if err != nil {
+			return err
+		}
+		defer stw.Close()
+		w, h := d.tp.Width(), d.tp.Height()
+		// A tap in the middle, then a swipe across both diagonals covers the whole surface.
+		if err := stw.Move(w/2, h/2); err != nil {
+			return err
+		}
+		if err := stw.End(); err != nil {
+			return err
+		}
+		for _, line := range [][4]input.TouchCoord{{0, 0, w - 1, h - 1}, {w - 1, 0, 0, h - 1}} {
+			if err := stw.Swipe(ctx, line[0], line[1], line[2], line[3], 500*time.Millisecond); err != nil {
+				return err
+			}
+			if err := stw.End(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// record starts recording the loopback sink, the returned function waits for the recording and returns its level
+func (d *Driver) record(ctx context.Context) (func() (float64, error), error) {
+	f, err := ioutil.TempFile("", "hpsa_tone_*.raw")
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	// The output played on device 0 of the loopback card is captured on device 1.
+	cmd := exec.CommandContext(ctx, "arecord", "-D", "hw:Loopback,1,0", "-f", "S16_LE", "-c", "2", "-r", "48000",
+		"-t", "raw", "-d", fmt.Sprint(int(recordDuration.Seconds())), f.Name())
+	if err := cmd.Start(); err != nil {
+		os.Remove(f.Name())
+		return nil, errors.Wrap(err, "failed to start arecord")
+	}
+	return func() (float64, error) {
+		defer os.Remove(f.Name())
+		if err := cmd.Wait(); err != nil {
+			return 0, errors.Wrap(err, "failed to record the loopback sink")
+		}
+		raw, err := ioutil.ReadFile(f.Name())
+		if err != nil {
+			return 0, err
+		}
+		return Level(raw)
+	}, nil
+}
+
+// status waits for the pass image or the failed message
+func (d *Driver) status(ctx context.Context) (Status, error) {
+	var st Status
+	err := testing.Poll(ctx, func(ctx context.Context) error {
+		for _, m := range []struct {
+			finder *nodewith.Finder
+			status Status
+		}{{d.finders[common.CPUCheckPassImage], StatusPassed}, {d.failed, StatusFailed}} {
+			if found, err := d.ui.IsNodeFound(ctx, m.finder); err == nil && found {
+				st = m.status
+				return nil
+			}
+		}
+		return errors.New("the sub-test shows no result yet")
+	}, &testing.PollOptions{Interval: time.Second, Timeout: resultTimeout})
+	return st, err
+}
+
+// back goes back to the list, a sub-test which did not finish may have left its prompt open
+func (d *Driver) back(ctx context.Context, name string) error {
+	back := d.finders[common.ComponentItemBack]
+	return uiauto.Combine("go back to the component list",
+		d.ui.WithTimeout(10*time.Second).WaitUntilExists(back),
+		d.ui.LeftClick(back),
+		d.ui.WithTimeout(30*time.Second).WaitUntilExists(item(name)),
+	)(ctx)
+}
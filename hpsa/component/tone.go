@@ -0,0 +1,32 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package component
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// ToneThreshold is the level in dBFS above which the recording has a tone, the loopback sink is silent at about -90
+const ToneThreshold = -50.0
+
+// Level is the RMS level in dBFS of raw signed 16 bit little endian samples, all channels together
+func Level(raw []byte) (float64, error) {
+	if len(raw) < 2 || len(raw)%2 != 0 {
+		return 0, fmt.Errorf("%d bytes are no 16 bit samples", len(raw))
+	}
+	var sum float64
+	n := len(raw) / 2
+	for i := 0; i < n; i++ {
+		v := float64(int16(binary.LittleEndian.Uint16(raw[2*i:]))) / math.MaxInt16
+		sum += v * v
+	}
+	rms := math.Sqrt(sum / float64(n))
+	if rms == 0 {
+		return math.Inf(-1), nil
+	}
+	return 20 * math.Log10(rms), nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package component
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// samples encodes the values as signed 16 bit little endian samples
+func samples(values ...int16) []byte {
+	raw := make([]byte, 2*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint16(raw[2*i:], uint16(v))
+	}
+	return raw
+}
+
+func TestLevel(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		raw  []byte
+		want float64
+	}{
+		{"full scale", samples(math.MaxInt16, -math.MaxInt16), 0},
+		{"half scale", samples(math.MaxInt16/2+1, -(math.MaxInt16/2 + 1)), -6.02},
+		{"tenth", samples(3277, -3277, 3277, -3277), -20},
+		{"silence", samples(0, 0, 0, 0), math.Inf(-1)},
+	} {
+		got, err := Level(tc.raw)
+		if err != nil {
+			t.Errorf("%v: Level failed: %v", tc.name, err)
+			continue
+		}
+		if math.IsInf(tc.want, -1) {
+			if !math.IsInf(got, -1) {
+				t.Errorf("%v: Level = %v, want -Inf", tc.name, got)
+			}
+			continue
+		}
+		if math.Abs(got-tc.want) > 0.01 {
+			t.Errorf("%v: Level = %.3f, want %.2f", tc.name, got, tc.want)
+		}
+	}
+	for _, raw := range [][]byte{nil, {1}, {1, 2, 3}} {
+		if _, err := Level(raw); err == nil {
+			t.Errorf("Level(%v) succeeded, want an error for a partial sample", raw)
+		}
+	}
+	tone, _ := Level(samples(3277, -3277))
+	noise, _ := Level(samples(1, -1))
+	if tone < ToneThreshold || noise >= ToneThreshold {
+		t.Errorf("ToneThreshold %v does not tell a tone at %.1f dBFS from noise at %.1f dBFS", ToneThreshold, tone, noise)
+	}
+}
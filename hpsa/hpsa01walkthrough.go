@@ -28,12 +28,13 @@ func init() {
 	testing.AddTest(&testing.Test{
 		Func:         Hpsa01walkthrough,
 		LacrosStatus: testing.LacrosVariantExists,
-		Desc:         "POC for HPSA Tast",
+		Desc:         "Opens every dashboard card and diagnostic page and goes back to the dashboard",
 		Contacts:     []string{"xinyang.li@hp.com"},
 		BugComponent: "",
 		Data:         []string{"hpsa.json", "dashboard.json"},
-		Attr:         []string{"group:mainline"},
+		Attr:         []string{"group:hpsa", "hpsa_smoke"},
 		SoftwareDeps: []string{"chrome"},
+		Timeout:      8 * time.Minute,
 	})
 }
 
@@ -60,6 +61,8 @@ func Hpsa01walkthrough(ctx context.Context, s *testing.State) {
 	cleanupCtx := ctx
 	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
 	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
 	_, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 	// br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 	if err != nil {
@@ -242,9 +245,14 @@ func Hpsa01walkthrough(ctx context.Context, s *testing.State) {
 		s.Fatalf("Failed to click  %v button : %v ", common.FeedbackCancel, err)
 	}
 	common.TakeScreenshot(ctx, s, "HPSA_hpsa01walkthrough_specifications.png", common.ScreenshotPath)
+	scroller, err := common.NewScroller(ctx, tconn, ui, common.ScrollByWheel)
+	if err != nil {
+		s.Fatal("Failed to create the scroller: ", err)
+	}
+	defer scroller.Close(cleanupCtx)
 	networtElement := nodewith.HasClass(networkclass).Nth(networknth)
 	specificationsList := nodewith.HasClass(specificationsListclass).First()
-	if err := common.ScrollToElement(ctx, s, ui, specificationsList, networtElement); err != nil {
+	if err := common.ScrollToElement(ctx, scroller, specificationsList, networtElement); err != nil {
 		s.Fatalf("Failed to scroll to element  %v : %v ", common.Audio, err)
 	}
 	common.TakeScreenshot(ctx, s, "HPSA_hpsa01walkthrough_scrollToNetWork.png", common.ScreenshotPath)
@@ -33,12 +33,13 @@ func init() {
 	testing.AddTest(&testing.Test{
 		Func:         Hpsa03checksnpn,
 		LacrosStatus: testing.LacrosVariantExists,
-		Desc:         "POC for HPSA Tast",
+		Desc:         "Checks the serial and product number on the dashboard match the VPD",
 		Contacts:     []string{"xinyang.li@hp.com"},
 		BugComponent: "",
 		Data:         []string{"hpsa.json", "dashboard.json"},
-		Attr:         []string{"group:mainline"},
+		Attr:         []string{"group:hpsa", "hpsa_smoke"},
 		SoftwareDeps: []string{"chrome"},
+		Timeout:      5 * time.Minute,
 	})
 }
 
@@ -65,6 +66,8 @@ func Hpsa03checksnpn(ctx context.Context, s *testing.State) {
 	cleanupCtx := ctx
 	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
 	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
 	_, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 	// br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 	if err != nil {
@@ -31,12 +31,13 @@ func init() {
 	testing.AddTest(&testing.Test{
 		Func:         Hpsa04batterytest,
 		LacrosStatus: testing.LacrosVariantExists,
-		Desc:         "POC for HPSA Tast",
+		Desc:         "Runs the battery check and checks it finishes without an exception",
 		Contacts:     []string{"xinyang.li@hp.com"},
 		BugComponent: "",
 		Data:         []string{"hpsa.json", "dashboard.json"},
-		Attr:         []string{"group:mainline"},
+		Attr:         []string{"group:hpsa", "hpsa_smoke"},
 		SoftwareDeps: []string{"chrome"},
+		Timeout:      5 * time.Minute,
 	})
 }
 
@@ -63,6 +64,8 @@ func Hpsa04batterytest(ctx context.Context, s *testing.State) {
 	cleanupCtx := ctx
 	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
 	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
 	_, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 	// br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 	if err != nil {
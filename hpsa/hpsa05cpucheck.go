@@ -30,12 +30,13 @@ func init() {
 	testing.AddTest(&testing.Test{
 		Func:         Hpsa05cpucheck,
 		LacrosStatus: testing.LacrosVariantExists,
-		Desc:         "POC for HPSA Tast",
+		Desc:         "Runs the CPU check and waits for its pass mark",
 		Contacts:     []string{"xinyang.li@hp.com"},
 		BugComponent: "",
 		Data:         []string{"hpsa.json", "dashboard.json"},
-		Attr:         []string{"group:mainline"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
 		SoftwareDeps: []string{"chrome"},
+		Timeout:      15 * time.Minute,
 	})
 }
 
@@ -62,6 +63,8 @@ func Hpsa05cpucheck(ctx context.Context, s *testing.State) {
 	cleanupCtx := ctx
 	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
 	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
 	_, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 	// br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 	if err != nil {
@@ -31,12 +31,13 @@ func init() {
 	testing.AddTest(&testing.Test{
 		Func:         Hpsa06signwelcome,
 		LacrosStatus: testing.LacrosVariantExists,
-		Desc:         "POC for HPSA Tast",
+		Desc:         "Goes through the welcome pages to the HP ID sign in and the warranty option",
 		Contacts:     []string{"xinyang.li@hp.com"},
 		BugComponent: "",
 		Data:         []string{"hpsa.json", "dashboard.json", "profile.json"},
-		Attr:         []string{"group:mainline"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
 		SoftwareDeps: []string{"chrome"},
+		Timeout:      8 * time.Minute,
 	})
 }
 
@@ -63,6 +64,8 @@ func Hpsa06signwelcome(ctx context.Context, s *testing.State) {
 	cleanupCtx := ctx
 	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
 	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
 	_, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 	// br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 	if err != nil {
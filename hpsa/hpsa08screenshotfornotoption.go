@@ -29,12 +29,13 @@ func init() {
 	testing.AddTest(&testing.Test{
 		Func:         Hpsa08screenshotfornotoption,
 		LacrosStatus: testing.LacrosVariantExists,
-		Desc:         "POC for HPSA Tast",
+		Desc:         "Takes screenshots of the dashboard pages without the warranty option for translation review",
 		Contacts:     []string{"xinyang.li@hp.com"},
 		BugComponent: "",
 		Data:         []string{"hpsa.json", "dashboard.json", "profile.json"},
-		Attr:         []string{"group:mainline"},
+		Attr:         []string{"group:hpsa", "hpsa_l10n"},
 		SoftwareDeps: []string{"chrome"},
+		Timeout:      10 * time.Minute,
 	})
 }
 
@@ -65,6 +66,8 @@ func Hpsa08screenshotfornotoption(ctx context.Context, s *testing.State) {
 	cleanupCtx := ctx
 	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
 	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
 	br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 
 	// br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
@@ -223,9 +226,14 @@ func Hpsa08screenshotfornotoption(ctx context.Context, s *testing.State) {
 		s.Fatalf("Failed to click  %v button : %v ", common.FeedbackCancel, err)
 	}
 	common.TakeScreenshot(ctx, s, "Hpsa08screenshot_specifications.png", common.ScreenshotPath)
+	scroller, err := common.NewScroller(ctx, tconn, ui, common.ScrollByWheel)
+	if err != nil {
+		s.Fatal("Failed to create the scroller: ", err)
+	}
+	defer scroller.Close(cleanupCtx)
 	networtElement := nodewith.HasClass(networkclass).Nth(networknth)
 	specificationsList := nodewith.HasClass(specificationsListclass).First()
-	if err := common.ScrollToElement(ctx, s, ui, specificationsList, networtElement); err != nil {
+	if err := common.ScrollToElement(ctx, scroller, specificationsList, networtElement); err != nil {
 		s.Fatalf("Failed to scroll to element  %v : %v ", common.Network, err)
 	}
 	common.TakeScreenshot(ctx, s, "Hpsa08screenshot_scrollToNetWork.png", common.ScreenshotPath)
@@ -28,12 +28,13 @@ func init() {
 	testing.AddTest(&testing.Test{
 		Func:         Hpsa08screenshotfornotoptionva,
 		LacrosStatus: testing.LacrosVariantExists,
-		Desc:         "POC for HPSA Tast",
+		Desc:         "Takes screenshots of the virtual agent without the warranty option for translation review",
 		Contacts:     []string{"xinyang.li@hp.com"},
 		BugComponent: "",
 		Data:         []string{"hpsa.json", "dashboard.json", "profile.json"},
-		Attr:         []string{"group:mainline"},
+		Attr:         []string{"group:hpsa", "hpsa_l10n"},
 		SoftwareDeps: []string{"chrome"},
+		Timeout:      5 * time.Minute,
 	})
 }
 
@@ -64,6 +65,8 @@ func Hpsa08screenshotfornotoptionva(ctx context.Context, s *testing.State) {
 	cleanupCtx := ctx
 	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
 	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
 	br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 
 	// br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
@@ -8,19 +8,19 @@ import (
 
 	// Standard library packages
 	"context"
-	"fmt"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	//chromiumos/ packages
 	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/bundles/cros/hpsa/stress"
 	"chromiumos/tast/local/chrome"
 	"chromiumos/tast/local/chrome/ash"
 	"chromiumos/tast/local/chrome/browser"
 	"chromiumos/tast/local/chrome/browser/browserfixt"
 	"chromiumos/tast/local/chrome/uiauto"
 	"chromiumos/tast/local/chrome/uiauto/faillog"
-	"chromiumos/tast/local/chrome/uiauto/nodewith"
 
 	"go.chromium.org/tast/core/ctxutil"
 	"go.chromium.org/tast/core/testing"
@@ -30,16 +30,64 @@ func init() {
 	testing.AddTest(&testing.Test{
 		Func:         Hpsa09stresscpu,
 		LacrosStatus: testing.LacrosVariantExists,
-		Desc:         "POC for HPSA Tast",
+		Desc:         "Runs diagnostics repeatedly under load and disruptions and records every run",
 		Contacts:     []string{"xinyang.li@hp.com"},
 		BugComponent: "",
 		Data:         []string{"hpsa.json", "dashboard.json", "profile.json"},
-		Attr:         []string{"group:mainline"},
+		Vars:         []string{"hpsa.stressIterations", "hpsa.stressDuration"},
+		Attr:         []string{"group:stress"},
 		SoftwareDeps: []string{"chrome"},
+		Timeout:      3 * time.Hour,
+		Params: []testing.Param{{
+			Name: "cpu",
+			Val: stress.Config{
+				Diagnostics: []string{common.CheckCPU},
+				Iterations:  10,
+				RunTimeout:  10 * time.Minute,
+			},
+		}, {
+			Name: "memory_load",
+			Val: stress.Config{
+				Diagnostics: []string{common.BatteryCheck, common.CheckSystemMemory},
+				Iterations:  10,
+				Load:        stress.LoadMemory,
+				RunTimeout:  15 * time.Minute,
+			},
+		}, {
+			Name: "all_disrupted",
+			Val: stress.Config{
+				Diagnostics:  []string{common.BatteryCheck, common.CheckCPU, common.CheckSystemMemory, common.CheckConnectivity, common.CheckStorage},
+				Duration:     2 * time.Hour,
+				Load:         stress.LoadCPU,
+				Disruptions:  []stress.Disruption{stress.DisruptRestart, stress.DisruptSuspend, stress.DisruptNetwork},
+				DisruptEvery: 1,
+				RunTimeout:   15 * time.Minute,
+			},
+		}},
 	})
 }
 
+// Hpsa09stresscpu runs the diagnostics of the parameter back to back.
+// The iterations and the duration can be changed with -var=hpsa.stressIterations=N and -var=hpsa.stressDuration=1h.
 func Hpsa09stresscpu(ctx context.Context, s *testing.State) {
+	cfg := s.Param().(stress.Config)
+	if v, ok := s.Var("hpsa.stressIterations"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			s.Fatalf("Bad hpsa.stressIterations %q: %v", v, err)
+		}
+		cfg.Iterations = n
+	}
+	if v, ok := s.Var("hpsa.stressDuration"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			s.Fatalf("Bad hpsa.stressDuration %q: %v", v, err)
+		}
+		cfg.Duration = d
+	}
+	if err := cfg.Validate(); err != nil {
+		s.Fatal("Bad stress config: ", err)
+	}
 	// for _, language := range common.AllLanguage {
 	// hpsaSteps(ctx, s, language)
 	//Need copy the file to the path
@@ -74,6 +122,8 @@ func Hpsa09stresscpu(ctx context.Context, s *testing.State) {
 	// context.WithDeadline(ctx, dl.Add(5*time.Minute))
 	s.Log(ctx.Deadline())
 	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
 	br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 
 	// br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
@@ -102,7 +152,7 @@ func Hpsa09stresscpu(ctx context.Context, s *testing.State) {
 		s.Fatalf("Failed to ensure the tablet mode is set to %v: %v", tabletMode, err)
 	}
 	defer cleanup(cleanupCtx)
-	_, err = common.ManualInstallHPSA(ctx, tconn, cr, bt, common.AppURLITG)
+	appID, err := common.ManualInstallHPSA(ctx, tconn, cr, bt, common.AppURLITG)
 	if err != nil {
 		s.Fatal("Failed to manually install HPSA: ", err)
 	}
@@ -113,50 +163,27 @@ func Hpsa09stresscpu(ctx context.Context, s *testing.State) {
 	// Do pretest after oobe
 	common.PreTest(ctx, s, bt, ui, path)
 
-	//Check CPU screenshot
-	var checkCPUClass, _, _ = common.GetJSONDashboard(common.CheckCPU, dashboardPath)
-	if _, err := common.ClickDashboardBtns(ctx, s, bt, ui, common.CheckCPU, checkCPUClass); err != nil {
-		s.Fatalf("Failed to click %v button : %v", common.CheckCPU, err)
-	}
-	common.TakeScreenshot(ctx, s, "Hpsa08screenshotfornotoption_checkCPU.png", common.ScreenshotPath)
-	var runCPUCheckClass, _, _ = common.GetJSONDashboard(common.RunBatteryCheck, dashboardPath)
-	if _, err := common.ClickDashboardBtns(ctx, s, bt, ui, common.RunBatteryCheck, runCPUCheckClass); err != nil {
-		s.Fatalf("Can not click %v : %v", common.RunBatteryCheck, err)
-	}
-	s.Logf("Asserting that mouse click works on the %v button in %v browser", common.CPUCheckCancel, bt)
-	// var cpuCheckPassClass, _, _ = common.GetJSONDashboard(common.CPUCheckPassImage, dashboardPath)
-	// if err := testing.Poll(ctx, func(ctx context.Context) error {
-	// 	if err := uiauto.Combine(
-	// 		fmt.Sprintf("Click the %v button in %v browser", common.CPUCheckPassImage, bt),
-	// 		ui.WaitUntilExists(nodewith.HasClass(cpuCheckPassClass).Name("Passed").First()),
-	// 		ui.LeftClick(nodewith.HasClass(cpuCheckPassClass).Name("Passed").First()),
-	// 	)(ctx); err != nil {
-	// 		s.Logf("Failed to find and click the %v button in %v: %v", common.CPUCheckPassImage, bt, err)
-	// 		return err
-	// 	}
-	// 	return nil
-	// }, &testing.PollOptions{Interval: 2 * time.Minute,
-	// 	Timeout: 6 * time.Minute}); err != nil {
-	// 	s.Log("Can not finish the action: ", err)
-	// }
-	//GoBigSleepLint wait for run CPU Check enable
-	// testing.Sleep(ctx, 3*time.Minute)
-	if err := testing.Poll(ctx, func(ctx context.Context) error {
-		if err := uiauto.Combine(
-			fmt.Sprintf("Click the %v button in %v browser", common.RunBatteryCheck, bt),
-			ui.WaitUntilExists(nodewith.HasClass(runCPUCheckClass).Name("Run CPU check")),
-			ui.LeftClick(nodewith.HasClass(runCPUCheckClass).Name("Run CPU check")),
-		)(ctx); err != nil {
-			s.Logf("Failed to find and click the %v button in %v: %v", common.RunBatteryCheck, bt, err)
-			return err
-		}
-		return nil
-	}, &testing.PollOptions{Interval: 10 * time.Second,
-		Timeout: 3 * time.Minute}); err != nil {
-		s.Fatal("Can not finish the action: ", err)
-	}
-	s.Log("All Complete")
-	// testing.Sleep(ctx, 40*time.Second)
-	// s.Fatal("Get ui dump")
-
+	runner, err := stress.NewRunner(cfg, tconn, ui, appID, dashboardPath, s.OutDir())
+	if err != nil {
+		s.Fatal("Failed to create the stress runner: ", err)
+	}
+	start := time.Now()
+	records, runErr := runner.Run(ctx)
+	summary := stress.Summarize(cfg, records, time.Since(start))
+	if err := stress.Write(s.OutDir(), records, summary); err != nil {
+		s.Error("Failed to write the stress results: ", err)
+	}
+	s.Logf("Stress summary after %d iterations: %v", summary.Iterations, summary.Total)
+	for d, st := range summary.ByDiagnostic {
+		s.Logf("%v: %v", d, st)
+	}
+	if runErr != nil {
+		s.Fatal("Stress run stopped: ", runErr)
+	}
+	if summary.Total.Runs == 0 {
+		s.Fatal("No diagnostic was run")
+	}
+	if summary.Total.Failures > 0 {
+		s.Errorf("%d of %d runs failed, reasons: %v", summary.Total.Failures, summary.Total.Runs, summary.FailedReasons)
+	}
 }
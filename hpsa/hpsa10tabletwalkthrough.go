@@ -0,0 +1,169 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/ash"
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/browser/browserfixt"
+	"chromiumos/tast/local/chrome/display"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/faillog"
+
+	"go.chromium.org/tast/core/ctxutil"
+	"go.chromium.org/tast/core/testing"
+	"go.chromium.org/tast/core/testing/hwdep"
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa10tabletwalkthrough,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Taps through the dashboard in tablet mode in both orientations",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      10 * time.Minute,
+		HardwareDeps: hwdep.D(hwdep.TouchScreen(), hwdep.FormFactor(hwdep.Convertible, hwdep.Detachable, hwdep.Chromeslate)),
+		Params: []testing.Param{{
+			Name: "landscape",
+			Val:  display.Rotate0,
+		}, {
+			Name: "portrait",
+			Val:  display.Rotate90,
+		}},
+	})
+}
+
+// tabletWalkthroughSteps are the dashboard elements tapped by the walkthrough in order.
+var tabletWalkthroughSteps = []string{
+	common.WarrantyCard,
+	common.AdditionalInformation,
+	common.WarrantyBack,
+	common.CheckSystemMemory,
+	common.CheckSystemMemoryBack,
+	common.BatteryCheck,
+	common.BatteryCheckBack,
+	common.ComponentTest,
+	common.ComponentTestBack,
+	common.CheckStorage,
+	common.CheckStorageBack,
+	common.CheckCPU,
+	common.CheckCPUBack,
+	common.CheckConnectivity,
+	common.CheckConnectivityBack,
+	common.Settings,
+	common.AboutHPSA,
+	common.Settings,
+	common.SeeAll,
+	common.Feedback,
+	common.OneStar,
+	common.TwoStars,
+	common.ThreeStars,
+	common.FourStars,
+	common.FiveStars,
+	common.FeedbackCancel,
+	common.Specifications,
+	common.SpecificationsClose,
+}
+
+func Hpsa10tabletwalkthrough(ctx context.Context, s *testing.State) {
+	rotation := s.Param().(display.RotationAngle)
+	//Need copy the file to the path
+	extDir := filepath.Dir(common.ExtensionDir)
+	extID, err := chrome.ComputeExtensionID(extDir)
+	if err != nil {
+		s.Fatalf("Failed to compute extension ID for %v: %v", extDir, err)
+	}
+	s.Log("Extension ID is ", extID)
+	//Create the chrome with the extra arguments
+	cr, err := chrome.New(ctx, chrome.UnpackedExtension(extDir),
+		chrome.ExtraArgs(common.Proxy),
+		chrome.ExtraArgs(common.Language),
+	)
+	if err != nil {
+		s.Fatal("Chrome login failed: ", err)
+	}
+	defer cr.Close(ctx)
+
+	bt := browser.TypeAsh
+	// Reserve ten seconds for cleanup.
+	cleanupCtx := ctx
+	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
+	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
+	_, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
+	if err != nil {
+		s.Fatal("Failed to set up browser: ", err)
+	}
+	defer closeBrowser(cleanupCtx)
+	tconn, err := cr.TestAPIConn(ctx)
+	if err != nil {
+		s.Fatal("Failed to create Test API connection: ", err)
+	}
+	const tabletMode = true
+	cleanup, err := ash.EnsureTabletModeEnabled(ctx, tconn, tabletMode)
+	if err != nil {
+		s.Fatalf("Failed to ensure the tablet mode is set to %v: %v", tabletMode, err)
+	}
+	defer cleanup(cleanupCtx)
+	info, err := display.GetInternalInfo(ctx, tconn)
+	if err != nil {
+		s.Fatal("Failed to get the internal display info: ", err)
+	}
+	if err := display.SetDisplayRotationSync(ctx, tconn, info.ID, rotation); err != nil {
+		s.Fatalf("Failed to rotate the display to %v: %v", rotation, err)
+	}
+	defer display.SetDisplayRotationSync(cleanupCtx, tconn, info.ID, display.Rotate0)
+	ui := uiauto.New(tconn)
+	_, err = common.ManualInstallHPSA(ctx, tconn, cr, bt, common.AppURLITG)
+	if err != nil {
+		s.Fatal("Failed to manually install HPSA: ", err)
+	}
+	defer faillog.DumpUITreeOnError(cleanupCtx, s.OutDir(), s.HasError, tconn)
+	// The touchscreen is opened after the rotation so the coordinates follow the new layout.
+	tc, err := common.NewTouch(ctx, tconn, ui)
+	if err != nil {
+		s.Fatal("Failed to set up the touchscreen: ", err)
+	}
+	defer tc.Close()
+	var path = s.DataPath("hpsa.json")
+	var dashboardPath = s.DataPath("dashboard.json")
+	//Do pretest after oobe
+	if _, err := common.PreTestTablet(ctx, s, bt, ui, tc, path); err != nil {
+		s.Fatal("Failed to navigate to dashboard: ", err)
+	}
+	common.TakeScreenshot(ctx, s, fmt.Sprintf("Hpsa10tabletwalkthrough_%v_dashboard.png", rotation), common.ScreenshotPath)
+	for i, element := range tabletWalkthroughSteps {
+		elementClass, elementNTH, err := common.GetJSONDashboard(element, dashboardPath)
+		if err != nil {
+			s.Fatalf("Failed to get the json data for %v: %v", element, err)
+		}
+		if _, err := common.TapDashboardBtnsNTH(ctx, s, bt, ui, tc, element, elementClass, elementNTH); err != nil {
+			s.Fatalf("Failed to tap %v button : %v", element, err)
+		}
+		common.TakeScreenshot(ctx, s, fmt.Sprintf("Hpsa10tabletwalkthrough_%v_%02d_%v.png", rotation, i, element), common.ScreenshotPath)
+	}
+	exceptionName := fmt.Sprintf("hpsa10tabletwalkthrough_%v_Exception.png", rotation)
+	if err := common.FindException(ctx, ui, s, exceptionName); err != nil {
+		s.Log("Can not finish the action: ", err)
+	}
+	if common.CheckExceptionFailed(exceptionName) {
+		s.Fatal("Test failed, find the exception popup")
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/ash"
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/browser/browserfixt"
+	"chromiumos/tast/local/chrome/display"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/faillog"
+
+	"go.chromium.org/tast/core/ctxutil"
+	"go.chromium.org/tast/core/testing"
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa11layoutmatrix,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Checks the page layout at every window size and display zoom for the device scale factor",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      60 * time.Minute,
+		// The device scale factor can only be forced when Chrome starts, so it is a parameter.
+		Params: []testing.Param{{
+			Name: "dsf_1_0",
+			Val:  "",
+		}, {
+			Name: "dsf_1_25",
+			Val:  "--force-device-scale-factor=1.25",
+		}, {
+			Name: "dsf_2_0",
+			Val:  "--force-device-scale-factor=2",
+		}},
+	})
+}
+
+// layoutZoomFactors are the display zoom factors checked for each window layout.
+var layoutZoomFactors = []float64{0.9, 1.0, 1.25, 1.5}
+
+// layoutWindows are the HPSA window sizes checked for each zoom factor.
+var layoutWindows = []common.WindowLayout{common.WindowMaximized, common.WindowSnappedHalf, common.WindowMinimumSize}
+
+// layoutStep is one click of the walkthrough, page names the page the click opens, it is empty for the clicks going back.
+type layoutStep struct {
+	click string
+	page  string
+}
+
+// layoutWalk are the clicks of the walkthrough in its order, Hpsa01walkthrough, ending back on the dashboard.
+// About HPSA is opened from Settings and Feedback from the support page of See all.
+var layoutWalk = []layoutStep{
+	{common.WarrantyCard, common.WarrantyCard},
+	{common.AdditionalInformation, common.AdditionalInformation},
+	{common.WarrantyBack, ""},
+	{common.CheckSystemMemory, common.CheckSystemMemory},
+	{common.CheckSystemMemoryBack, ""},
+	{common.BatteryCheck, common.BatteryCheck},
+	{common.BatteryCheckBack, ""},
+	{common.ComponentTest, common.ComponentTest},
+	{common.ComponentTestBack, ""},
+	{common.CheckStorage, common.CheckStorage},
+	{common.CheckStorageBack, ""},
+	{common.CheckCPU, common.CheckCPU},
+	{common.CheckCPUBack, ""},
+	{common.CheckConnectivity, common.CheckConnectivity},
+	{common.CheckConnectivityBack, ""},
+	{common.Settings, common.Settings},
+	{common.AboutHPSA, common.AboutHPSA},
+	{common.Settings, ""},
+	{common.SeeAll, common.SeeAll},
+	{common.Feedback, common.Feedback},
+	{common.FeedbackCancel, ""},
+	{common.Specifications, common.Specifications},
+	{common.SpecificationsClose, ""},
+}
+
+// layoutResult is one page of the matrix written to layout_issues.json
+type layoutResult struct {
+	Zoom   float64              `json:"zoom"`
+	Window common.WindowLayout  `json:"window"`
+	Page   string               `json:"page"`
+	Issues []common.LayoutIssue `json:"issues"`
+}
+
+func Hpsa11layoutmatrix(ctx context.Context, s *testing.State) {
+	//Need copy the file to the path
+	extDir := filepath.Dir(common.ExtensionDir)
+	extID, err := chrome.ComputeExtensionID(extDir)
+	if err != nil {
+		s.Fatalf("Failed to compute extension ID for %v: %v", extDir, err)
+	}
+	s.Log("Extension ID is ", extID)
+	//Create the chrome with the extra arguments
+	opts := []chrome.Option{chrome.UnpackedExtension(extDir),
+		chrome.ExtraArgs(common.Proxy),
+		chrome.ExtraArgs(common.Language),
+	}
+	if dsf := s.Param().(string); dsf != "" {
+		opts = append(opts, chrome.ExtraArgs(dsf))
+	}
+	cr, err := chrome.New(ctx, opts...)
+	if err != nil {
+		s.Fatal("Chrome login failed: ", err)
+	}
+	defer cr.Close(ctx)
+
+	bt := browser.TypeAsh
+	// Reserve ten seconds for cleanup.
+	cleanupCtx := ctx
+	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
+	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
+	_, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
+	if err != nil {
+		s.Fatal("Failed to set up browser: ", err)
+	}
+	defer closeBrowser(cleanupCtx)
+	tconn, err := cr.TestAPIConn(ctx)
+	if err != nil {
+		s.Fatal("Failed to create Test API connection: ", err)
+	}
+	const tabletMode = false
+	cleanup, err := ash.EnsureTabletModeEnabled(ctx, tconn, tabletMode)
+	if err != nil {
+		s.Fatalf("Failed to ensure the tablet mode is set to %v: %v", tabletMode, err)
+	}
+	defer cleanup(cleanupCtx)
+	ui := uiauto.New(tconn)
+	_, err = common.ManualInstallHPSA(ctx, tconn, cr, bt, common.AppURLITG)
+	if err != nil {
+		s.Fatal("Failed to manually install HPSA: ", err)
+	}
+	defer faillog.DumpUITreeOnError(cleanupCtx, s.OutDir(), s.HasError, tconn)
+	var path = s.DataPath("hpsa.json")
+	var dashboardPath = s.DataPath("dashboard.json")
+	//Do pretest after oobe
+	common.PreTest(ctx, s, bt, ui, path)
+
+	info, err := display.GetPrimaryInfo(ctx, tconn)
+	if err != nil {
+		s.Fatal("Failed to get the primary display info: ", err)
+	}
+	defer display.SetDisplayProperties(cleanupCtx, tconn, info.ID, display.DisplayProperties{DisplayZoomFactor: &info.DisplayZoomFactor})
+
+	var results []layoutResult
+	checkPage := func(zoom float64, window common.WindowLayout, page string) {
+		name := fmt.Sprintf("%v_zoom%v_%v_%v.png", s.TestName(), zoom, window, page)
+		common.TakeScreenshot(ctx, s, name, common.ScreenshotPath)
+		nodes, viewport, err := common.CollectLayoutNodes(ctx, ui)
+		if err != nil {
+			s.Errorf("Failed to collect the layout of %v at zoom %v in %v window: %v", page, zoom, window, err)
+			return
+		}
+		issues := common.CheckLayout(nodes, viewport)
+		for _, issue := range issues {
+			s.Errorf("Layout issue on %v at zoom %v in %v window: %v", page, zoom, window, issue)
+		}
+		results = append(results, layoutResult{Zoom: zoom, Window: window, Page: page, Issues: issues})
+	}
+	for _, zoom := range layoutZoomFactors {
+		if !zoomAvailable(info.AvailableDisplayZoomFactors, zoom) {
+			s.Logf("Zoom factor %v is not available on this display, skipping", zoom)
+			continue
+		}
+		zoom := zoom
+		if err := display.SetDisplayProperties(ctx, tconn, info.ID, display.DisplayProperties{DisplayZoomFactor: &zoom}); err != nil {
+			s.Fatalf("Failed to set the display zoom to %v: %v", zoom, err)
+		}
+		for _, window := range layoutWindows {
+			if err := common.SetWindowLayout(ctx, tconn, window); err != nil {
+				s.Fatalf("Failed to set the HPSA window to %v: %v", window, err)
+			}
+			checkPage(zoom, window, "Dashboard")
+			for _, step := range layoutWalk {
+				class, nth, err := common.GetJSONDashboard(step.click, dashboardPath)
+				if err != nil {
+					s.Fatalf("Can not get the json data for %v: %v", step.click, err)
+				}
+				if _, err := common.ClickDashboardBtnsNTH(ctx, s, bt, ui, step.click, class, nth); err != nil {
+					s.Fatalf("Failed to click %v button : %v", step.click, err)
+				}
+				if step.page != "" {
+					checkPage(zoom, window, step.page)
+				}
+			}
+		}
+	}
+
+	b, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		s.Fatal("Failed to marshal the layout results: ", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.OutDir(), "layout_issues.json"), b, 0644); err != nil {
+		s.Error("Failed to write the layout results: ", err)
+	}
+}
+
+// zoomAvailable reports whether the display supports the zoom factor.
+func zoomAvailable(available []float64, zoom float64) bool {
+	for _, z := range available {
+		if z-zoom < 0.01 && zoom-z < 0.01 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"path/filepath"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/ash"
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/browser/browserfixt"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/faillog"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+
+	"go.chromium.org/tast/core/ctxutil"
+	"go.chromium.org/tast/core/testing"
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa12keyboardnav,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Checks every page can be used with the keyboard in the expected focus order",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json", "focus_order.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      10 * time.Minute,
+	})
+}
+
+// keyboardCards are the dashboard cards opened with the keyboard, each with the button to leave it.
+var keyboardCards = []struct {
+	open string
+	back string
+}{
+	{common.WarrantyCard, common.WarrantyBack},
+	{common.CheckSystemMemory, common.CheckSystemMemoryBack},
+	{common.BatteryCheck, common.BatteryCheckBack},
+	{common.ComponentTest, common.ComponentTestBack},
+	{common.CheckStorage, common.CheckStorageBack},
+	{common.CheckCPU, common.CheckCPUBack},
+	{common.CheckConnectivity, common.CheckConnectivityBack},
+	{common.Specifications, common.SpecificationsClose},
+}
+
+func Hpsa12keyboardnav(ctx context.Context, s *testing.State) {
+	//Need copy the file to the path
+	extDir := filepath.Dir(common.ExtensionDir)
+	extID, err := chrome.ComputeExtensionID(extDir)
+	if err != nil {
+		s.Fatalf("Failed to compute extension ID for %v: %v", extDir, err)
+	}
+	s.Log("Extension ID is ", extID)
+	//Create the chrome with the extra arguments
+	cr, err := chrome.New(ctx, chrome.UnpackedExtension(extDir),
+		chrome.ExtraArgs(common.Proxy),
+		chrome.ExtraArgs(common.Language),
+	)
+	if err != nil {
+		s.Fatal("Chrome login failed: ", err)
+	}
+	defer cr.Close(ctx)
+
+	bt := browser.TypeAsh
+	// Reserve ten seconds for cleanup.
+	cleanupCtx := ctx
+	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
+	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
+	_, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
+	if err != nil {
+		s.Fatal("Failed to set up browser: ", err)
+	}
+	defer closeBrowser(cleanupCtx)
+	tconn, err := cr.TestAPIConn(ctx)
+	if err != nil {
+		s.Fatal("Failed to create Test API connection: ", err)
+	}
+	const tabletMode = false
+	cleanup, err := ash.EnsureTabletModeEnabled(ctx, tconn, tabletMode)
+	if err != nil {
+		s.Fatalf("Failed to ensure the tablet mode is set to %v: %v", tabletMode, err)
+	}
+	defer cleanup(cleanupCtx)
+	ui := uiauto.New(tconn)
+	_, err = common.ManualInstallHPSA(ctx, tconn, cr, bt, common.AppURLITG)
+	if err != nil {
+		s.Fatal("Failed to manually install HPSA: ", err)
+	}
+	defer faillog.DumpUITreeOnError(cleanupCtx, s.OutDir(), s.HasError, tconn)
+	var path = s.DataPath("hpsa.json")
+	var dashboardPath = s.DataPath("dashboard.json")
+	order, err := common.GetFocusOrderJSON(s.DataPath("focus_order.json"))
+	if err != nil {
+		s.Fatal("Failed to read the focus order: ", err)
+	}
+	kn, err := common.NewKeyboardNavigator(ctx, ui)
+	if err != nil {
+		s.Fatal("Failed to set up the keyboard: ", err)
+	}
+	defer kn.Close(cleanupCtx)
+
+	//Welcome with the keyboard only, the focus order is checked on the consent page
+	if _, err := common.KeyboardWelcomeSteps(ctx, s, bt, kn, path, common.KeyboardWelcomeBeforeConsent); err != nil {
+		s.Fatal("Failed to reach the consent page with the keyboard: ", err)
+	}
+	var welcomeTargets []common.FocusTarget
+	for _, element := range order.Welcome {
+		class, nth, err := common.GetJSON(element, path)
+		if err != nil {
+			s.Fatalf("Failed to get the json data for %v: %v", element, err)
+		}
+		target, err := kn.Target(ctx, element, class, nth)
+		if err != nil {
+			s.Fatal("Failed to find the welcome control: ", err)
+		}
+		welcomeTargets = append(welcomeTargets, target)
+	}
+	checkFocus(ctx, s, kn, "welcome", welcomeTargets)
+	if _, err := common.KeyboardWelcomeSteps(ctx, s, bt, kn, path, common.KeyboardWelcomeConsent); err != nil {
+		s.Fatal("Failed to reach the dashboard with the keyboard: ", err)
+	}
+	common.TakeScreenshot(ctx, s, "Hpsa12keyboardnav_dashboard.png", common.ScreenshotPath)
+
+	//Dashboard focus order
+	var dashboardTargets []common.FocusTarget
+	for _, element := range order.Dashboard {
+		class, nth, err := common.GetJSONDashboard(element, dashboardPath)
+		if err != nil {
+			s.Fatalf("Failed to get the json data for %v: %v", element, err)
+		}
+		target, err := kn.Target(ctx, element, class, nth)
+		if err != nil {
+			s.Fatal("Failed to find the dashboard control: ", err)
+		}
+		dashboardTargets = append(dashboardTargets, target)
+	}
+	checkFocus(ctx, s, kn, "dashboard", dashboardTargets)
+
+	//Every card is opened and closed with the keyboard
+	for _, card := range keyboardCards {
+		openClass, openNTH, err := common.GetJSONDashboard(card.open, dashboardPath)
+		if err != nil {
+			s.Fatalf("Failed to get the json data for %v: %v", card.open, err)
+		}
+		if err := kn.Activate(ctx, nodewith.HasClass(openClass).Nth(openNTH)); err != nil {
+			s.Fatalf("Failed to open %v with the keyboard: %v", card.open, err)
+		}
+		common.TakeScreenshot(ctx, s, "Hpsa12keyboardnav_"+card.open+".png", common.ScreenshotPath)
+		backClass, backNTH, err := common.GetJSONDashboard(card.back, dashboardPath)
+		if err != nil {
+			s.Fatalf("Failed to get the json data for %v: %v", card.back, err)
+		}
+		if err := kn.Activate(ctx, nodewith.HasClass(backClass).Nth(backNTH)); err != nil {
+			s.Fatalf("Failed to close %v with the keyboard: %v", card.open, err)
+		}
+	}
+}
+
+// checkFocus tabs through the page in both directions and reports order and reachability problems.
+func checkFocus(ctx context.Context, s *testing.State, kn *common.KeyboardNavigator, page string, expected []common.FocusTarget) {
+	forward, err := kn.TabCycle(ctx, common.KeyTab)
+	if err != nil {
+		s.Fatalf("Failed to tab through the %v page: %v", page, err)
+	}
+	for i, stop := range forward {
+		s.Logf("%v focus stop %d: %v %q .%v", page, i, stop.Role, stop.Name, stop.ClassName)
+	}
+	if err := common.CheckFocusOrder(forward, expected); err != nil {
+		s.Errorf("Wrong focus order on the %v page: %v", page, err)
+	}
+	backward, err := kn.TabCycle(ctx, common.KeyShiftTab)
+	if err != nil {
+		s.Fatalf("Failed to shift tab through the %v page: %v", page, err)
+	}
+	if err := common.CheckReverseOrder(forward, backward); err != nil {
+		s.Errorf("Wrong reverse focus order on the %v page: %v", page, err)
+	}
+	missing, err := kn.Unreachable(ctx, forward)
+	if err != nil {
+		s.Fatalf("Failed to list the controls of the %v page: %v", page, err)
+	}
+	for _, n := range missing {
+		s.Errorf("Control %v %q .%v on the %v page can not be reached with the keyboard", n.Role, n.Name, n.ClassName, page)
+	}
+}
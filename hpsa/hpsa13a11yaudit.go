@@ -0,0 +1,140 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/a11yaudit"
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/ash"
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/browser/browserfixt"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/faillog"
+
+	"go.chromium.org/tast/core/ctxutil"
+	"go.chromium.org/tast/core/testing"
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa13a11yaudit,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Runs the accessibility rules and the contrast check on every page",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      10 * time.Minute,
+	})
+}
+
+// auditPage is the a11y audit result of one page written to a11y_audit.json
+type auditPage struct {
+	Page       string                `json:"page"`
+	Violations []a11yaudit.Violation `json:"violations"`
+}
+
+func Hpsa13a11yaudit(ctx context.Context, s *testing.State) {
+	//Need copy the file to the path
+	extDir := filepath.Dir(common.ExtensionDir)
+	extID, err := chrome.ComputeExtensionID(extDir)
+	if err != nil {
+		s.Fatalf("Failed to compute extension ID for %v: %v", extDir, err)
+	}
+	s.Log("Extension ID is ", extID)
+	//Create the chrome with the extra arguments
+	cr, err := chrome.New(ctx, chrome.UnpackedExtension(extDir),
+		chrome.ExtraArgs(common.Proxy),
+		chrome.ExtraArgs(common.Language),
+	)
+	if err != nil {
+		s.Fatal("Chrome login failed: ", err)
+	}
+	defer cr.Close(ctx)
+
+	bt := browser.TypeAsh
+	// Reserve ten seconds for cleanup.
+	cleanupCtx := ctx
+	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
+	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
+	_, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
+	if err != nil {
+		s.Fatal("Failed to set up browser: ", err)
+	}
+	defer closeBrowser(cleanupCtx)
+	tconn, err := cr.TestAPIConn(ctx)
+	if err != nil {
+		s.Fatal("Failed to create Test API connection: ", err)
+	}
+	const tabletMode = false
+	cleanup, err := ash.EnsureTabletModeEnabled(ctx, tconn, tabletMode)
+	if err != nil {
+		s.Fatalf("Failed to ensure the tablet mode is set to %v: %v", tabletMode, err)
+	}
+	defer cleanup(cleanupCtx)
+	ui := uiauto.New(tconn)
+	_, err = common.ManualInstallHPSA(ctx, tconn, cr, bt, common.AppURLITG)
+	if err != nil {
+		s.Fatal("Failed to manually install HPSA: ", err)
+	}
+	defer faillog.DumpUITreeOnError(cleanupCtx, s.OutDir(), s.HasError, tconn)
+	var path = s.DataPath("hpsa.json")
+	var dashboardPath = s.DataPath("dashboard.json")
+	//Do pretest after oobe
+	common.PreTest(ctx, s, bt, ui, path)
+
+	var report []auditPage
+	audit := func(page string) {
+		tree, violations, err := common.AuditPage(ctx, cr, tconn, ui, page)
+		if err != nil {
+			s.Errorf("Failed to audit the %v page: %v", page, err)
+		}
+		// The tree is kept so the rules can be rerun on a host without a DUT.
+		if err := a11yaudit.WriteTree(filepath.Join(s.OutDir(), "a11y_tree_"+page+".json"), tree); err != nil {
+			s.Errorf("Failed to write the a11y tree of %v: %v", page, err)
+		}
+		for _, v := range violations {
+			s.Logf("[%v] %v on %v: %v", v.Severity, v.Rule, page, v.Message)
+			if v.Severity == a11yaudit.Critical {
+				s.Errorf("Critical a11y violation on %v: %v", page, v.Message)
+			}
+		}
+		report = append(report, auditPage{Page: page, Violations: violations})
+	}
+	audit("Dashboard")
+	for _, step := range layoutWalk {
+		class, nth, err := common.GetJSONDashboard(step.click, dashboardPath)
+		if err != nil {
+			s.Fatalf("Can not get the json data for %v: %v", step.click, err)
+		}
+		if _, err := common.ClickDashboardBtnsNTH(ctx, s, bt, ui, step.click, class, nth); err != nil {
+			s.Fatalf("Failed to click %v button : %v", step.click, err)
+		}
+		if step.page != "" {
+			audit(step.page)
+		}
+	}
+
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		s.Fatal("Failed to marshal the a11y report: ", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.OutDir(), "a11y_audit.json"), b, 0644); err != nil {
+		s.Error("Failed to write the a11y report: ", err)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/ash"
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/browser/browserfixt"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/faillog"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+
+	"go.chromium.org/tast/core/ctxutil"
+	"go.chromium.org/tast/core/testing"
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa14chromevox,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Checks ChromeVox announces the dashboard and diagnostic buttons",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json", "chromevox_phrases.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      15 * time.Minute,
+		Params: []testing.Param{{
+			Name: "en_us",
+			Val:  "en-US",
+		}, {
+			Name: "de_de",
+			Val:  "de-DE",
+		}, {
+			Name: "ja_jp",
+			Val:  "ja-JP",
+		}},
+	})
+}
+
+// chromevoxDiagnostics are the diagnostic buttons announced on the dashboard, each with the button to leave it.
+var chromevoxDiagnostics = []struct {
+	open string
+	back string
+}{
+	{common.BatteryCheck, common.BatteryCheckBack},
+	{common.CheckCPU, common.CheckCPUBack},
+	{common.CheckSystemMemory, common.CheckSystemMemoryBack},
+	{common.CheckConnectivity, common.CheckConnectivityBack},
+	{common.ComponentTest, common.ComponentTestBack},
+	{common.CheckStorage, common.CheckStorageBack},
+}
+
+func Hpsa14chromevox(ctx context.Context, s *testing.State) {
+	locale := s.Param().(string)
+	lang := "--lang=" + locale
+	//Need copy the file to the path
+	extDir := filepath.Dir(common.ExtensionDir)
+	extID, err := chrome.ComputeExtensionID(extDir)
+	if err != nil {
+		s.Fatalf("Failed to compute extension ID for %v: %v", extDir, err)
+	}
+	s.Log("Extension ID is ", extID)
+	//Create the chrome with the extra arguments
+	cr, err := chrome.New(ctx, chrome.UnpackedExtension(extDir),
+		chrome.ExtraArgs(common.Proxy),
+		chrome.ExtraArgs(lang),
+	)
+	if err != nil {
+		s.Fatal("Chrome login failed: ", err)
+	}
+	defer cr.Close(ctx)
+
+	bt := browser.TypeAsh
+	// Reserve ten seconds for cleanup.
+	cleanupCtx := ctx
+	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
+	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
+	br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
+	if err != nil {
+		s.Fatal("Failed to set up browser: ", err)
+	}
+	defer closeBrowser(cleanupCtx)
+	tconn, err := cr.TestAPIConn(ctx)
+	if err != nil {
+		s.Fatal("Failed to create Test API connection: ", err)
+	}
+	const tabletMode = false
+	cleanup, err := ash.EnsureTabletModeEnabled(ctx, tconn, tabletMode)
+	if err != nil {
+		s.Fatalf("Failed to ensure the tablet mode is set to %v: %v", tabletMode, err)
+	}
+	defer cleanup(cleanupCtx)
+	ui := uiauto.New(tconn)
+	if err := common.SetUpBrowser(ctx, ui, br, s, lang); err != nil {
+		s.Fatal("Failed to set the HPSA language: ", err)
+	}
+	_, err = common.ManualInstallHPSA(ctx, tconn, cr, bt, common.AppURLITG)
+	if err != nil {
+		s.Fatal("Failed to manually install HPSA: ", err)
+	}
+	defer faillog.DumpUITreeOnError(cleanupCtx, s.OutDir(), s.HasError, tconn)
+	var path = s.DataPath("hpsa.json")
+	var dashboardPath = s.DataPath("dashboard.json")
+	phrases, err := common.GetSpeechPhrasesJSON(locale, s.DataPath("chromevox_phrases.json"))
+	if err != nil {
+		s.Fatal("Failed to read the expected phrases: ", err)
+	}
+	if phrases == nil {
+		s.Logf("No expected phrases for %v, only checking ChromeVox speaks the accessible names", locale)
+	}
+
+	sf, err := common.StartSpokenFeedback(ctx, cr, tconn, locale)
+	if err != nil {
+		s.Fatal("Failed to start ChromeVox: ", err)
+	}
+	defer sf.Close(cleanupCtx)
+	kn, err := common.NewKeyboardNavigator(ctx, ui)
+	if err != nil {
+		s.Fatal("Failed to set up the keyboard: ", err)
+	}
+	defer kn.Close(cleanupCtx)
+
+	// checkSpeech checks the accessible name of the focused element and waits for ChromeVox to say the expected phrase.
+	checkSpeech := func(element string, target *nodewith.Finder) {
+		info, err := ui.Info(ctx, target)
+		if err != nil {
+			s.Fatalf("Failed to get the node info of %v: %v", element, err)
+		}
+		for _, problem := range common.CheckAccessibleName(info) {
+			s.Errorf("%v in %v: %v", element, locale, problem)
+		}
+		phrase := phrases[element]
+		if phrase == nil && info.Name == "" {
+			return
+		}
+		if err := sf.ExpectSpeech(ctx, info, phrase); err != nil {
+			want := fmt.Sprintf("%q", info.Name)
+			if phrase != nil {
+				want = phrase.String()
+			}
+			s.Errorf("ChromeVox did not say %v for %v in %v: %v", want, element, locale, err)
+		}
+	}
+
+	// announce focuses the element, checks what ChromeVox says for it and activates it.
+	announce := func(element, elementClass string, nth int) {
+		target := nodewith.HasClass(elementClass).Nth(nth)
+		if err := kn.FocusOn(ctx, target); err != nil {
+			s.Fatalf("Failed to focus %v: %v", element, err)
+		}
+		checkSpeech(element, target)
+		if err := kn.Activate(ctx, target); err != nil {
+			s.Fatalf("Failed to activate %v: %v", element, err)
+		}
+	}
+
+	//Welcome flow
+	for _, element := range append(common.KeyboardWelcomeBeforeConsent, common.KeyboardWelcomeConsent...) {
+		if element == common.SelectRegionUS {
+			if _, err := common.KeyboardWelcomeSteps(ctx, s, bt, kn, path, []string{element}); err != nil {
+				s.Fatal("Failed to select the region: ", err)
+			}
+			continue
+		}
+		class, nth, err := common.GetJSON(element, path)
+		if err != nil {
+			s.Fatalf("Failed to get the json data for %v: %v", element, err)
+		}
+		announce(element, class, nth)
+	}
+
+	//Diagnostic buttons
+	runClass, runNTH, err := common.GetJSONDashboard(common.RunBatteryCheck, dashboardPath)
+	if err != nil {
+		s.Fatalf("Failed to get the json data for %v: %v", common.RunBatteryCheck, err)
+	}
+	for _, diagnostic := range chromevoxDiagnostics {
+		openClass, openNTH, err := common.GetJSONDashboard(diagnostic.open, dashboardPath)
+		if err != nil {
+			s.Fatalf("Failed to get the json data for %v: %v", diagnostic.open, err)
+		}
+		announce(diagnostic.open, openClass, openNTH)
+		if diagnostic.open != common.ComponentTest {
+			// The run button is only checked for focus and speech, the diagnostic is not started.
+			run := nodewith.HasClass(runClass).Nth(runNTH)
+			if err := kn.FocusOn(ctx, run); err != nil {
+				s.Fatalf("Failed to focus the run button of %v: %v", diagnostic.open, err)
+			}
+			checkSpeech(common.RunBatteryCheck, run)
+		}
+		backClass, backNTH, err := common.GetJSONDashboard(diagnostic.back, dashboardPath)
+		if err != nil {
+			s.Fatalf("Failed to get the json data for %v: %v", diagnostic.back, err)
+		}
+		announce(diagnostic.back, backClass, backNTH)
+	}
+}
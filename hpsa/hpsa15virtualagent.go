@@ -0,0 +1,132 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/bundles/cros/hpsa/va"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/ash"
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/browser/browserfixt"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/faillog"
+
+	"go.chromium.org/tast/core/ctxutil"
+	"go.chromium.org/tast/core/testing"
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa15virtualagent,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Runs the scripted virtual agent conversations and checks the replies",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json", "va_conversations.yaml"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      20 * time.Minute,
+	})
+}
+
+func Hpsa15virtualagent(ctx context.Context, s *testing.State) {
+	script, err := va.ReadScript(s.DataPath("va_conversations.yaml"))
+	if err != nil {
+		s.Fatal("Failed to read the conversations: ", err)
+	}
+	//Need copy the file to the path
+	extDir := filepath.Dir(common.ExtensionDir)
+	extID, err := chrome.ComputeExtensionID(extDir)
+	if err != nil {
+		s.Fatalf("Failed to compute extension ID for %v: %v", extDir, err)
+	}
+	s.Log("Extension ID is ", extID)
+	//Create the chrome with the extra arguments
+	cr, err := chrome.New(ctx, chrome.UnpackedExtension(extDir),
+		chrome.ExtraArgs(common.Proxy),
+		chrome.ExtraArgs(common.Language),
+	)
+	if err != nil {
+		s.Fatal("Chrome login failed: ", err)
+	}
+	defer cr.Close(ctx)
+
+	bt := browser.TypeAsh
+	// Reserve ten seconds for cleanup.
+	cleanupCtx := ctx
+	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
+	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
+	br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
+	if err != nil {
+		s.Fatal("Failed to set up browser: ", err)
+	}
+	defer closeBrowser(cleanupCtx)
+	tconn, err := cr.TestAPIConn(ctx)
+	if err != nil {
+		s.Fatal("Failed to create Test API connection: ", err)
+	}
+	ui := uiauto.New(tconn)
+	common.SetUpBrowser(ctx, ui, br, s, common.Language)
+	const tabletMode = false
+	cleanup, err := ash.EnsureTabletModeEnabled(ctx, tconn, tabletMode)
+	if err != nil {
+		s.Fatalf("Failed to ensure the tablet mode is set to %v: %v", tabletMode, err)
+	}
+	defer cleanup(cleanupCtx)
+	_, err = common.ManualInstallHPSA(ctx, tconn, cr, bt, common.AppURLITG)
+	if err != nil {
+		s.Fatal("Failed to manually install HPSA: ", err)
+	}
+	defer faillog.DumpUITreeOnError(cleanupCtx, s.OutDir(), s.HasError, tconn)
+	var path = s.DataPath("hpsa.json")
+	var dashboardPath = s.DataPath("dashboard.json")
+	common.CloseLastBrowser(ctx, "BrowserFrame", s, bt, ui)
+	// Do pretest after oobe
+	common.PreTestWithNoOPT(ctx, s, bt, ui, path)
+
+	agent, err := va.New(ctx, ui, dashboardPath)
+	if err != nil {
+		s.Fatal("Failed to create the Virtual Agent driver: ", err)
+	}
+	defer agent.Release(cleanupCtx)
+	transcripts := make(map[string][]va.Turn)
+	for _, conversation := range script.Conversations {
+		s.Log("Running conversation ", conversation.Name)
+		if err := agent.Open(ctx); err != nil {
+			s.Fatal("Failed to open the Virtual Agent: ", err)
+		}
+		if err := agent.Run(ctx, conversation); err != nil {
+			s.Error("Conversation failed: ", err)
+			common.TakeScreenshot(ctx, s, "Hpsa15virtualagent_"+conversation.Name+"_failed.png", common.ScreenshotPath)
+		}
+		if turns, err := agent.Transcript(ctx); err != nil {
+			s.Errorf("Failed to read the transcript of %v: %v", conversation.Name, err)
+		} else {
+			transcripts[conversation.Name] = turns
+		}
+		if err := agent.Close(ctx); err != nil {
+			s.Fatal("Failed to close the Virtual Agent: ", err)
+		}
+	}
+	b, err := json.MarshalIndent(transcripts, "", "  ")
+	if err != nil {
+		s.Fatal("Failed to marshal the transcripts: ", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.OutDir(), "va_transcripts.json"), b, 0644); err != nil {
+		s.Error("Failed to write the transcripts: ", err)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/ash"
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/browser/browserfixt"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/faillog"
+
+	"go.chromium.org/tast/core/ctxutil"
+	"go.chromium.org/tast/core/testing"
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa16feedback,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Submits feedback and checks the request HPSA sends",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      15 * time.Minute,
+	})
+}
+
+const (
+	// feedbackRating is the number of stars submitted
+	feedbackRating = 4
+	// feedbackComment is the comment submitted, it is tagged in case a stub is missed and it reaches the backend
+	feedbackComment = "Tast automated feedback, please ignore"
+)
+
+func Hpsa16feedback(ctx context.Context, s *testing.State) {
+	//Need copy the file to the path
+	extDir := filepath.Dir(common.ExtensionDir)
+	extID, err := chrome.ComputeExtensionID(extDir)
+	if err != nil {
+		s.Fatalf("Failed to compute extension ID for %v: %v", extDir, err)
+	}
+	s.Log("Extension ID is ", extID)
+	//Create the chrome with the extra arguments
+	cr, err := chrome.New(ctx, chrome.UnpackedExtension(extDir),
+		chrome.ExtraArgs(common.Proxy),
+		chrome.ExtraArgs(common.Language),
+	)
+	if err != nil {
+		s.Fatal("Chrome login failed: ", err)
+	}
+	defer cr.Close(ctx)
+
+	bt := browser.TypeAsh
+	// Reserve ten seconds for cleanup.
+	cleanupCtx := ctx
+	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
+	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
+	br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
+	if err != nil {
+		s.Fatal("Failed to set up browser: ", err)
+	}
+	defer closeBrowser(cleanupCtx)
+	tconn, err := cr.TestAPIConn(ctx)
+	if err != nil {
+		s.Fatal("Failed to create Test API connection: ", err)
+	}
+	ui := uiauto.New(tconn)
+	common.SetUpBrowser(ctx, ui, br, s, common.Language)
+	const tabletMode = false
+	cleanup, err := ash.EnsureTabletModeEnabled(ctx, tconn, tabletMode)
+	if err != nil {
+		s.Fatalf("Failed to ensure the tablet mode is set to %v: %v", tabletMode, err)
+	}
+	defer cleanup(cleanupCtx)
+	_, err = common.ManualInstallHPSA(ctx, tconn, cr, bt, common.AppURLITG)
+	if err != nil {
+		s.Fatal("Failed to manually install HPSA: ", err)
+	}
+	defer faillog.DumpUITreeOnError(cleanupCtx, s.OutDir(), s.HasError, tconn)
+	var path = s.DataPath("hpsa.json")
+	var dashboardPath = s.DataPath("dashboard.json")
+	common.CloseLastBrowser(ctx, "BrowserFrame", s, bt, ui)
+	// Do pretest after oobe
+	common.PreTestWithNoOPT(ctx, s, bt, ui, path)
+
+	recorder, err := common.StartNetworkRecorder(ctx, cr)
+	if err != nil {
+		s.Fatal("Failed to start recording the HPSA requests: ", err)
+	}
+	defer recorder.Close()
+	// The feedback never reaches HP, the stub answers it like the backend does.
+	stub := common.FeedbackEndpoint
+	stub.Status = 200
+	stub.Body = "{}"
+	if err := recorder.Stub(ctx, stub); err != nil {
+		s.Fatal("Failed to stub the feedback backend: ", err)
+	}
+
+	// A cancelled dialog must not send anything.
+	dialog, err := common.OpenFeedbackDialog(ctx, cr, ui, dashboardPath)
+	if err != nil {
+		s.Fatal("Failed to open the feedback dialog: ", err)
+	}
+	defer dialog.Release(cleanupCtx)
+	if err := dialog.SetRating(ctx, 2); err != nil {
+		s.Fatal("Failed to set the rating: ", err)
+	}
+	if err := dialog.Cancel(ctx); err != nil {
+		s.Fatal("Failed to cancel the feedback: ", err)
+	}
+	if req, err := recorder.WaitForRequest(ctx, common.FeedbackEndpoint, 5*time.Second); err == nil {
+		s.Errorf("Cancelled feedback was sent to %v", req.URL)
+	}
+
+	dialog, err = common.OpenFeedbackDialog(ctx, cr, ui, dashboardPath)
+	if err != nil {
+		s.Fatal("Failed to open the feedback dialog: ", err)
+	}
+	defer dialog.Release(cleanupCtx)
+	privacyURL, err := dialog.OpenPrivacyLink(ctx)
+	if err != nil {
+		s.Fatal("Failed to open the privacy statement: ", err)
+	}
+	if !strings.HasPrefix(privacyURL, "https://") {
+		s.Errorf("Privacy statement opened %q, want a https page", privacyURL)
+	}
+	if err := dialog.SetRating(ctx, feedbackRating); err != nil {
+		s.Fatal("Failed to set the rating: ", err)
+	}
+	if err := dialog.TypeComment(ctx, feedbackComment); err != nil {
+		s.Fatal("Failed to type the comment: ", err)
+	}
+	common.TakeScreenshot(ctx, s, "Hpsa16feedback_filled.png", common.ScreenshotPath)
+	if err := dialog.Submit(ctx); err != nil {
+		s.Fatal("Failed to submit the feedback: ", err)
+	}
+	req, err := recorder.WaitForRequest(ctx, common.FeedbackEndpoint, time.Minute)
+	if err != nil {
+		s.Fatal("Feedback was not sent: ", err)
+	}
+	if b, err := json.MarshalIndent(req, "", "  "); err == nil {
+		os.WriteFile(filepath.Join(s.OutDir(), "feedback_request.json"), b, 0644)
+	}
+	if !req.Stubbed {
+		s.Errorf("Feedback was sent to %v instead of the stub", req.URL)
+	}
+	if err := common.CheckFeedbackRequest(req, feedbackRating, feedbackComment); err != nil {
+		s.Error("Wrong feedback sent: ", err)
+	}
+}
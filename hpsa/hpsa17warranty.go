@@ -0,0 +1,143 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/ash"
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/browser/browserfixt"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/faillog"
+
+	"go.chromium.org/tast/core/ctxutil"
+	"go.chromium.org/tast/core/testing"
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa17warranty,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Checks the warranty page loads in each locale and shows its dates the way the locale writes them",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json"},
+		Attr:         []string{"group:hpsa", "hpsa_l10n"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      20 * time.Minute,
+		Params: []testing.Param{{
+			Name: "en_us",
+			Val:  "en-US",
+		}, {
+			Name: "de_de",
+			Val:  "de-DE",
+		}, {
+			Name: "ja_jp",
+			Val:  "ja-JP",
+		}},
+	})
+}
+
+func Hpsa17warranty(ctx context.Context, s *testing.State) {
+	locale := s.Param().(string)
+	lang := "--lang=" + locale
+	//Need copy the file to the path
+	extDir := filepath.Dir(common.ExtensionDir)
+	extID, err := chrome.ComputeExtensionID(extDir)
+	if err != nil {
+		s.Fatalf("Failed to compute extension ID for %v: %v", extDir, err)
+	}
+	s.Log("Extension ID is ", extID)
+	//Create the chrome with the extra arguments
+	cr, err := chrome.New(ctx, chrome.UnpackedExtension(extDir),
+		chrome.ExtraArgs(common.Proxy),
+		chrome.ExtraArgs(lang),
+	)
+	if err != nil {
+		s.Fatal("Chrome login failed: ", err)
+	}
+	defer cr.Close(ctx)
+
+	bt := browser.TypeAsh
+	// Reserve ten seconds for cleanup.
+	cleanupCtx := ctx
+	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
+	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
+	br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
+	if err != nil {
+		s.Fatal("Failed to set up browser: ", err)
+	}
+	defer closeBrowser(cleanupCtx)
+	tconn, err := cr.TestAPIConn(ctx)
+	if err != nil {
+		s.Fatal("Failed to create Test API connection: ", err)
+	}
+	ui := uiauto.New(tconn)
+	common.SetUpBrowser(ctx, ui, br, s, lang)
+	const tabletMode = false
+	cleanup, err := ash.EnsureTabletModeEnabled(ctx, tconn, tabletMode)
+	if err != nil {
+		s.Fatalf("Failed to ensure the tablet mode is set to %v: %v", tabletMode, err)
+	}
+	defer cleanup(cleanupCtx)
+	_, err = common.ManualInstallHPSA(ctx, tconn, cr, bt, common.AppURLITG)
+	if err != nil {
+		s.Fatal("Failed to manually install HPSA: ", err)
+	}
+	defer faillog.DumpUITreeOnError(cleanupCtx, s.OutDir(), s.HasError, tconn)
+	var path = s.DataPath("hpsa.json")
+	var dashboardPath = s.DataPath("dashboard.json")
+	common.CloseLastBrowser(ctx, "BrowserFrame", s, bt, ui)
+	// Do pretest after oobe
+	common.PreTestWithNoOPT(ctx, s, bt, ui, path)
+
+	recorder, err := common.StartNetworkRecorder(ctx, cr)
+	if err != nil {
+		s.Fatal("Failed to start recording the HPSA requests: ", err)
+	}
+	defer recorder.Close()
+	// The page looks the warranty up while it starts, the reload records the lookup from the first request.
+	if err := recorder.Reload(ctx); err != nil {
+		s.Fatal("Failed to reload HPSA: ", err)
+	}
+	if err := common.OpenWarrantyDetails(ctx, ui, dashboardPath); err != nil {
+		s.Fatal("Failed to open the warranty page: ", err)
+	}
+	page, err := common.ReadWarrantyPage(ctx, ui, dashboardPath)
+	common.TakeScreenshot(ctx, s, fmt.Sprintf("Hpsa17warranty_%v.png", locale), common.ScreenshotPath)
+	if err != nil {
+		s.Fatal("Failed to read the warranty page: ", err)
+	}
+	if len(page.Texts) == 0 {
+		s.Error("The warranty page shows no text")
+	}
+	for _, problem := range common.CheckWarrantyDates(page.Texts, locale) {
+		s.Error("Warranty page: ", problem)
+	}
+	// The requests are kept so the lookup and its answer can be looked at for each locale.
+	if page.Requests, err = recorder.Requests(ctx); err != nil {
+		s.Error("Failed to read the HPSA requests: ", err)
+	}
+	b, err := json.MarshalIndent(page, "", "  ")
+	if err != nil {
+		s.Fatal("Failed to marshal the warranty page: ", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.OutDir(), "warranty_"+locale+".json"), b, 0644); err != nil {
+		s.Error("Failed to write the warranty page: ", err)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/ash"
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/browser/browserfixt"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/faillog"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+
+	"go.chromium.org/tast/core/ctxutil"
+	"go.chromium.org/tast/core/testing"
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa18specifications,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Checks every specification section is shown and filled",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      15 * time.Minute,
+	})
+}
+
+// specSections are the sections every HP Chromebook shows
+var specSections = []string{common.Network, common.Audio, common.Battery, common.Video, common.Processor, common.Memory, common.Storage}
+
+func Hpsa18specifications(ctx context.Context, s *testing.State) {
+	//Need copy the file to the path
+	extDir := filepath.Dir(common.ExtensionDir)
+	extID, err := chrome.ComputeExtensionID(extDir)
+	if err != nil {
+		s.Fatalf("Failed to compute extension ID for %v: %v", extDir, err)
+	}
+	s.Log("Extension ID is ", extID)
+	//Create the chrome with the extra arguments
+	cr, err := chrome.New(ctx, chrome.UnpackedExtension(extDir),
+		chrome.ExtraArgs(common.Proxy),
+		chrome.ExtraArgs(common.Language),
+	)
+	if err != nil {
+		s.Fatal("Chrome login failed: ", err)
+	}
+	defer cr.Close(ctx)
+
+	bt := browser.TypeAsh
+	// Reserve ten seconds for cleanup.
+	cleanupCtx := ctx
+	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
+	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
+	br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
+	if err != nil {
+		s.Fatal("Failed to set up browser: ", err)
+	}
+	defer closeBrowser(cleanupCtx)
+	tconn, err := cr.TestAPIConn(ctx)
+	if err != nil {
+		s.Fatal("Failed to create Test API connection: ", err)
+	}
+	ui := uiauto.New(tconn)
+	common.SetUpBrowser(ctx, ui, br, s, common.Language)
+	const tabletMode = false
+	cleanup, err := ash.EnsureTabletModeEnabled(ctx, tconn, tabletMode)
+	if err != nil {
+		s.Fatalf("Failed to ensure the tablet mode is set to %v: %v", tabletMode, err)
+	}
+	defer cleanup(cleanupCtx)
+	_, err = common.ManualInstallHPSA(ctx, tconn, cr, bt, common.AppURLITG)
+	if err != nil {
+		s.Fatal("Failed to manually install HPSA: ", err)
+	}
+	defer faillog.DumpUITreeOnError(cleanupCtx, s.OutDir(), s.HasError, tconn)
+	var path = s.DataPath("hpsa.json")
+	common.CloseLastBrowser(ctx, "BrowserFrame", s, bt, ui)
+	// Do pretest after oobe
+	common.PreTestWithNoOPT(ctx, s, bt, ui, path)
+
+	var specificationsClass, _, _ = common.GetJSON(common.Specifications, path)
+	if _, err := common.ClickDashboardBtns(ctx, s, bt, ui, common.Specifications, specificationsClass); err != nil {
+		s.Fatalf("Failed to click %v button : %v ", common.Specifications, err)
+	}
+	scroller, err := common.NewScroller(ctx, tconn, ui, common.ScrollByWheel)
+	if err != nil {
+		s.Fatal("Failed to create the scroller: ", err)
+	}
+	defer scroller.Close(cleanupCtx)
+	reader, err := common.NewSpecificationsReader(ui, scroller, path)
+	if err != nil {
+		s.Fatal("Failed to create the specifications reader: ", err)
+	}
+	specs, err := reader.Read(ctx)
+	if err != nil {
+		s.Fatal("Failed to read the specifications: ", err)
+	}
+	s.Log("Specification sections: ", reader.Order)
+	b, err := json.MarshalIndent(specs, "", "  ")
+	if err != nil {
+		s.Fatal("Failed to marshal the specifications: ", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.OutDir(), "specifications.json"), b, 0644); err != nil {
+		s.Error("Failed to write the specifications: ", err)
+	}
+	for _, name := range specSections {
+		section, ok := specs[name]
+		if !ok {
+			s.Errorf("Section %v is not shown", name)
+			continue
+		}
+		if len(section.Entries) == 0 {
+			s.Errorf("Section %v has no entries", name)
+		}
+		for _, e := range section.Entries {
+			if e.Key == "" || e.Value == "" {
+				s.Errorf("Section %v has an incomplete entry %+v", name, e)
+			}
+		}
+	}
+	var specificationsCloseClass, _, _ = common.GetJSON(common.SpecificationsClose, path)
+	if err := ui.LeftClick(nodewith.HasClass(specificationsCloseClass).First())(ctx); err != nil {
+		s.Fatalf("Failed to click %v button : %v ", common.SpecificationsClose, err)
+	}
+}
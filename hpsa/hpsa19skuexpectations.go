@@ -0,0 +1,175 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"path/filepath"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/bundles/cros/hpsa/sku"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/ash"
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/browser/browserfixt"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/faillog"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+
+	"go.chromium.org/tast/core/ctxutil"
+	"go.chromium.org/tast/core/testing"
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa19skuexpectations,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Checks the specifications and diagnostics against the expectations of the SKU",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json", "sku_expectations.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      15 * time.Minute,
+	})
+}
+
+// diagnosticButtonTimeout is how long a diagnostic button has to show up, or to go away when the model does not offer it
+const diagnosticButtonTimeout = 30 * time.Second
+
+func Hpsa19skuexpectations(ctx context.Context, s *testing.State) {
+	db, err := sku.Read(s.DataPath("sku_expectations.json"))
+	if err != nil {
+		s.Fatal("Failed to read the SKU expectations: ", err)
+	}
+	// A field missing from VPD is looked up as empty, so the DUT is reported as an unknown SKU.
+	skuNumber, err := common.ReadFromVpd("sku_number")
+	if err != nil {
+		s.Log("No sku_number in VPD: ", err)
+		skuNumber = ""
+	}
+	modelName, err := common.ReadFromVpd("model_name")
+	if err != nil {
+		s.Log("No model_name in VPD: ", err)
+		modelName = ""
+	}
+	model, err := db.Lookup(skuNumber, modelName)
+	if err != nil {
+		// Unknown models fail so a new model is never tested without expectations.
+		s.Fatal("Unknown DUT: ", err)
+	}
+	s.Logf("Checking the dashboard against %v", model.ModelName)
+	//Need copy the file to the path
+	extDir := filepath.Dir(common.ExtensionDir)
+	extID, err := chrome.ComputeExtensionID(extDir)
+	if err != nil {
+		s.Fatalf("Failed to compute extension ID for %v: %v", extDir, err)
+	}
+	s.Log("Extension ID is ", extID)
+	//Create the chrome with the extra arguments
+	cr, err := chrome.New(ctx, chrome.UnpackedExtension(extDir),
+		chrome.ExtraArgs(common.Proxy),
+		chrome.ExtraArgs(common.Language),
+	)
+	if err != nil {
+		s.Fatal("Chrome login failed: ", err)
+	}
+	defer cr.Close(ctx)
+
+	bt := browser.TypeAsh
+	// Reserve ten seconds for cleanup.
+	cleanupCtx := ctx
+	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
+	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
+	br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
+	if err != nil {
+		s.Fatal("Failed to set up browser: ", err)
+	}
+	defer closeBrowser(cleanupCtx)
+	tconn, err := cr.TestAPIConn(ctx)
+	if err != nil {
+		s.Fatal("Failed to create Test API connection: ", err)
+	}
+	ui := uiauto.New(tconn)
+	common.SetUpBrowser(ctx, ui, br, s, common.Language)
+	const tabletMode = false
+	cleanup, err := ash.EnsureTabletModeEnabled(ctx, tconn, tabletMode)
+	if err != nil {
+		s.Fatalf("Failed to ensure the tablet mode is set to %v: %v", tabletMode, err)
+	}
+	defer cleanup(cleanupCtx)
+	_, err = common.ManualInstallHPSA(ctx, tconn, cr, bt, common.AppURLITG)
+	if err != nil {
+		s.Fatal("Failed to manually install HPSA: ", err)
+	}
+	defer faillog.DumpUITreeOnError(cleanupCtx, s.OutDir(), s.HasError, tconn)
+	var path = s.DataPath("hpsa.json")
+	common.CloseLastBrowser(ctx, "BrowserFrame", s, bt, ui)
+	// Do pretest after oobe
+	var dashboardPath = s.DataPath("dashboard.json")
+	common.PreTest(ctx, s, bt, ui, path)
+
+	// The buttons the model offers are waited for first, so the dashboard is complete
+	// when the missing ones are checked.
+	offered := make(map[string]bool)
+	for _, want := range []bool{true, false} {
+		for _, d := range sku.Diagnostics {
+			if expected, ok := model.Diagnostics[d]; !ok || expected != want {
+				continue
+			}
+			class, nth, err := common.GetJSONDashboard(d, dashboardPath)
+			if err != nil {
+				s.Fatalf("Can not get the json data for %v: %v", d, err)
+			}
+			button := nodewith.HasClass(class).Nth(nth)
+			if want {
+				offered[d] = ui.WithTimeout(diagnosticButtonTimeout).WaitUntilExists(button)(ctx) == nil
+			} else {
+				offered[d] = ui.WithTimeout(diagnosticButtonTimeout).WaitUntilGone(button)(ctx) != nil
+			}
+		}
+	}
+	for _, problem := range model.CheckDiagnostics(offered) {
+		s.Error("Diagnostics: ", problem)
+	}
+
+	var specificationsClass, _, _ = common.GetJSON(common.Specifications, path)
+	if _, err := common.ClickDashboardBtns(ctx, s, bt, ui, common.Specifications, specificationsClass); err != nil {
+		s.Fatalf("Failed to click %v button : %v ", common.Specifications, err)
+	}
+	scroller, err := common.NewScroller(ctx, tconn, ui, common.ScrollByWheel)
+	if err != nil {
+		s.Fatal("Failed to create the scroller: ", err)
+	}
+	defer scroller.Close(cleanupCtx)
+	reader, err := common.NewSpecificationsReader(ui, scroller, path)
+	if err != nil {
+		s.Fatal("Failed to create the specifications reader: ", err)
+	}
+	specs, err := reader.Read(ctx)
+	if err != nil {
+		s.Fatal("Failed to read the specifications: ", err)
+	}
+	shown := make(map[string]map[string]string)
+	for title, section := range specs {
+		shown[title] = make(map[string]string)
+		for _, e := range section.Entries {
+			shown[title][e.Key] = e.Value
+		}
+	}
+	for _, problem := range model.CheckSpecs(shown) {
+		s.Error("Specifications: ", problem)
+	}
+	var specificationsCloseClass, _, _ = common.GetJSON(common.SpecificationsClose, path)
+	if err := ui.LeftClick(nodewith.HasClass(specificationsCloseClass).First())(ctx); err != nil {
+		s.Fatalf("Failed to click %v button : %v ", common.SpecificationsClose, err)
+	}
+}
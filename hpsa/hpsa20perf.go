@@ -0,0 +1,143 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/bundles/cros/hpsa/metrics"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+
+	"go.chromium.org/tast/core/testing"
+)
+
+const (
+	// warmLaunches is the number of warm launches measured
+	warmLaunches = 3
+	// launchTimeout is how long a launch may take before the test fails
+	launchTimeout = time.Minute
+	// diagnosticTimeout is how long one diagnostic may run
+	diagnosticTimeout = 10 * time.Minute
+)
+
+// perfDiagnostics are the diagnostics whose run time is measured, the component test has no single run
+var perfDiagnostics = []string{common.BatteryCheck, common.CheckCPU, common.CheckSystemMemory, common.CheckConnectivity, common.CheckStorage}
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa20perf,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Measures launch, card render and diagnostic durations and compares them with the baseline where one is stored",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json", "perf_baseline.json"},
+		Attr:         []string{"group:crosbolt", "crosbolt_nightly"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      45 * time.Minute,
+	})
+}
+
+func Hpsa20perf(ctx context.Context, s *testing.State) {
+	baseline, err := metrics.ReadBaseline(s.DataPath("perf_baseline.json"))
+	if err != nil {
+		s.Fatal("Failed to read the perf baseline: ", err)
+	}
+	ctx, sess := common.SetUpSession(ctx, s, common.Language, common.DefaultCleanupTime)
+	defer sess.Close()
+	tconn, ui, bt, path, dashboardPath, appID := sess.Tconn, sess.UI, sess.BrowserType, sess.Path, sess.DashboardPath, sess.AppID
+
+	letsStart, err := common.Finder(common.Letsstart, path, "welcome")
+	if err != nil {
+		s.Fatal("Failed to get the welcome page locator: ", err)
+	}
+	cards := make(map[string]*nodewith.Finder)
+	for _, card := range metrics.DashboardCards {
+		if cards[card], err = common.Finder(card, dashboardPath, "dashboard"); err != nil {
+			s.Fatal("Failed to get the card locator: ", err)
+		}
+	}
+	firstCard := cards[common.DeviceName]
+	recorder := metrics.NewRecorder()
+
+	// The install opens HPSA, close it so the first launch is measured from the start.
+	if err := metrics.Close(ctx, tconn, ui, appID, letsStart); err != nil {
+		s.Fatal("Failed to close HPSA after the install: ", err)
+	}
+	sampler, err := metrics.NewRendererSampler(ctx, time.Second)
+	if err != nil {
+		s.Fatal("Failed to start the renderer sampler: ", err)
+	}
+	sampler.Start(ctx)
+	cold, err := metrics.Launch(ctx, tconn, ui, appID, letsStart, launchTimeout)
+	if err != nil {
+		sampler.Stop()
+		s.Fatal("Failed the cold launch: ", err)
+	}
+	recorder.SetDuration(metrics.LaunchCold, cold)
+
+	start := time.Now()
+	common.PreTest(ctx, s, bt, ui, path)
+	if err := ui.WithTimeout(launchTimeout).WaitUntilExists(firstCard)(ctx); err != nil {
+		sampler.Stop()
+		s.Fatal("Failed to reach the dashboard: ", err)
+	}
+	recorder.SetDuration(metrics.WelcomeToDashboard, time.Since(start))
+
+	for i := 0; i < warmLaunches; i++ {
+		if err := metrics.Close(ctx, tconn, ui, appID, firstCard); err != nil {
+			sampler.Stop()
+			s.Fatal("Failed to close HPSA: ", err)
+		}
+		warm, rendered, err := metrics.LaunchToCards(ctx, tconn, ui, appID, cards, launchTimeout)
+		if err != nil {
+			sampler.Stop()
+			s.Fatalf("Failed warm launch %d: %v", i+1, err)
+		}
+		recorder.AppendDuration(metrics.LaunchWarm, warm)
+		for card, d := range rendered {
+			recorder.AppendDuration(metrics.CardFirstRender(card), d)
+		}
+	}
+
+	for _, d := range perfDiagnostics {
+		elapsed, err := common.RunDiagnostic(ctx, ui, d, dashboardPath, diagnosticTimeout)
+		if err != nil {
+			s.Errorf("Failed to run %v: %v", d, err)
+			continue
+		}
+		recorder.SetDuration(metrics.DiagnosticDuration(d), elapsed)
+	}
+
+	usage := sampler.Stop()
+	s.Logf("HPSA renderer usage: %+v", usage)
+	if usage.Samples == 0 {
+		s.Error("No HPSA renderer was sampled")
+	} else {
+		usage.Record(recorder)
+	}
+
+	if err := recorder.Save(s.OutDir()); err != nil {
+		s.Error("Failed to save the perf values: ", err)
+	}
+	regressions := baseline.Compare(recorder)
+	if err := metrics.WriteRegressions(s.OutDir(), regressions); err != nil {
+		s.Error("Failed to write the regressions: ", err)
+	}
+	for _, r := range regressions {
+		s.Error("Perf regression: ", r)
+	}
+	// No baseline is stored before real runs on the lab boards, until then the metrics are recorded only.
+	if names := baseline.Unbaselined(recorder); len(names) > 0 {
+		s.Log("No baseline, recorded only: ", names)
+	}
+	if err := metrics.WriteCandidate(s.OutDir(), baseline.Candidate(recorder)); err != nil {
+		s.Error("Failed to write the baseline candidate: ", err)
+	}
+}
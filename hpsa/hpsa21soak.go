@@ -0,0 +1,201 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/bundles/cros/hpsa/metrics"
+	"chromiumos/tast/local/chrome/uiauto"
+
+	"go.chromium.org/tast/core/testing"
+)
+
+const (
+	// soakCycles is the number of cycles when hpsa.soakCycles is not set
+	soakCycles = 20
+	// soakHeapSnapshotStart and soakHeapSnapshotEnd are the heap snapshots written to OutDir
+	soakHeapSnapshotStart = "heap_start.heapsnapshot"
+	soakHeapSnapshotEnd   = "heap_end.heapsnapshot"
+)
+
+// soakLimits are the growths per cycle which fail the soak
+var soakLimits = metrics.SoakLimits{JSHeapMB: 0.5, RSSMB: 2, Nodes: 50, WarmUp: 2}
+
+// soakPages are the dashboard pages opened and closed in every cycle, the diagnostics follow them
+var soakPages = [][2]string{
+	{common.Specifications, common.SpecificationsClose},
+	{common.WarrantyCard, common.WarrantyBack},
+}
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa21soak,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Cycles through the diagnostics for hours and checks memory and DOM counters do not grow",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json"},
+		Vars:         []string{"hpsa.soakCycles"},
+		Attr:         []string{"group:stress"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      3 * time.Hour,
+	})
+}
+
+// Hpsa21soak cycles through the dashboard pages and diagnostics and fails when the memory of HPSA keeps growing.
+// Set the number of cycles with -var=hpsa.soakCycles=N.
+func Hpsa21soak(ctx context.Context, s *testing.State) {
+	cycles := soakCycles
+	if v, ok := s.Var("hpsa.soakCycles"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= soakLimits.WarmUp+1 {
+			s.Fatalf("hpsa.soakCycles must be a number above %d, got %q", soakLimits.WarmUp+1, v)
+		}
+		cycles = n
+	}
+	ctx, sess := common.SetUpSession(ctx, s, common.Language, common.DefaultCleanupTime)
+	defer sess.Close()
+	tconn, ui, bt, path, dashboardPath, appID := sess.Tconn, sess.UI, sess.BrowserType, sess.Path, sess.DashboardPath, sess.AppID
+
+	letsStart, err := common.Finder(common.Letsstart, path, "welcome")
+	if err != nil {
+		s.Fatal("Failed to get the welcome page locator: ", err)
+	}
+	dashboard, err := common.Finder(common.DeviceName, dashboardPath, "dashboard")
+	if err != nil {
+		s.Fatal("Failed to get the dashboard locator: ", err)
+	}
+	// Relaunch HPSA so its renderers are told apart from the ones running before.
+	if err := metrics.Close(ctx, tconn, ui, appID, letsStart); err != nil {
+		s.Fatal("Failed to close HPSA after the install: ", err)
+	}
+	sampler, err := metrics.NewRendererSampler(ctx, time.Second)
+	if err != nil {
+		s.Fatal("Failed to list the renderers: ", err)
+	}
+	if _, err := metrics.Launch(ctx, tconn, ui, appID, letsStart, time.Minute); err != nil {
+		s.Fatal("Failed to launch HPSA: ", err)
+	}
+	common.PreTest(ctx, s, bt, ui, path)
+	if err := ui.WithTimeout(time.Minute).WaitUntilExists(dashboard)(ctx); err != nil {
+		s.Fatal("Failed to reach the dashboard: ", err)
+	}
+
+	devtools, err := common.NewDevTools(ctx, common.AppURLITG)
+	if err != nil {
+		s.Fatal("Failed to open DevTools on HPSA: ", err)
+	}
+	defer devtools.Close()
+	if err := devtools.TakeHeapSnapshot(ctx, filepath.Join(s.OutDir(), soakHeapSnapshotStart)); err != nil {
+		s.Error("Failed to take the start heap snapshot: ", err)
+	}
+
+	start := time.Now()
+	var samples []metrics.SoakSample
+	sample := func(cycle int) error {
+		used, _, err := devtools.HeapUsage(ctx)
+		if err != nil {
+			return err
+		}
+		documents, nodes, listeners, err := devtools.DOMCounters(ctx)
+		if err != nil {
+			return err
+		}
+		rss, err := sampler.RSS(ctx)
+		if err != nil {
+			return err
+		}
+		samples = append(samples, metrics.SoakSample{
+			Cycle:     cycle,
+			Elapsed:   time.Since(start),
+			JSHeapMB:  used / (1024 * 1024),
+			RSSMB:     rss,
+			Documents: documents,
+			Nodes:     nodes,
+			Listeners: listeners,
+		})
+		return nil
+	}
+	if err := sample(0); err != nil {
+		s.Fatal("Failed to sample the memory before the first cycle: ", err)
+	}
+	for cycle := 1; cycle <= cycles; cycle++ {
+		if err := soakCycle(ctx, ui, dashboardPath); err != nil {
+			s.Fatalf("Cycle %d failed: %v", cycle, err)
+		}
+		if err := ui.WithTimeout(time.Minute).WaitUntilExists(dashboard)(ctx); err != nil {
+			s.Fatalf("Cycle %d did not end on the dashboard: %v", cycle, err)
+		}
+		if err := sample(cycle); err != nil {
+			s.Fatalf("Failed to sample the memory after cycle %d: %v", cycle, err)
+		}
+		last := samples[len(samples)-1]
+		s.Logf("Cycle %d: JS heap %.1f MB, renderer %.1f MB, %d nodes", cycle, last.JSHeapMB, last.RSSMB, last.Nodes)
+	}
+
+	if err := devtools.TakeHeapSnapshot(ctx, filepath.Join(s.OutDir(), soakHeapSnapshotEnd)); err != nil {
+		s.Error("Failed to take the end heap snapshot: ", err)
+	}
+	result, problems := metrics.AnalyzeSoak(samples, soakLimits)
+	if err := metrics.WriteSoak(s.OutDir(), samples, result); err != nil {
+		s.Error("Failed to write the soak samples: ", err)
+	}
+	recorder := metrics.NewRecorder()
+	result.Record(recorder, samples)
+	if err := recorder.Save(s.OutDir()); err != nil {
+		s.Error("Failed to save the perf values: ", err)
+	}
+	for _, problem := range problems {
+		s.Error("Memory leak: ", problem)
+	}
+}
+
+// soakCycle opens and closes every soak page and diagnostic once and runs the battery check
+func soakCycle(ctx context.Context, ui *uiauto.Context, dashboardPath string) error {
+	for _, page := range soakPages {
+		open, err := common.Finder(page[0], dashboardPath, "dashboard")
+		if err != nil {
+			return err
+		}
+		back, err := common.Finder(page[1], dashboardPath, "dashboard")
+		if err != nil {
+			return err
+		}
+		if err := uiauto.Combine(fmt.Sprintf("open and close %v", page[0]),
+			ui.WithTimeout(time.Minute).WaitUntilExists(open),
+			ui.LeftClick(open),
+			ui.WithTimeout(time.Minute).WaitUntilExists(back),
+			ui.LeftClick(back),
+			ui.WithTimeout(time.Minute).WaitUntilExists(open),
+		)(ctx); err != nil {
+			return err
+		}
+	}
+	for _, d := range perfDiagnostics {
+		if d == common.BatteryCheck {
+			// The battery check is the shortest run, it keeps the diagnostic code paths in every cycle.
+			if _, err := common.RunDiagnostic(ctx, ui, d, dashboardPath, diagnosticTimeout); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := common.OpenDiagnostic(ctx, ui, d, dashboardPath); err != nil {
+			return err
+		}
+		if err := common.CloseDiagnostic(ctx, ui, d, dashboardPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+	"go.chromium.org/tast/core/testing/hwdep"
+)
+
+// suspendCase is a diagnostic interrupted by a suspend
+type suspendCase struct {
+	Diagnostic string
+	// RunFor is how long the diagnostic runs before the DUT suspends.
+	RunFor time.Duration
+	// Timeout is how long the diagnostic may take to finish after the resume.
+	Timeout time.Duration
+	// Lid suspends by closing the lid instead of asking powerd directly.
+	Lid bool
+}
+
+// suspendOutcome is what HPSA did with the interrupted diagnostic
+type suspendOutcome struct {
+	Diagnostic string `json:"diagnostic"`
+	// Resumed is true when the diagnostic went on after the resume and showed its result.
+	Resumed bool `json:"resumed"`
+	// Cancelled is true when HPSA cancelled the run, Message is what it said.
+	Cancelled bool   `json:"cancelled"`
+	Message   string `json:"message,omitempty"`
+	// Settled is the time from the resume until the run button was enabled again.
+	Settled   time.Duration `json:"settledNs"`
+	Exception bool          `json:"exception"`
+	// Rerun tells the diagnostic could be started again after the resume.
+	Rerun bool `json:"rerun"`
+}
+
+const (
+	// sleepDuration is how long the DUT stays suspended
+	sleepDuration = 20 * time.Second
+	// exceptionWatch is how long an exception popup is looked for after the resume
+	exceptionWatch = 15 * time.Second
+	// messageWatch is how long the cancellation message may take to show up once the run ended
+	messageWatch = 15 * time.Second
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa22suspendresume,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Suspends the device during a diagnostic and checks the diagnostic recovers after resume",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json", "diagnostic_messages.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      30 * time.Minute,
+		Params: []testing.Param{{
+			Name: "battery",
+			Val:  suspendCase{Diagnostic: common.BatteryCheck, RunFor: 5 * time.Second, Timeout: 5 * time.Minute},
+		}, {
+			Name:         "battery_lid",
+			Val:          suspendCase{Diagnostic: common.BatteryCheck, RunFor: 5 * time.Second, Timeout: 5 * time.Minute, Lid: true},
+			HardwareDeps: hwdep.D(hwdep.FormFactor(hwdep.Clamshell, hwdep.Convertible)),
+		}, {
+			Name: "cpu",
+			Val:  suspendCase{Diagnostic: common.CheckCPU, RunFor: 30 * time.Second, Timeout: 10 * time.Minute},
+		}, {
+			Name: "memory",
+			Val:  suspendCase{Diagnostic: common.CheckSystemMemory, RunFor: 30 * time.Second, Timeout: 15 * time.Minute},
+		}},
+	})
+}
+
+// Hpsa22suspendresume suspends the DUT while a diagnostic runs.
+// After the resume HPSA has to finish or cancel the run cleanly, without an exception and with the run button enabled again.
+func Hpsa22suspendresume(ctx context.Context, s *testing.State) {
+	tc := s.Param().(suspendCase)
+	ctx, sess := common.SetUpSession(ctx, s, common.Language, common.DefaultCleanupTime)
+	defer sess.Close()
+	ui, bt, path, dashboardPath := sess.UI, sess.BrowserType, sess.Path, sess.DashboardPath
+	locale := common.LocaleFromLanguage(common.Language)
+	messages, err := common.GetDiagnosticMessagesJSON(locale, s.DataPath("diagnostic_messages.json"))
+	if err != nil {
+		s.Fatal("Failed to read the diagnostic messages: ", err)
+	}
+	// Do pretest after oobe
+	common.PreTest(ctx, s, bt, ui, path)
+
+	if err := common.OpenDiagnostic(ctx, ui, tc.Diagnostic, dashboardPath); err != nil {
+		s.Fatalf("Failed to open %v: %v", tc.Diagnostic, err)
+	}
+	if err := common.StartDiagnostic(ctx, ui, dashboardPath); err != nil {
+		s.Fatalf("Failed to start %v: %v", tc.Diagnostic, err)
+	}
+	// GoBigSleepLint Let the diagnostic get into its run before the suspend
+	if err := testing.Sleep(ctx, tc.RunFor); err != nil {
+		s.Fatal("Failed to sleep: ", err)
+	}
+	if running, err := common.DiagnosticRunning(ctx, ui, dashboardPath); err != nil || !running {
+		s.Fatalf("%v is not running before the suspend (err %v), make RunFor shorter", tc.Diagnostic, err)
+	}
+	if tc.Lid {
+		lid, err := common.NewLidSwitch(ctx)
+		if err != nil {
+			s.Fatal("Failed to create the lid switch: ", err)
+		}
+		defer lid.Close()
+		if err := lid.SuspendByLid(ctx, sleepDuration); err != nil {
+			s.Fatal("Failed to suspend by closing the lid: ", err)
+		}
+	} else if err := common.Suspend(ctx, sleepDuration); err != nil {
+		s.Fatal("Failed to suspend: ", err)
+	}
+	resumed := time.Now()
+	s.Log("Resumed from the suspend")
+
+	outcome := suspendOutcome{Diagnostic: tc.Diagnostic}
+	defer func() {
+		b, err := json.MarshalIndent(outcome, "", "  ")
+		if err == nil {
+			err = ioutil.WriteFile(filepath.Join(s.OutDir(), "suspend_outcome.json"), b, 0644)
+		}
+		if err != nil {
+			s.Error("Failed to write the suspend outcome: ", err)
+		}
+	}()
+	if err := common.WaitDiagnostic(ctx, ui, dashboardPath, tc.Timeout); err != nil {
+		common.TakeScreenshot(ctx, s, "hpsa22suspendresume_stuck.png", s.OutDir())
+		s.Fatalf("%v is stuck running %v after the resume: %v", common.RunBatteryCheckDisabled, tc.Timeout, err)
+	}
+	outcome.Settled = time.Since(resumed)
+
+	// A run which went on shows its result, a cancelled one has to say it was cancelled.
+	if outcome.Resumed, err = common.DiagnosticPassed(ctx, ui, tc.Diagnostic, dashboardPath); err != nil {
+		s.Fatal("Failed to look for the result after the resume: ", err)
+	}
+	if !outcome.Resumed {
+		if cancelled := messages[common.DiagnosticCancelled]; cancelled == nil {
+			s.Logf("No cancellation message for %v, not checking its text", locale)
+		} else if outcome.Message, err = common.WaitDiagnosticMessage(ctx, ui, tc.Diagnostic, dashboardPath, cancelled, messageWatch); err != nil {
+			common.TakeScreenshot(ctx, s, "hpsa22suspendresume_no_message.png", s.OutDir())
+			s.Errorf("%v showed neither a result nor a message matching %v after the resume: %v", tc.Diagnostic, cancelled, err)
+		} else {
+			outcome.Cancelled = true
+		}
+	}
+	s.Logf("%v settled %v after the resume, resumed: %v, cancelled with %q", tc.Diagnostic, outcome.Settled.Round(time.Second), outcome.Resumed, outcome.Message)
+
+	// The popup can show up a bit after the run ends, watch for it for a while.
+	if err := testing.Poll(ctx, func(ctx context.Context) error {
+		shown, err := common.ExceptionShown(ctx, ui, dashboardPath)
+		if err != nil {
+			return testing.PollBreak(err)
+		}
+		if !shown {
+			return errors.New("no exception popup")
+		}
+		return nil
+	}, &testing.PollOptions{Interval: time.Second, Timeout: exceptionWatch}); err == nil {
+		outcome.Exception = true
+		common.TakeScreenshot(ctx, s, "hpsa22suspendresume_exception.png", s.OutDir())
+		s.Error("HPSA showed an exception after the resume")
+	}
+
+	// A clean state lets the diagnostic start again.
+	if err := common.StartDiagnostic(ctx, ui, dashboardPath); err != nil {
+		s.Fatalf("Failed to start %v again after the resume: %v", tc.Diagnostic, err)
+	}
+	outcome.Rerun = true
+	if err := common.WaitDiagnostic(ctx, ui, dashboardPath, tc.Timeout); err != nil {
+		s.Fatalf("%v did not finish after the resume: %v", tc.Diagnostic, err)
+	}
+	if err := common.CloseDiagnostic(ctx, ui, tc.Diagnostic, dashboardPath); err != nil {
+		s.Error("Failed to go back to the dashboard: ", err)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/action"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+// networkCase is a network condition and how HPSA should handle it
+type networkCase struct {
+	Condition common.NetworkCondition
+	// BlockLookup and DropLookup make the condition block or drop the requests of the warranty lookup.
+	// HPSA does not say where it looks the warranty up, the requests are recorded on a normal load first.
+	BlockLookup bool
+	DropLookup  bool
+	// ExpectError tells HPSA has to report the failed lookup with its exception popup, otherwise it has to load the page slowly.
+	ExpectError bool
+	// LoadTimeout is how long the warranty page may take to load under the condition.
+	LoadTimeout time.Duration
+	// Retries is how often the warranty page may be opened again before it has to load.
+	Retries int
+}
+
+// networkState is what the warranty page shows
+type networkState string
+
+const (
+	networkLoading networkState = ""
+	networkLoaded  networkState = "loaded"
+	networkFailed  networkState = "error"
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa23network,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Checks the warranty page under offline, slow, blocked and flaky network conditions",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      20 * time.Minute,
+		Params: []testing.Param{{
+			Name: "offline",
+			Val:  networkCase{Condition: common.NetworkOffline, ExpectError: true, LoadTimeout: time.Minute},
+		}, {
+			Name: "2g",
+			Val:  networkCase{Condition: common.Network2G, LoadTimeout: 3 * time.Minute},
+		}, {
+			Name: "high_latency",
+			Val:  networkCase{Condition: common.NetworkHighLatency, LoadTimeout: 2 * time.Minute},
+		}, {
+			Name: "warranty_blocked",
+			Val:  networkCase{Condition: common.NetworkCondition{Name: "warranty-blocked"}, BlockLookup: true, ExpectError: true, LoadTimeout: time.Minute},
+		}, {
+			// Half of the lookups fail, HPSA has to get the page with a few retries.
+			Name: "flaky",
+			Val:  networkCase{Condition: common.NetworkFlaky(0.5), DropLookup: true, LoadTimeout: time.Minute, Retries: 8},
+		}},
+	})
+}
+
+// Hpsa23network opens the warranty page, which calls the backend, under an emulated network.
+// HPSA has to report a failed lookup with its exception popup or load slowly, and it has to recover once the network is back.
+func Hpsa23network(ctx context.Context, s *testing.State) {
+	tc := s.Param().(networkCase)
+	ctx, sess := common.SetUpSession(ctx, s, common.Language, common.DefaultCleanupTime)
+	defer sess.Close()
+	cr, ui, bt, cleanupCtx, path, dashboardPath := sess.Cr, sess.UI, sess.BrowserType, sess.CleanupCtx, sess.Path, sess.DashboardPath
+	// Do pretest after oobe
+	common.PreTest(ctx, s, bt, ui, path)
+
+	finders := make(map[string]*nodewith.Finder)
+	for _, name := range []string{common.AdditionalInformation, common.WarrantyBack} {
+		finder, err := common.Finder(name, dashboardPath, "dashboard")
+		if err != nil {
+			s.Fatal("Failed to get the locator: ", err)
+		}
+		finders[name] = finder
+	}
+	// state tells whether the warranty page loaded, failed or is still loading.
+	// HPSA reports a lookup it could not make with its exception popup, and shows the additional information link with the lookup result.
+	state := func(ctx context.Context) networkState {
+		if shown, err := common.ExceptionShown(ctx, ui, dashboardPath); err == nil && shown {
+			return networkFailed
+		}
+		if found, err := ui.IsNodeFound(ctx, finders[common.AdditionalInformation]); err == nil && found {
+			return networkLoaded
+		}
+		return networkLoading
+	}
+	waitState := func(ctx context.Context, timeout time.Duration) (networkState, error) {
+		var st networkState
+		err := testing.Poll(ctx, func(ctx context.Context) error {
+			if st = state(ctx); st == networkLoading {
+				return errors.New("the warranty page is still loading")
+			}
+			return nil
+		}, &testing.PollOptions{Interval: time.Second, Timeout: timeout})
+		return st, err
+	}
+	// leave goes back from the warranty page to the dashboard
+	leave := uiauto.Combine("go back to the dashboard",
+		ui.LeftClick(finders[common.WarrantyBack]),
+		ui.WithTimeout(time.Minute).WaitUntilGone(finders[common.WarrantyBack]),
+	)
+
+	// retry dismisses the popup and opens the warranty page again until it loads or the retries are used up
+	retry := func(ctx context.Context, retries int) (networkState, error) {
+		st := networkFailed
+		for i := 0; i < retries && st == networkFailed; i++ {
+			if err := common.DismissException(ctx, ui, dashboardPath); err != nil {
+				return st, err
+			}
+			if found, err := ui.IsNodeFound(ctx, finders[common.WarrantyBack]); err == nil && found {
+				if err := leave(ctx); err != nil {
+					return st, err
+				}
+			}
+			if err := common.OpenWarrantyDetails(ctx, ui, dashboardPath); err != nil {
+				return st, err
+			}
+			var err error
+			if st, err = waitState(ctx, tc.LoadTimeout); err != nil {
+				return st, err
+			}
+		}
+		return st, nil
+	}
+
+	condition := tc.Condition
+	if tc.BlockLookup || tc.DropLookup {
+		lookups, err := recordLookups(ctx, cr, ui, dashboardPath, leave)
+		if err != nil {
+			s.Fatal("Failed to record the warranty lookup: ", err)
+		}
+		s.Log("The warranty lookup requests ", lookups)
+		if tc.BlockLookup {
+			condition.Blocked = lookups
+		}
+		if tc.DropLookup {
+			condition.Drop = lookups
+		}
+	}
+
+	nc, err := common.NewNetworkController(ctx)
+	if err != nil {
+		s.Fatal("Failed to open the network controller: ", err)
+	}
+	defer nc.Close(cleanupCtx)
+	if err := nc.Apply(ctx, condition); err != nil {
+		s.Fatal("Failed to apply the network condition: ", err)
+	}
+	start := time.Now()
+	if err := common.OpenWarrantyDetails(ctx, ui, dashboardPath); err != nil {
+		s.Fatal("Failed to open the warranty page: ", err)
+	}
+	st, err := waitState(ctx, tc.LoadTimeout)
+	if err != nil {
+		common.TakeScreenshot(ctx, s, "hpsa23network_loading.png", s.OutDir())
+		s.Fatalf("The warranty page neither loaded nor failed in %v under %v: %v", tc.LoadTimeout, condition.Name, err)
+	}
+	s.Logf("The warranty page is %v after %v under %v", st, time.Since(start).Round(time.Millisecond), condition.Name)
+	common.TakeScreenshot(ctx, s, "hpsa23network_"+condition.Name+".png", s.OutDir())
+	switch {
+	case tc.ExpectError && st != networkFailed:
+		s.Errorf("The warranty page is %v under %v, want the exception popup", st, condition.Name)
+	case !tc.ExpectError && st == networkFailed && tc.Retries > 0:
+		if st, err = retry(ctx, tc.Retries); err != nil || st != networkLoaded {
+			s.Errorf("The warranty page is %v after %d retries under %v: %v", st, tc.Retries, condition.Name, err)
+		}
+	case !tc.ExpectError && st != networkLoaded:
+		s.Errorf("The warranty page is %v under %v, want it loaded", st, condition.Name)
+	}
+	if dropped, passed := nc.Dropped(); dropped+passed > 0 {
+		s.Logf("Dropped %d of %d warranty requests", dropped, dropped+passed)
+	}
+
+	// Bring the network back, HPSA has to load the page without a restart.
+	if err := nc.Reset(ctx); err != nil {
+		s.Fatal("Failed to reset the network: ", err)
+	}
+	if st == networkFailed {
+		if st, err = retry(ctx, 1); err != nil || st != networkLoaded {
+			common.TakeScreenshot(ctx, s, "hpsa23network_recovery.png", s.OutDir())
+			s.Fatalf("HPSA did not recover once the network was back, the warranty page is %v: %v", st, err)
+		}
+	}
+	if err := leave(ctx); err != nil {
+		s.Error("Failed to go back to the dashboard: ", err)
+	}
+}
+
+// recordLookups opens the warranty page on the normal network and returns the URL patterns of the requests HPSA sent for it
+func recordLookups(ctx context.Context, cr *chrome.Chrome, ui *uiauto.Context, dashboardPath string, leave action.Action) ([]string, error) {
+	recorder, err := common.StartNetworkRecorder(ctx, cr)
+	if err != nil {
+		return nil, err
+	}
+	defer recorder.Close()
+	if err := common.OpenWarrantyDetails(ctx, ui, dashboardPath); err != nil {
+		return nil, err
+	}
+	if err := common.WaitForWarrantyPage(ctx, ui, dashboardPath, time.Minute); err != nil {
+		return nil, err
+	}
+	requests, err := recorder.Requests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := leave(ctx); err != nil {
+		return nil, err
+	}
+	lookups := common.LookupPatterns(requests)
+	if len(lookups) == 0 {
+		return nil, errors.New("HPSA sent no request for the warranty page")
+	}
+	return lookups, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/bundles/cros/hpsa/netenv"
+	"chromiumos/tast/local/shill"
+
+	"go.chromium.org/tast/core/testing"
+)
+
+const (
+	// connectivityTimeout is how long the connectivity check may run
+	connectivityTimeout = 3 * time.Minute
+	// networkCleanupTimeout is the time kept to remove the virtual network and bring the page back online
+	networkCleanupTimeout = 30 * time.Second
+)
+
+// connectivityCase is the network the connectivity check runs on.
+// Disabling a technology may cut the lab off the DUT while the check runs, the test runs on the DUT
+// and switches the technology back on before it returns.
+type connectivityCase struct {
+	// Disable are the technologies switched off in shill.
+	Disable []shill.Technology
+	// VirtualEthernet adds a virtual Ethernet next to the real network, so the Ethernet row passes on a DUT without an Ethernet port.
+	VirtualEthernet bool
+	// Offline takes the HPSA page offline, the internet row has to fail while the Wi-Fi and Ethernet rows still follow shill.
+	Offline bool
+	// WantWifi and WantEthernet are what the rows have to show whatever the DUT, nil leaves them to shill.
+	WantWifi, WantEthernet *bool
+}
+
+var (
+	connected    = true
+	notConnected = false
+)
+
+// connectivityOutcome is written to OutDir for the report
+type connectivityOutcome struct {
+	State    netenv.State               `json:"state"`
+	Result   *common.ConnectivityResult `json:"result"`
+	Problems []string                   `json:"problems"`
+}
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa24connectivity,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Checks the connectivity check result with Wi-Fi off, Ethernet only, no connection, a virtual Ethernet and with the HPSA page offline",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json", "diagnostic_messages.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      15 * time.Minute,
+		Params: []testing.Param{{
+			Name: "virtual_ethernet",
+			Val:  connectivityCase{VirtualEthernet: true},
+		}, {
+			Name: "offline",
+			Val:  connectivityCase{Offline: true},
+		}, {
+			Name: "wifi_off",
+			Val:  connectivityCase{Disable: []shill.Technology{shill.TechnologyWifi}, WantWifi: &notConnected},
+		}, {
+			// The virtual Ethernet stands in for the port of a DUT without one.
+			Name: "ethernet_only",
+			Val:  connectivityCase{Disable: []shill.Technology{shill.TechnologyWifi}, VirtualEthernet: true, WantWifi: &notConnected, WantEthernet: &connected},
+		}, {
+			Name: "no_connection",
+			Val:  connectivityCase{Disable: []shill.Technology{shill.TechnologyWifi, shill.TechnologyEthernet}, WantWifi: &notConnected, WantEthernet: &notConnected},
+		}},
+	})
+}
+
+// Hpsa24connectivity sets up the network of the case, runs the connectivity check and compares its rows with what shill sees
+func Hpsa24connectivity(ctx context.Context, s *testing.State) {
+	tc := s.Param().(connectivityCase)
+	ctx, sess := common.SetUpSession(ctx, s, common.Language, networkCleanupTimeout)
+	defer sess.Close()
+	ui, bt, cleanupCtx, path, dashboardPath := sess.UI, sess.BrowserType, sess.CleanupCtx, sess.Path, sess.DashboardPath
+	locale := common.LocaleFromLanguage(common.Language)
+	labels, err := common.GetDiagnosticMessagesJSON(locale, s.DataPath("diagnostic_messages.json"))
+	if err != nil {
+		s.Fatal("Failed to read the diagnostic messages: ", err)
+	}
+	for _, row := range []string{common.ConnectivityWifiRow, common.ConnectivityEthernetRow, common.ConnectivityInternetRow} {
+		if labels[row] == nil {
+			s.Fatalf("No label of the %v row for %v", row, locale)
+		}
+	}
+	// Do pretest after oobe
+	common.PreTest(ctx, s, bt, ui, path)
+
+	env, err := netenv.New(ctx)
+	if err != nil {
+		s.Fatal("Failed to connect to shill: ", err)
+	}
+	for _, tech := range tc.Disable {
+		restore, err := env.DisableTechnology(ctx, tech)
+		if err != nil {
+			s.Fatal("Failed to switch the network off: ", err)
+		}
+		defer func() {
+			if err := restore(cleanupCtx); err != nil {
+				s.Error("Failed to restore the network: ", err)
+			}
+		}()
+	}
+	if tc.VirtualEthernet {
+		veth, err := env.NewVeth(ctx)
+		if err != nil {
+			s.Fatal("Failed to add the virtual Ethernet: ", err)
+		}
+		defer veth.Close(cleanupCtx)
+	}
+	st, err := env.WaitStable(ctx, 3*time.Second, time.Minute)
+	if err != nil {
+		s.Fatal("The network did not settle: ", err)
+	}
+	s.Logf("The network is %+v", st)
+	if tc.VirtualEthernet && !st.EthernetConnected {
+		s.Fatalf("The network is %+v, the virtual Ethernet is not connected", st)
+	}
+	// shill has to agree with the case before the page is compared with it.
+	if tc.WantWifi != nil && st.WifiConnected != *tc.WantWifi || tc.WantEthernet != nil && st.EthernetConnected != *tc.WantEthernet {
+		s.Fatalf("The network is %+v with %v off and the virtual Ethernet %v, it does not match the case", st, tc.Disable, tc.VirtualEthernet)
+	}
+	// want is what the page has to see, offline it reaches nothing but still lists the connected devices.
+	want := st
+	if tc.Offline {
+		nc, err := common.NewNetworkController(ctx)
+		if err != nil {
+			s.Fatal("Failed to open the network controller: ", err)
+		}
+		defer nc.Close(cleanupCtx)
+		if err := nc.Apply(ctx, common.NetworkOffline); err != nil {
+			s.Fatal("Failed to take the HPSA page offline: ", err)
+		}
+		want.Online = false
+	}
+
+	outcome := connectivityOutcome{State: want}
+	defer func() {
+		b, err := json.MarshalIndent(outcome, "", "  ")
+		if err == nil {
+			err = ioutil.WriteFile(filepath.Join(s.OutDir(), "connectivity_outcome.json"), b, 0644)
+		}
+		if err != nil {
+			s.Error("Failed to write the connectivity outcome: ", err)
+		}
+	}()
+	result, err := common.RunConnectivityCheck(ctx, ui, dashboardPath, labels, connectivityTimeout)
+	common.TakeScreenshot(ctx, s, "hpsa24connectivity.png", s.OutDir())
+	if err != nil {
+		s.Fatal("Failed to run the connectivity check: ", err)
+	}
+	outcome.Result = result
+	// The state may change during the check, e.g. the portal detection of shill finishes, then the rows can not be compared.
+	if after, err := env.Read(ctx); err != nil {
+		s.Fatal("Failed to read the network after the check: ", err)
+	} else if after != st {
+		s.Fatalf("The network changed during the check from %+v to %+v", st, after)
+	}
+	outcome.Problems = netenv.Check(want, *result)
+	for _, p := range outcome.Problems {
+		s.Error("The connectivity check does not match the network: ", p)
+	}
+}
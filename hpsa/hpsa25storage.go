@@ -0,0 +1,136 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/bundles/cros/hpsa/storage"
+
+	"go.chromium.org/tast/core/testing"
+)
+
+// storageCheckTimeout is how long the storage check may run
+const storageCheckTimeout = 3 * time.Minute
+
+// storageOutcome is written to OutDir for the report
+type storageOutcome struct {
+	Target storage.Target `json:"target"`
+	// Allocated is what the test filled, 0 when the partition was fuller than the target already.
+	Allocated int64                 `json:"allocated"`
+	Statfs    storage.Usage         `json:"statfs"`
+	Result    *common.StorageResult `json:"result"`
+	Problems  []string              `json:"problems"`
+}
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa25storage,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Fills the stateful partition and checks the storage check reports the free space",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json", "diagnostic_messages.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      10 * time.Minute,
+		Params: []testing.Param{{
+			Name: "fill_50",
+			Val:  storage.Target{Name: "50%", Used: 0.5},
+		}, {
+			Name: "fill_95",
+			Val:  storage.Target{Name: "95%", Used: 0.95},
+		}, {
+			// A little above the threshold, so the DUT does not start its own cleanup during the test.
+			Name: "near_low_disk",
+			Val:  storage.Target{Name: "near low disk", Free: storage.LowDiskThreshold + 256<<20},
+		}},
+	})
+}
+
+// Hpsa25storage fills the stateful partition to the level of the case, runs the storage check and compares its numbers with statfs
+func Hpsa25storage(ctx context.Context, s *testing.State) {
+	target := s.Param().(storage.Target)
+	ctx, sess := common.SetUpSession(ctx, s, common.Language, common.DefaultCleanupTime)
+	defer sess.Close()
+	ui, bt, path, dashboardPath := sess.UI, sess.BrowserType, sess.Path, sess.DashboardPath
+	locale := common.LocaleFromLanguage(common.Language)
+	labels, err := common.GetDiagnosticMessagesJSON(locale, s.DataPath("diagnostic_messages.json"))
+	if err != nil {
+		s.Fatal("Failed to read the diagnostic messages: ", err)
+	}
+	if labels[common.StorageTotalLabel] == nil || labels[common.StorageFreeLabel] == nil {
+		s.Fatal("No labels of the storage check for ", locale)
+	}
+	// Do pretest after oobe
+	common.PreTest(ctx, s, bt, ui, path)
+
+	outcome := storageOutcome{Target: target}
+	defer func() {
+		b, err := json.MarshalIndent(outcome, "", "  ")
+		if err == nil {
+			err = ioutil.WriteFile(filepath.Join(s.OutDir(), "storage_outcome.json"), b, 0644)
+		}
+		if err != nil {
+			s.Error("Failed to write the storage outcome: ", err)
+		}
+	}()
+	// Fill also removes the files an earlier run left behind when it was killed before its cleanup.
+	filler, err := storage.Fill(storage.StatefulPartition, target)
+	defer func() {
+		if err := filler.Cleanup(); err != nil {
+			s.Error("Failed to clean up the stateful partition: ", err)
+		}
+	}()
+	outcome.Allocated = filler.Allocated()
+	if err != nil {
+		s.Fatalf("Failed to fill the stateful partition to %v: %v", target.Name, err)
+	}
+	before, err := storage.Statfs(storage.StatefulPartition)
+	if err != nil {
+		s.Fatal("Failed to read the usage: ", err)
+	}
+	if filler.Allocated() == 0 {
+		s.Logf("The stateful partition is fuller than %v already: %v", target.Name, before)
+	} else {
+		s.Logf("Filled %v, the stateful partition is %v", storage.FormatSize(filler.Allocated()), before)
+	}
+
+	result, err := common.RunStorageCheck(ctx, ui, dashboardPath, labels, storageCheckTimeout)
+	outcome.Result = result
+	common.TakeScreenshot(ctx, s, "hpsa25storage.png", s.OutDir())
+	if err != nil {
+		s.Fatal("Failed to run the storage check: ", err)
+	}
+	usage, err := storage.Statfs(storage.StatefulPartition)
+	if err != nil {
+		s.Fatal("Failed to read the usage: ", err)
+	}
+	outcome.Statfs = usage
+	s.Logf("HPSA shows total %q free %q, statfs has %v", result.Total, result.Free, usage)
+	total, err := storage.ParseSize(result.Total)
+	if err != nil {
+		s.Fatal("Failed to parse the total space: ", err)
+	}
+	free, err := storage.ParseSize(result.Free)
+	if err != nil {
+		s.Fatal("Failed to parse the free space: ", err)
+	}
+	outcome.Problems = storage.Compare(total, free, usage, storage.DefaultTolerance)
+	for _, p := range outcome.Problems {
+		s.Error("The storage check does not match statfs: ", p)
+	}
+	if shown, err := common.ExceptionShown(ctx, ui, dashboardPath); err == nil && shown {
+		s.Errorf("HPSA showed an exception with the stateful partition %v", usage)
+	}
+}
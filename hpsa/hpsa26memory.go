@@ -0,0 +1,157 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/bundles/cros/hpsa/storage"
+	"chromiumos/tast/local/bundles/cros/hpsa/stress"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+const (
+	// memoryCheckTimeout is how long the memory check may run
+	memoryCheckTimeout = 5 * time.Minute
+	// cancelAfter is how long the memory check runs before it is canceled
+	cancelAfter = 5 * time.Second
+)
+
+// memoryMode is what the test does with the memory check
+type memoryMode string
+
+const (
+	// memoryComplete runs the check to the end and checks the total
+	memoryComplete memoryMode = "complete"
+	// memoryCancel cancels the check while it runs
+	memoryCancel memoryMode = "cancel"
+	// memoryPressure runs the check while almost no memory is available
+	memoryPressure memoryMode = "pressure"
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa26memory,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Checks the memory check reports MemTotal, can be canceled and survives memory pressure",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json", "diagnostic_messages.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      15 * time.Minute,
+		Params: []testing.Param{{
+			Name: "complete",
+			Val:  memoryComplete,
+		}, {
+			Name: "cancel",
+			Val:  memoryCancel,
+		}, {
+			Name: "pressure",
+			Val:  memoryPressure,
+		}},
+	})
+}
+
+// Hpsa26memory runs the memory check to the end, cancels it, or runs it under memory pressure
+func Hpsa26memory(ctx context.Context, s *testing.State) {
+	mode := s.Param().(memoryMode)
+	ctx, sess := common.SetUpSession(ctx, s, common.Language, common.DefaultCleanupTime)
+	defer sess.Close()
+	ui, bt, path, dashboardPath := sess.UI, sess.BrowserType, sess.Path, sess.DashboardPath
+	locale := common.LocaleFromLanguage(common.Language)
+	labels, err := common.GetDiagnosticMessagesJSON(locale, s.DataPath("diagnostic_messages.json"))
+	if err != nil {
+		s.Fatal("Failed to read the diagnostic messages: ", err)
+	}
+	if labels[common.MemoryTotalLabel] == nil {
+		s.Fatal("No label of the total memory for ", locale)
+	}
+	// Do pretest after oobe
+	common.PreTest(ctx, s, bt, ui, path)
+
+	switch mode {
+	case memoryComplete:
+		total, err := common.RunMemoryCheck(ctx, ui, dashboardPath, labels, memoryCheckTimeout)
+		common.TakeScreenshot(ctx, s, "hpsa26memory_complete.png", s.OutDir())
+		if err != nil {
+			s.Fatal("Failed to run the memory check: ", err)
+		}
+		info, err := common.ReadMemInfo()
+		if err != nil {
+			s.Fatal("Failed to read meminfo: ", err)
+		}
+		s.Logf("HPSA shows %q, MemTotal is %v", total, storage.FormatSize(info["MemTotal"]))
+		if err := common.CheckMemTotal(total, info["MemTotal"]); err != nil {
+			s.Error("The memory check does not match meminfo: ", err)
+		}
+	case memoryCancel:
+		if err := common.OpenDiagnostic(ctx, ui, common.CheckSystemMemory, dashboardPath); err != nil {
+			s.Fatal("Failed to open the memory check: ", err)
+		}
+		if err := common.StartDiagnostic(ctx, ui, dashboardPath); err != nil {
+			s.Fatal("Failed to start the memory check: ", err)
+		}
+		// GoBigSleepLint Let the check run for a while before it is canceled
+		if err := testing.Sleep(ctx, cancelAfter); err != nil {
+			s.Fatal("Failed to sleep: ", err)
+		}
+		if running, err := common.DiagnosticRunning(ctx, ui, dashboardPath); err != nil || !running {
+			s.Fatalf("The memory check is not running after %v (err %v), nothing to cancel", cancelAfter, err)
+		}
+		if err := common.CancelDiagnostic(ctx, ui, dashboardPath, 30*time.Second); err != nil {
+			common.TakeScreenshot(ctx, s, "hpsa26memory_cancel_stuck.png", s.OutDir())
+			s.Fatal("Failed to cancel the memory check: ", err)
+		}
+		// The page may take a moment to drop the progress after the cancel.
+		if err := testing.Poll(ctx, func(ctx context.Context) error {
+			return common.DiagnosticIdle(ctx, ui, dashboardPath)
+		}, &testing.PollOptions{Interval: time.Second, Timeout: 10 * time.Second}); err != nil {
+			common.TakeScreenshot(ctx, s, "hpsa26memory_cancel.png", s.OutDir())
+			s.Fatal("The memory check is not idle after the cancel: ", err)
+		}
+		if err := common.CloseDiagnostic(ctx, ui, common.CheckSystemMemory, dashboardPath); err != nil {
+			s.Error("Failed to go back to the dashboard: ", err)
+		}
+	case memoryPressure:
+		// The session detaches when the renderer of HPSA is killed, so a working session after the check tells HPSA did not crash.
+		dt, err := common.NewDevTools(ctx, common.AppURLITG)
+		if err != nil {
+			s.Fatal("Failed to open a DevTools session on HPSA: ", err)
+		}
+		defer dt.Close()
+		stopLoad, err := stress.StartLoad(ctx, stress.LoadPressure, memoryCheckTimeout+time.Minute)
+		if err != nil {
+			s.Fatal("Failed to start the memory pressure: ", err)
+		}
+		total, runErr := common.RunMemoryCheck(ctx, ui, dashboardPath, labels, memoryCheckTimeout)
+		common.TakeScreenshot(ctx, s, "hpsa26memory_pressure.png", s.OutDir())
+		stopLoad()
+		alive := func() error {
+			if _, _, err := dt.HeapUsage(ctx); err != nil {
+				return errors.Wrap(err, "the HPSA page is gone")
+			}
+			if shown, err := common.ExceptionShown(ctx, ui, dashboardPath); err == nil && shown {
+				return errors.New("HPSA shows an exception")
+			}
+			return nil
+		}
+		if err := alive(); err != nil {
+			s.Fatal("HPSA crashed under memory pressure: ", err)
+		}
+		if runErr != nil {
+			s.Error("The memory check failed under memory pressure: ", runErr)
+		} else {
+			s.Logf("HPSA shows %q under memory pressure", total)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/bundles/cros/hpsa/component"
+	"chromiumos/tast/local/chrome"
+
+	"go.chromium.org/tast/core/testing"
+)
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa27componenttest,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Runs every component sub-test, feeds synthetic input to the keyboard, touchpad and audio ones and checks the prompts and results follow the answers",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json", "diagnostic_messages.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      30 * time.Minute,
+		Params: []testing.Param{{
+			// Every prompt is answered like a user seeing working hardware, so every sub-test has to pass.
+			Name: "answer_yes",
+			Val:  true,
+		}, {
+			// Every prompt is answered no, so every sub-test which asks has to fail.
+			Name: "answer_no",
+			Val:  false,
+		}},
+	})
+}
+
+// Hpsa27componenttest runs every sub-test of the component test with synthetic input and checks their prompts and results.
+// Nothing looks at the display and camera output, their prompts are still checked and answered.
+func Hpsa27componenttest(ctx context.Context, s *testing.State) {
+	yes := s.Param().(bool)
+	// The fake camera feeds the camera sub-test, the fake UI grants it the camera without asking.
+	ctx, sess := common.SetUpSession(ctx, s, common.Language, common.DefaultCleanupTime,
+		chrome.ExtraArgs("--use-fake-device-for-media-stream", "--use-fake-ui-for-media-stream"))
+	defer sess.Close()
+	ui, bt, cleanupCtx, path, dashboardPath := sess.UI, sess.BrowserType, sess.CleanupCtx, sess.Path, sess.DashboardPath
+	locale := common.LocaleFromLanguage(common.Language)
+	messages, err := common.GetDiagnosticMessagesJSON(locale, s.DataPath("diagnostic_messages.json"))
+	if err != nil {
+		s.Fatal("Failed to read the diagnostic messages: ", err)
+	}
+	// Do pretest after oobe
+	common.PreTest(ctx, s, bt, ui, path)
+
+	driver, err := component.NewDriver(ctx, ui, dashboardPath, messages, s.OutDir())
+	if err != nil {
+		s.Fatal("Failed to set up the synthetic input: ", err)
+	}
+	defer driver.Close(cleanupCtx)
+	if err := common.OpenDiagnostic(ctx, ui, common.ComponentTest, dashboardPath); err != nil {
+		s.Fatal("Failed to open the component test: ", err)
+	}
+	names, err := driver.List(ctx)
+	if err != nil {
+		s.Fatal("Failed to list the component sub-tests: ", err)
+	}
+	s.Logf("HPSA offers %d component sub-tests: %q", len(names), names)
+
+	var results []component.Result
+	defer func() {
+		if err := component.Write(s.OutDir(), results); err != nil {
+			s.Error("Failed to write the component results: ", err)
+		}
+	}()
+	for i, name := range names {
+		if kind := component.Classify(name); !kind.Verifiable() {
+			s.Logf("Only checking the prompt of %v, the %v output can not be checked", name, kind)
+		}
+		r := driver.Run(ctx, i, name, yes)
+		results = append(results, r)
+		s.Logf("%v (%v): %v in %v, prompt shown %v %v", r.Name, r.Kind, r.Status, r.Duration.Round(time.Second), r.PromptShown, r.Evidence)
+		for _, p := range r.Problems {
+			s.Errorf("%v: %v", r.Name, p)
+		}
+	}
+	if err := common.CloseDiagnostic(ctx, ui, common.ComponentTest, dashboardPath); err != nil {
+		s.Error("Failed to go back to the dashboard: ", err)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+
+	// Standard library packages
+	"context"
+	"time"
+
+	//chromiumos/ packages
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/bundles/cros/hpsa/scenario"
+	"chromiumos/tast/local/bundles/cros/hpsa/scenariofile"
+
+	"go.chromium.org/tast/core/testing"
+)
+
+// A scenario is a YAML file in data/scenarios, see diagnostic_pages.yaml for the steps it can use.
+// Its parameter is generated from the file name, run hpsa28scenario_test.go with TAST_GENERATE_UPDATE=1 after adding one.
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:         Hpsa28scenario,
+		LacrosStatus: testing.LacrosVariantExists,
+		Desc:         "Runs the YAML scenarios of hpsa/data/scenarios",
+		Contacts:     []string{"xinyang.li@hp.com"},
+		BugComponent: "",
+		Data:         []string{"hpsa.json", "dashboard.json", "profile.json"},
+		Attr:         []string{"group:hpsa", "hpsa_full"},
+		SoftwareDeps: []string{"chrome"},
+		Timeout:      20 * time.Minute,
+		Params: []testing.Param{
+			// Parameters generated by hpsa28scenario_test.go. DO NOT EDIT.
+			{
+				Name:      "battery_check",
+				Val:       "scenarios/battery_check.yaml",
+				ExtraData: []string{"scenarios/battery_check.yaml"},
+			},
+			{
+				Name:      "diagnostic_pages",
+				Val:       "scenarios/diagnostic_pages.yaml",
+				ExtraData: []string{"scenarios/diagnostic_pages.yaml"},
+			},
+			{
+				Name:      "sign_in",
+				Val:       "scenarios/sign_in.yaml",
+				ExtraData: []string{"scenarios/sign_in.yaml"},
+			},
+			{
+				Name:      "specifications",
+				Val:       "scenarios/specifications.yaml",
+				ExtraData: []string{"scenarios/specifications.yaml"},
+			},
+		},
+	})
+}
+
+// Hpsa28scenario runs the steps of the scenario file from the dashboard
+func Hpsa28scenario(ctx context.Context, s *testing.State) {
+	// The scenario is checked before Chrome starts, so a bad file fails fast.
+	locators, err := scenariofile.ReadLocators(s.DataPath("hpsa.json"), s.DataPath("dashboard.json"))
+	if err != nil {
+		s.Fatal("Failed to read the locators: ", err)
+	}
+	sc, err := scenariofile.Read(s.DataPath(s.Param().(string)), locators, scenario.Diagnostics)
+	if err != nil {
+		s.Fatal("Failed to read the scenario: ", err)
+	}
+	if want := s.TestName()[len("hpsa.Hpsa28scenario."):]; sc.Name != want {
+		s.Fatalf("The scenario is named %q, want %q like the file", sc.Name, want)
+	}
+	s.Logf("Running scenario %v: %v", sc.Name, sc.Description)
+	ctx, sess := common.SetUpSession(ctx, s, common.Language, common.DefaultCleanupTime)
+	defer sess.Close()
+	br, tconn, ui, bt, path, dashboardPath := sess.Browser, sess.Tconn, sess.UI, sess.BrowserType, sess.Path, sess.DashboardPath
+	// Do pretest after oobe
+	common.PreTest(ctx, s, bt, ui, path)
+
+	env := &scenario.Env{
+		S:             s,
+		BrowserType:   bt,
+		UI:            ui,
+		TConn:         tconn,
+		Browser:       br,
+		Path:          path,
+		DashboardPath: dashboardPath,
+		ProfilePath:   s.DataPath("profile.json"),
+		Locators:      locators,
+	}
+	if err := env.Run(ctx, sc); err != nil {
+		common.TakeScreenshot(ctx, s, "hpsa28scenario_failed.png", s.OutDir())
+		s.Fatalf("Scenario %v failed: %v", sc.Name, err)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package hpsa
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"chromiumos/tast/common/genparams"
+)
+
+// TestHpsa28scenarioParams generates a parameter of Hpsa28scenario for every file in data/scenarios
+func TestHpsa28scenarioParams(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("data", "scenarios", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type param struct {
+		Name string
+		File string
+	}
+	var params []param
+	for _, path := range paths {
+		file := filepath.Base(path)
+		params = append(params, param{Name: strings.TrimSuffix(file, ".yaml"), File: "scenarios/" + file})
+	}
+	code := genparams.Template(t, `{{ range . }}{
+		Name:      {{ .Name | fmt }},
+		Val:       {{ .File | fmt }},
+		ExtraData: []string{ {{ .File | fmt }} },
+	},
+	{{ end }}`, params)
+	genparams.Ensure(t, "hpsa28scenario.go", code)
+}
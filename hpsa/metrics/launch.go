@@ -0,0 +1,94 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"chromiumos/tast/local/apps"
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+// renderPollInterval is how often the cards are looked for, it bounds the precision of the render times
+const renderPollInterval = 100 * time.Millisecond
+
+// DashboardCards are the dashboard elements whose first render is measured
+var DashboardCards = []string{common.DeviceName, common.ProductNumber, common.SerialNumber, common.WarrantyCard, common.Specifications, common.BatteryCheck}
+
+// Launch launches the app and returns the time until ready is shown
+func Launch(ctx context.Context, tconn *chrome.TestConn, ui *uiauto.Context, appID string, ready *nodewith.Finder, timeout time.Duration) (elapsed time.Duration, err error) {
+	step := common.StartStep(ctx, "launch", "Launch", ready.Pretty())
+	step.Attempt()
+	defer func() { step.Done(err) }()
+	start := time.Now()
+	if err := apps.Launch(ctx, tconn, appID); err != nil {
+		return 0, errors.Wrap(err, "failed to launch HPSA")
+	}
+	if err := ui.WithInterval(renderPollInterval).WithTimeout(timeout).WaitUntilExists(ready)(ctx); err != nil {
+		return 0, errors.Wrap(err, "HPSA was not ready")
+	}
+	return time.Since(start), nil
+}
+
+// LaunchToCards launches the app and returns the time until the first card is shown and the first render time of every card
+func LaunchToCards(ctx context.Context, tconn *chrome.TestConn, ui *uiauto.Context, appID string, cards map[string]*nodewith.Finder, timeout time.Duration) (first time.Duration, rendered map[string]time.Duration, err error) {
+	step := common.StartStep(ctx, "launch", "LaunchToCards", "")
+	step.Attempt()
+	defer func() { step.Done(err) }()
+	start := time.Now()
+	if err := apps.Launch(ctx, tconn, appID); err != nil {
+		return 0, nil, errors.Wrap(err, "failed to launch HPSA")
+	}
+	rendered, err = CardRenderTimes(ctx, ui, start, cards, timeout)
+	if len(rendered) == 0 {
+		return 0, rendered, errors.Wrap(err, "no dashboard card was shown")
+	}
+	first = timeout
+	for _, d := range rendered {
+		if d < first {
+			first = d
+		}
+	}
+	return first, rendered, err
+}
+
+// Close closes the app and waits for shown to be gone
+func Close(ctx context.Context, tconn *chrome.TestConn, ui *uiauto.Context, appID string, shown *nodewith.Finder) error {
+	if err := apps.Close(ctx, tconn, appID); err != nil {
+		return errors.Wrap(err, "failed to close HPSA")
+	}
+	return ui.WithTimeout(30 * time.Second).WaitUntilGone(shown)(ctx)
+}
+
+// CardRenderTimes returns for every card the time from start until it was first found.
+// Cards still missing after the timeout are left out of the result and reported in the error.
+func CardRenderTimes(ctx context.Context, ui *uiauto.Context, start time.Time, cards map[string]*nodewith.Finder, timeout time.Duration) (map[string]time.Duration, error) {
+	rendered := make(map[string]time.Duration)
+	err := testing.Poll(ctx, func(ctx context.Context) error {
+		var missing []string
+		for name, finder := range cards {
+			if _, ok := rendered[name]; ok {
+				continue
+			}
+			if found, err := ui.IsNodeFound(ctx, finder); err == nil && found {
+				rendered[name] = time.Since(start)
+				continue
+			}
+			missing = append(missing, name)
+		}
+		if len(missing) > 0 {
+			return errors.Errorf("cards not rendered yet: %v", missing)
+		}
+		return nil
+	}, &testing.PollOptions{Interval: renderPollInterval, Timeout: timeout})
+	return rendered, err
+}
@@ -0,0 +1,244 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package metrics measures how fast HPSA launches and loads and how much the HPSA renderer uses.
+// Results are saved as perf.Values and compared with a stored baseline.
+// A metric without a baseline is only recorded, its baseline is taken from the candidate real runs write.
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"chromiumos/tast/common/perf"
+
+	"go.chromium.org/tast/core/errors"
+)
+
+const (
+	// RegressionsFile is the list of regressions written to OutDir
+	RegressionsFile = "perf_regressions.json"
+	// CandidateFile is the baseline made of the values of the run, written to OutDir
+	CandidateFile = "perf_baseline_candidate.json"
+)
+
+// Metrics of the launch and the page loads
+var (
+	// LaunchCold is the first launch of the app after it is installed, up to the first welcome button.
+	LaunchCold = perf.Metric{Name: "HPSA.Launch.Cold", Unit: "ms", Direction: perf.SmallerIsBetter}
+	// LaunchWarm is a launch of the app after the welcome pages were done, up to the first dashboard card.
+	LaunchWarm = perf.Metric{Name: "HPSA.Launch.Warm", Unit: "ms", Direction: perf.SmallerIsBetter, Multiple: true}
+	// WelcomeToDashboard is the time from the first welcome click to the first dashboard card.
+	WelcomeToDashboard = perf.Metric{Name: "HPSA.WelcomeToDashboard", Unit: "ms", Direction: perf.SmallerIsBetter}
+	// RendererCPUMean is the mean CPU use of the HPSA renderers.
+	RendererCPUMean = perf.Metric{Name: "HPSA.Renderer.CPU.Mean", Unit: "percent", Direction: perf.SmallerIsBetter}
+	// RendererCPUPeak is the highest CPU use of the HPSA renderers in one sample.
+	RendererCPUPeak = perf.Metric{Name: "HPSA.Renderer.CPU.Peak", Unit: "percent", Direction: perf.SmallerIsBetter}
+	// RendererRSSMean is the mean resident memory of the HPSA renderers.
+	RendererRSSMean = perf.Metric{Name: "HPSA.Renderer.RSS.Mean", Unit: "MB", Direction: perf.SmallerIsBetter}
+	// RendererRSSPeak is the highest resident memory of the HPSA renderers.
+	RendererRSSPeak = perf.Metric{Name: "HPSA.Renderer.RSS.Peak", Unit: "MB", Direction: perf.SmallerIsBetter}
+)
+
+// CardFirstRender is the time from the launch to the first render of the dashboard card
+func CardFirstRender(card string) perf.Metric {
+	return perf.Metric{Name: "HPSA.CardFirstRender." + card, Unit: "ms", Direction: perf.SmallerIsBetter, Multiple: true}
+}
+
+// DiagnosticDuration is the run time of the diagnostic, from the click on the run button until it is enabled again
+func DiagnosticDuration(diagnostic string) perf.Metric {
+	return perf.Metric{Name: "HPSA.Diagnostic." + diagnostic, Unit: "sec", Direction: perf.SmallerIsBetter}
+}
+
+// Recorder keeps the measured values of one test
+type Recorder struct {
+	pv      *perf.Values
+	metrics map[string]perf.Metric
+	values  map[string][]float64
+}
+
+// NewRecorder returns an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{pv: perf.NewValues(), metrics: make(map[string]perf.Metric), values: make(map[string][]float64)}
+}
+
+// Set records the value of a single value metric
+func (r *Recorder) Set(m perf.Metric, value float64) {
+	r.pv.Set(m, value)
+	r.metrics[m.Name] = m
+	r.values[m.Name] = []float64{value}
+}
+
+// Append adds values to a multiple value metric
+func (r *Recorder) Append(m perf.Metric, values ...float64) {
+	r.pv.Append(m, values...)
+	r.metrics[m.Name] = m
+	r.values[m.Name] = append(r.values[m.Name], values...)
+}
+
+// SetDuration records the duration in the unit of the metric
+func (r *Recorder) SetDuration(m perf.Metric, d time.Duration) {
+	r.Set(m, durationIn(m.Unit, d))
+}
+
+// AppendDuration adds the duration in the unit of the metric
+func (r *Recorder) AppendDuration(m perf.Metric, d time.Duration) {
+	r.Append(m, durationIn(m.Unit, d))
+}
+
+func durationIn(unit string, d time.Duration) float64 {
+	if unit == "sec" {
+		return d.Seconds()
+	}
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Save writes the perf values to the directory
+func (r *Recorder) Save(dir string) error {
+	return r.pv.Save(dir)
+}
+
+// Value is the value of the metric compared with the baseline, the median for multiple value metrics
+func (r *Recorder) Value(name string) (float64, bool) {
+	values, ok := r.values[name]
+	if !ok || len(values) == 0 {
+		return 0, false
+	}
+	return median(values), true
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// BaselineEntry is the stored value of one metric
+type BaselineEntry struct {
+	Value float64 `json:"value"`
+	// Tolerance is the allowed relative change towards worse, e.g. 0.2 for 20%. The baseline tolerance is used when it is 0.
+	Tolerance float64 `json:"tolerance,omitempty"`
+}
+
+// Baseline is the stored values the measured ones are compared with
+type Baseline struct {
+	// Tolerance is the default allowed relative change towards worse.
+	Tolerance float64                  `json:"tolerance"`
+	Metrics   map[string]BaselineEntry `json:"metrics"`
+}
+
+// ReadBaseline reads the baseline from the path
+func ReadBaseline(path string) (*Baseline, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not read the baseline from %q", path)
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, errors.Wrapf(err, "can not parse the baseline from %q", path)
+	}
+	if b.Tolerance <= 0 {
+		return nil, errors.Errorf("baseline %q has no positive tolerance", path)
+	}
+	return &b, nil
+}
+
+// Regression is a metric which got worse than its baseline allows
+type Regression struct {
+	Metric    string  `json:"metric"`
+	Unit      string  `json:"unit"`
+	Value     float64 `json:"value"`
+	Baseline  float64 `json:"baseline"`
+	Tolerance float64 `json:"tolerance"`
+	// Change is the relative change from the baseline, positive when the value grew.
+	Change float64 `json:"change"`
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%v is %.1f %v, baseline %.1f %v (%+.0f%%, tolerance %.0f%%)", r.Metric, r.Value, r.Unit, r.Baseline, r.Unit, r.Change*100, r.Tolerance*100)
+}
+
+// Compare returns every recorded metric which is worse than the baseline by more than its tolerance.
+// Metrics without a baseline are not compared.
+func (b *Baseline) Compare(r *Recorder) []Regression {
+	var names []string
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var regressions []Regression
+	for _, name := range names {
+		entry, ok := b.Metrics[name]
+		if !ok || entry.Value == 0 {
+			continue
+		}
+		tolerance := entry.Tolerance
+		if tolerance == 0 {
+			tolerance = b.Tolerance
+		}
+		value, _ := r.Value(name)
+		change := (value - entry.Value) / entry.Value
+		m := r.metrics[name]
+		worse := change > tolerance
+		if m.Direction == perf.BiggerIsBetter {
+			worse = -change > tolerance
+		}
+		if worse {
+			regressions = append(regressions, Regression{Metric: name, Unit: m.Unit, Value: value, Baseline: entry.Value, Tolerance: tolerance, Change: change})
+		}
+	}
+	return regressions
+}
+
+// Unbaselined returns the recorded metrics the baseline has no value for, they are recorded only
+func (b *Baseline) Unbaselined(r *Recorder) []string {
+	var names []string
+	for name := range r.metrics {
+		if entry, ok := b.Metrics[name]; !ok || entry.Value == 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Candidate returns a baseline with the recorded values and the tolerance of b, to be reviewed over several runs before it is stored
+func (b *Baseline) Candidate(r *Recorder) *Baseline {
+	candidate := &Baseline{Tolerance: b.Tolerance, Metrics: make(map[string]BaselineEntry)}
+	for name := range r.metrics {
+		if value, ok := r.Value(name); ok {
+			candidate.Metrics[name] = BaselineEntry{Value: value}
+		}
+	}
+	return candidate
+}
+
+// WriteCandidate writes the candidate baseline to the directory
+func WriteCandidate(dir string, candidate *Baseline) error {
+	b, err := json.MarshalIndent(candidate, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, CandidateFile), b, 0644)
+}
+
+// WriteRegressions writes the regressions to the directory, an empty list is written too
+func WriteRegressions(dir string, regressions []Regression) error {
+	if regressions == nil {
+		regressions = []Regression{}
+	}
+	b, err := json.MarshalIndent(regressions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, RegressionsFile), b, 0644)
+}
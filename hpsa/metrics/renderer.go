@@ -0,0 +1,196 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package metrics
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"go.chromium.org/tast/core/errors"
+)
+
+// RendererUsage is the CPU and memory use of the HPSA renderers over a run
+type RendererUsage struct {
+	Samples   int     `json:"samples"`
+	CPUMean   float64 `json:"cpuMeanPercent"`
+	CPUPeak   float64 `json:"cpuPeakPercent"`
+	RSSMeanMB float64 `json:"rssMeanMB"`
+	RSSPeakMB float64 `json:"rssPeakMB"`
+	// Renderers is the number of HPSA renderers seen during the run.
+	Renderers int `json:"renderers"`
+}
+
+// Record sets the renderer metrics of the usage
+func (u RendererUsage) Record(r *Recorder) {
+	r.Set(RendererCPUMean, u.CPUMean)
+	r.Set(RendererCPUPeak, u.CPUPeak)
+	r.Set(RendererRSSMean, u.RSSMeanMB)
+	r.Set(RendererRSSPeak, u.RSSPeakMB)
+}
+
+// RendererSampler samples the renderers started after it was created.
+// Create it before HPSA is launched with no other page open, so every new renderer belongs to HPSA.
+type RendererSampler struct {
+	excluded map[int32]bool
+	interval time.Duration
+
+	mu     sync.Mutex
+	cpu    map[int32]float64
+	seen   map[int32]bool
+	usage  RendererUsage
+	cpuSum float64
+	rssSum float64
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRendererSampler records the renderers running now, they are left out of the samples
+func NewRendererSampler(ctx context.Context, interval time.Duration) (*RendererSampler, error) {
+	procs, err := renderers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	excluded := make(map[int32]bool)
+	for _, p := range procs {
+		excluded[p.Pid] = true
+	}
+	return &RendererSampler{excluded: excluded, interval: interval, cpu: make(map[int32]float64), seen: make(map[int32]bool)}, nil
+}
+
+// Start samples in the background until Stop is called
+func (rs *RendererSampler) Start(ctx context.Context) {
+	ctx, rs.cancel = context.WithCancel(ctx)
+	rs.done = make(chan struct{})
+	go func() {
+		defer close(rs.done)
+		last := time.Now()
+		ticker := time.NewTicker(rs.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				// A failed sample is skipped, renderers come and go while HPSA navigates.
+				rs.sample(ctx, now.Sub(last))
+				last = now
+			}
+		}
+	}()
+}
+
+// Stop stops sampling and returns the usage
+func (rs *RendererSampler) Stop() RendererUsage {
+	rs.cancel()
+	<-rs.done
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	u := rs.usage
+	if u.Samples > 0 {
+		u.CPUMean = rs.cpuSum / float64(u.Samples)
+		u.RSSMeanMB = rs.rssSum / float64(u.Samples)
+	}
+	u.Renderers = len(rs.seen)
+	return u
+}
+
+// sample adds the CPU use since the last sample and the resident memory of the HPSA renderers
+func (rs *RendererSampler) sample(ctx context.Context, elapsed time.Duration) error {
+	procs, err := renderers(ctx)
+	if err != nil {
+		return err
+	}
+	var cpuDelta, rss float64
+	current := make(map[int32]float64)
+	for _, p := range procs {
+		if rs.excluded[p.Pid] {
+			continue
+		}
+		times, err := p.TimesWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		mem, err := p.MemoryInfoWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		total := times.User + times.System
+		current[p.Pid] = total
+		// A renderer started since the last sample counts from zero.
+		cpuDelta += total - rs.cpu[p.Pid]
+		rss += float64(mem.RSS) / (1024 * 1024)
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	for pid := range current {
+		rs.seen[pid] = true
+	}
+	rs.cpu = current
+	if len(current) == 0 {
+		return nil
+	}
+	cpu := cpuDelta / elapsed.Seconds() * 100
+	rs.usage.Samples++
+	rs.cpuSum += cpu
+	rs.rssSum += rss
+	if cpu > rs.usage.CPUPeak {
+		rs.usage.CPUPeak = cpu
+	}
+	if rss > rs.usage.RSSPeakMB {
+		rs.usage.RSSPeakMB = rss
+	}
+	return nil
+}
+
+// RSS returns the resident memory of the HPSA renderers now in MB, it does not need the sampler to be started
+func (rs *RendererSampler) RSS(ctx context.Context) (float64, error) {
+	procs, err := renderers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var rss float64
+	found := false
+	for _, p := range procs {
+		if rs.excluded[p.Pid] {
+			continue
+		}
+		mem, err := p.MemoryInfoWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		rss += float64(mem.RSS) / (1024 * 1024)
+		found = true
+	}
+	if !found {
+		return 0, errors.New("no HPSA renderer is running")
+	}
+	return rss, nil
+}
+
+// renderers returns the Chrome renderer processes
+func renderers(ctx context.Context) ([]*process.Process, error) {
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the processes")
+	}
+	var result []*process.Process
+	for _, p := range procs {
+		args, err := p.CmdlineSliceWithContext(ctx)
+		if err != nil || len(args) == 0 || !strings.HasSuffix(args[0], "/chrome") {
+			continue
+		}
+		for _, arg := range args[1:] {
+			if arg == "--type=renderer" {
+				result = append(result, p)
+				break
+			}
+		}
+	}
+	return result, nil
+}
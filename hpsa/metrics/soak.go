@@ -0,0 +1,149 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"chromiumos/tast/common/perf"
+)
+
+// SoakFile is the per cycle memory samples written to OutDir
+const SoakFile = "soak_samples.json"
+
+// Metrics of the soak run, the growth is the slope of the trend fit over the cycles
+var (
+	// JSHeapGrowth is the growth of the used JS heap of the HPSA page per cycle.
+	JSHeapGrowth = perf.Metric{Name: "HPSA.Soak.JSHeap.Growth", Unit: "MB", Direction: perf.SmallerIsBetter}
+	// RendererRSSGrowth is the growth of the resident memory of the HPSA renderers per cycle.
+	RendererRSSGrowth = perf.Metric{Name: "HPSA.Soak.RendererRSS.Growth", Unit: "MB", Direction: perf.SmallerIsBetter}
+	// DOMNodesGrowth is the growth of the DOM nodes of the HPSA page per cycle.
+	DOMNodesGrowth = perf.Metric{Name: "HPSA.Soak.DOMNodes.Growth", Unit: "count", Direction: perf.SmallerIsBetter}
+	// JSHeapUsed is the used JS heap after every cycle.
+	JSHeapUsed = perf.Metric{Name: "HPSA.Soak.JSHeap.Used", Unit: "MB", Direction: perf.SmallerIsBetter, Multiple: true}
+	// RendererRSS is the resident memory of the HPSA renderers after every cycle.
+	RendererRSS = perf.Metric{Name: "HPSA.Soak.RendererRSS", Unit: "MB", Direction: perf.SmallerIsBetter, Multiple: true}
+)
+
+// SoakSample is the memory of HPSA after one cycle
+type SoakSample struct {
+	Cycle     int           `json:"cycle"`
+	Elapsed   time.Duration `json:"elapsedNs"`
+	JSHeapMB  float64       `json:"jsHeapMB"`
+	RSSMB     float64       `json:"rendererRSSMB"`
+	Documents int           `json:"documents"`
+	Nodes     int           `json:"nodes"`
+	Listeners int           `json:"listeners"`
+}
+
+// Trend is the least squares line through the samples
+type Trend struct {
+	// Slope is the growth per cycle.
+	Slope     float64 `json:"slope"`
+	Intercept float64 `json:"intercept"`
+	// R2 tells how well the line fits, from 0 to 1. A steady leak fits well, noise does not.
+	R2 float64 `json:"r2"`
+}
+
+// FitTrend fits a line through the values, the cycle number is the x axis
+func FitTrend(ys []float64) Trend {
+	n := float64(len(ys))
+	if len(ys) < 2 {
+		return Trend{}
+	}
+	var sx, sy, sxx, sxy float64
+	for i, y := range ys {
+		x := float64(i)
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+	}
+	slope := (n*sxy - sx*sy) / (n*sxx - sx*sx)
+	intercept := (sy - slope*sx) / n
+	mean := sy / n
+	var ssTot, ssRes float64
+	for i, y := range ys {
+		fit := intercept + slope*float64(i)
+		ssTot += (y - mean) * (y - mean)
+		ssRes += (y - fit) * (y - fit)
+	}
+	r2 := 1.0
+	if ssTot > 0 {
+		r2 = 1 - ssRes/ssTot
+	}
+	return Trend{Slope: slope, Intercept: intercept, R2: r2}
+}
+
+// SoakLimits are the allowed growths per cycle
+type SoakLimits struct {
+	JSHeapMB float64
+	RSSMB    float64
+	Nodes    float64
+	// WarmUp is the number of first cycles left out of the fit, they fill the caches.
+	WarmUp int
+}
+
+// SoakResult is the trend of every sampled value
+type SoakResult struct {
+	JSHeap Trend `json:"jsHeap"`
+	RSS    Trend `json:"rendererRSS"`
+	Nodes  Trend `json:"nodes"`
+}
+
+// AnalyzeSoak fits the trends of the samples after the warm up and returns the growths over the limits
+func AnalyzeSoak(samples []SoakSample, limits SoakLimits) (SoakResult, []string) {
+	var heap, rss, nodes []float64
+	for _, s := range samples {
+		if s.Cycle <= limits.WarmUp {
+			continue
+		}
+		heap = append(heap, s.JSHeapMB)
+		rss = append(rss, s.RSSMB)
+		nodes = append(nodes, float64(s.Nodes))
+	}
+	result := SoakResult{JSHeap: FitTrend(heap), RSS: FitTrend(rss), Nodes: FitTrend(nodes)}
+	if len(heap) < 2 {
+		return result, []string{fmt.Sprintf("%d cycles after the warm up, at least 2 are needed for a trend", len(heap))}
+	}
+	var problems []string
+	if result.JSHeap.Slope > limits.JSHeapMB {
+		problems = append(problems, fmt.Sprintf("JS heap grows %.2f MB per cycle (r2 %.2f), limit %.2f MB", result.JSHeap.Slope, result.JSHeap.R2, limits.JSHeapMB))
+	}
+	if result.RSS.Slope > limits.RSSMB {
+		problems = append(problems, fmt.Sprintf("renderer memory grows %.2f MB per cycle (r2 %.2f), limit %.2f MB", result.RSS.Slope, result.RSS.R2, limits.RSSMB))
+	}
+	if result.Nodes.Slope > limits.Nodes {
+		problems = append(problems, fmt.Sprintf("DOM grows %.0f nodes per cycle (r2 %.2f), limit %.0f", result.Nodes.Slope, result.Nodes.R2, limits.Nodes))
+	}
+	return result, problems
+}
+
+// Record sets the soak metrics of the samples and the result
+func (r SoakResult) Record(rec *Recorder, samples []SoakSample) {
+	rec.Set(JSHeapGrowth, r.JSHeap.Slope)
+	rec.Set(RendererRSSGrowth, r.RSS.Slope)
+	rec.Set(DOMNodesGrowth, r.Nodes.Slope)
+	for _, s := range samples {
+		rec.Append(JSHeapUsed, s.JSHeapMB)
+		rec.Append(RendererRSS, s.RSSMB)
+	}
+}
+
+// WriteSoak writes the samples and the trends to the directory
+func WriteSoak(dir string, samples []SoakSample, result SoakResult) error {
+	b, err := json.MarshalIndent(struct {
+		Samples []SoakSample `json:"samples"`
+		Trends  SoakResult   `json:"trends"`
+	}{samples, result}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, SoakFile), b, 0644)
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package metrics
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFitTrend(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		ys        []float64
+		slope     float64
+		intercept float64
+		r2        float64
+	}{
+		{"flat", []float64{50, 50, 50, 50}, 0, 50, 1},
+		{"growing", []float64{10, 12, 14, 16, 18}, 2, 10, 1},
+		{"shrinking", []float64{9, 6, 3}, -3, 9, 1},
+		{"noise around a growth", []float64{10, 13, 13, 16}, 1.8, 10.3, 0.9},
+		{"one value", []float64{42}, 0, 0, 0},
+		{"no values", nil, 0, 0, 0},
+	} {
+		got := FitTrend(tc.ys)
+		if math.Abs(got.Slope-tc.slope) > 1e-9 || math.Abs(got.Intercept-tc.intercept) > 1e-9 || math.Abs(got.R2-tc.r2) > 1e-9 {
+			t.Errorf("%v: FitTrend(%v) = %+v, want slope %v intercept %v r2 %v", tc.name, tc.ys, got, tc.slope, tc.intercept, tc.r2)
+		}
+	}
+}
+
+// soakSamples returns one sample per cycle, counted from 1, with the JS heap, renderer memory and nodes
+func soakSamples(heap, rss []float64, nodes []int) []SoakSample {
+	samples := make([]SoakSample, len(heap))
+	for i := range heap {
+		samples[i] = SoakSample{Cycle: i + 1, JSHeapMB: heap[i], RSSMB: rss[i], Nodes: nodes[i]}
+	}
+	return samples
+}
+
+func TestAnalyzeSoak(t *testing.T) {
+	limits := SoakLimits{JSHeapMB: 0.5, RSSMB: 1, Nodes: 10}
+	for _, tc := range []struct {
+		name    string
+		samples []SoakSample
+		warmUp  int
+		// want are parts of the expected problems in order, none when empty.
+		want []string
+	}{
+		{
+			name:    "flat",
+			samples: soakSamples([]float64{30, 30, 30, 30}, []float64{150, 150, 150, 150}, []int{900, 900, 900, 900}),
+		},
+		{
+			name:    "growing",
+			samples: soakSamples([]float64{30, 31, 32, 33}, []float64{150, 152, 154, 156}, []int{900, 920, 940, 960}),
+			want:    []string{"JS heap grows 1.00 MB", "renderer memory grows 2.00 MB", "DOM grows 20 nodes"},
+		},
+		{
+			// The caches fill in the first two cycles, only the flat rest counts.
+			name:    "warm up excluded",
+			samples: soakSamples([]float64{10, 25, 30, 30, 30}, []float64{80, 140, 150, 150, 150}, []int{300, 800, 900, 900, 900}),
+			warmUp:  2,
+		},
+		{
+			name:    "warm up growth counts without a warm up",
+			samples: soakSamples([]float64{10, 25, 30, 30, 30}, []float64{150, 150, 150, 150, 150}, []int{900, 900, 900, 900, 900}),
+			want:    []string{"JS heap grows"},
+		},
+		{
+			name:    "too few samples after the warm up",
+			samples: soakSamples([]float64{30, 40, 50}, []float64{150, 160, 170}, []int{900, 950, 1000}),
+			warmUp:  2,
+			want:    []string{"1 cycles after the warm up, at least 2"},
+		},
+		{
+			name: "no samples",
+			want: []string{"0 cycles after the warm up"},
+		},
+	} {
+		l := limits
+		l.WarmUp = tc.warmUp
+		_, problems := AnalyzeSoak(tc.samples, l)
+		if len(problems) != len(tc.want) {
+			t.Errorf("%v: AnalyzeSoak returned %q, want %d problems", tc.name, problems, len(tc.want))
+			continue
+		}
+		for i, want := range tc.want {
+			if !strings.Contains(problems[i], want) {
+				t.Errorf("%v: problem %d is %q, want it to contain %q", tc.name, i, problems[i], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package netenv adds a virtual network next to the real ones through shill and reads the state shill sees.
+package netenv
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"chromiumos/tast/common/shillconst"
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/shill"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+// State is the network of the DUT as shill sees it
+type State struct {
+	// WifiEnabled tells the Wi-Fi technology is on, WifiConnected tells a Wi-Fi service is connected.
+	WifiEnabled   bool `json:"wifiEnabled"`
+	WifiConnected bool `json:"wifiConnected"`
+	// EthernetConnected tells an Ethernet service, real or virtual, is connected.
+	EthernetConnected bool `json:"ethernetConnected"`
+	// Online tells a connected service passed the portal detection of shill, i.e. it reaches the internet.
+	Online bool `json:"online"`
+}
+
+// connectedStates are the service states shill has an IP config in
+var connectedStates = map[string]bool{
+	shillconst.ServiceStateReady:           true,
+	shillconst.ServiceStateOnline:          true,
+	shillconst.ServiceStateRedirectFound:   true,
+	shillconst.ServiceStatePortalSuspected: true,
+	shillconst.ServiceStateNoConnectivity:  true,
+}
+
+// Env reads the network of shill, adds virtual networks to it and switches its technologies off.
+// The real interfaces are left up unless a test disables their technology.
+type Env struct {
+	m *shill.Manager
+}
+
+// New connects to shill
+func New(ctx context.Context) (*Env, error) {
+	m, err := shill.NewManager(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the shill manager")
+	}
+	return &Env{m: m}, nil
+}
+
+// Read reads the state from the services of shill
+func (e *Env) Read(ctx context.Context) (State, error) {
+	var st State
+	enabled, err := e.m.IsEnabled(ctx, shill.TechnologyWifi)
+	if err != nil {
+		return st, errors.Wrap(err, "failed to get whether Wi-Fi is enabled")
+	}
+	st.WifiEnabled = enabled
+	props, err := e.m.GetProperties(ctx)
+	if err != nil {
+		return st, errors.Wrap(err, "failed to get the manager properties")
+	}
+	paths, err := props.GetObjectPaths(shillconst.ManagerPropertyServices)
+	if err != nil {
+		return st, errors.Wrap(err, "failed to get the services")
+	}
+	for _, path := range paths {
+		svcType, state, err := e.service(ctx, path)
+		if err != nil {
+			// The service went away while it was read.
+			continue
+		}
+		if !connectedStates[state] {
+			continue
+		}
+		switch svcType {
+		case shillconst.TypeWifi:
+			st.WifiConnected = true
+		case shillconst.TypeEthernet:
+			st.EthernetConnected = true
+		}
+		if state == shillconst.ServiceStateOnline {
+			st.Online = true
+		}
+	}
+	return st, nil
+}
+
+func (e *Env) service(ctx context.Context, path dbus.ObjectPath) (svcType, state string, err error) {
+	svc, err := shill.NewService(ctx, path)
+	if err != nil {
+		return "", "", err
+	}
+	props, err := svc.GetProperties(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	if svcType, err = props.GetString(shillconst.ServicePropertyType); err != nil {
+		return "", "", err
+	}
+	if state, err = props.GetString(shillconst.ServicePropertyState); err != nil {
+		return "", "", err
+	}
+	return svcType, state, nil
+}
+
+// DisableTechnology switches the technology off in shill and returns what switches it back on.
+// A technology which is off already is left off, its restore does nothing.
+func (e *Env) DisableTechnology(ctx context.Context, tech shill.Technology) (restore func(context.Context) error, err error) {
+	enabled, err := e.m.IsEnabled(ctx, tech)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get whether %v is enabled", tech)
+	}
+	if !enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	if err := e.m.DisableTechnology(ctx, tech); err != nil {
+		return nil, errors.Wrapf(err, "failed to disable %v", tech)
+	}
+	testing.ContextLogf(ctx, "Disabled %v", tech)
+	return func(ctx context.Context) error {
+		if err := e.m.EnableTechnology(ctx, tech); err != nil {
+			return errors.Wrapf(err, "failed to enable %v again", tech)
+		}
+		return nil
+	}, nil
+}
+
+// WaitStable waits for shill to settle after a change, i.e. until two reads interval apart are the same
+func (e *Env) WaitStable(ctx context.Context, interval, timeout time.Duration) (State, error) {
+	last, err := e.Read(ctx)
+	if err != nil {
+		return last, err
+	}
+	err = testing.Poll(ctx, func(ctx context.Context) error {
+		st, err := e.Read(ctx)
+		if err != nil {
+			return testing.PollBreak(err)
+		}
+		if st != last {
+			last = st
+			return errors.Errorf("the network is still changing: %+v", st)
+		}
+		return nil
+	}, &testing.PollOptions{Interval: interval, Timeout: timeout})
+	return last, err
+}
+
+// Check compares the connectivity check with the state and returns every row which does not match.
+// HPSA leaves out the row of a device the DUT does not have, e.g. Ethernet on a Wi-Fi only DUT, a missing row is not connected.
+func Check(st State, r common.ConnectivityResult) []string {
+	want := func(ok bool) common.ConnectivityStatus {
+		if ok {
+			return common.ConnectivityPassed
+		}
+		return common.ConnectivityFailed
+	}
+	var problems []string
+	for _, row := range []struct {
+		name      string
+		got, want common.ConnectivityStatus
+	}{
+		{"Wi-Fi", r.Wifi, want(st.WifiConnected)},
+		{"Ethernet", r.Ethernet, want(st.EthernetConnected)},
+		{"internet", r.Internet, want(st.Online)},
+	} {
+		got := row.got
+		if got == common.ConnectivityMissing {
+			got = common.ConnectivityFailed
+		}
+		if got != row.want {
+			problems = append(problems, fmt.Sprintf("%v is %q, want %q", row.name, row.got, row.want))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,54 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package netenv
+
+import (
+	"context"
+	"time"
+
+	"chromiumos/tast/common/shillconst"
+	"chromiumos/tast/local/network/virtualnet"
+	"chromiumos/tast/local/network/virtualnet/env"
+	"chromiumos/tast/local/network/virtualnet/subnet"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+const (
+	// vethSuffix tells the interfaces of the virtual Ethernet apart, virtualnet takes at most 3 characters
+	vethSuffix = "hp"
+	// connectTimeout is how long shill may take to connect the virtual Ethernet
+	connectTimeout = time.Minute
+)
+
+// Veth is a virtual Ethernet from virtualnet, a veth pair whose peer is a router with DHCP in a netns.
+// The router does not reach the internet, and the service keeps the default priority so the real network stays the default one.
+type Veth struct {
+	router *env.Env
+}
+
+// NewVeth creates the virtual Ethernet and waits for shill to connect it
+func (e *Env) NewVeth(ctx context.Context) (*Veth, error) {
+	svc, router, err := virtualnet.CreateRouterEnv(ctx, e.m, subnet.NewPool(), virtualnet.EnvOptions{
+		NameSuffix: vethSuffix,
+		EnableDHCP: true,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the virtual Ethernet")
+	}
+	v := &Veth{router: router}
+	if err := svc.WaitForProperty(ctx, shillconst.ServicePropertyIsConnected, true, connectTimeout); err != nil {
+		v.Close(ctx)
+		return nil, errors.Wrap(err, "shill did not connect the virtual Ethernet")
+	}
+	testing.ContextLog(ctx, "Virtual Ethernet is connected")
+	return v, nil
+}
+
+// Close removes the router, its netns and the veth pair
+func (v *Veth) Close(ctx context.Context) error {
+	return v.router.Cleanup(ctx)
+}
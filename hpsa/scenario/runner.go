@@ -0,0 +1,123 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package scenario runs HPSA scenarios written as YAML steps, so a scenario needs no Go code.
+// The files are parsed and validated by package scenariofile.
+package scenario
+
+import (
+	"context"
+	"time"
+
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/bundles/cros/hpsa/scenariofile"
+	"chromiumos/tast/local/bundles/cros/hpsa/sign"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/browser"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/input"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+// DiagnosticTimeout is how long a run_diagnostic step may run when it sets no timeout
+const DiagnosticTimeout = 5 * time.Minute
+
+// Diagnostics are the diagnostics a run_diagnostic step can run, the component test has no run button
+var Diagnostics = []string{common.BatteryCheck, common.CheckCPU, common.CheckSystemMemory, common.CheckConnectivity, common.CheckStorage}
+
+// Env is what the steps act on
+type Env struct {
+	S             *testing.State
+	BrowserType   browser.Type
+	UI            *uiauto.Context
+	TConn         *chrome.TestConn
+	Browser       *browser.Browser
+	Path          string
+	DashboardPath string
+	ProfilePath   string
+	Locators      scenariofile.Locators
+}
+
+// Run runs the steps of the scenario in order and stops at the first failed step
+func (e *Env) Run(ctx context.Context, sc *scenariofile.Scenario) error {
+	kb, err := input.Keyboard(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to open the keyboard")
+	}
+	defer kb.Close()
+	for i, step := range sc.Steps {
+		testing.ContextLogf(ctx, "Step %d: %v", i+1, step)
+		if err := e.run(ctx, step, kb); err != nil {
+			return errors.Wrapf(err, "step %d %v failed", i+1, step)
+		}
+	}
+	return nil
+}
+
+func (e *Env) finder(name string) (*nodewith.Finder, string) {
+	l := e.Locators[name]
+	return nodewith.HasClass(l.Class).Nth(l.Nth), common.Locator(l.Class, l.Nth)
+}
+
+func (e *Env) run(ctx context.Context, step scenariofile.Step, kb *input.KeyboardEventWriter) error {
+	ui := e.UI.WithTimeout(step.TimeoutOr(scenariofile.DefaultTimeout))
+	switch step.Action() {
+	case "click":
+		finder, locator := e.finder(step.Click)
+		return common.TraceAction("click", step.Click, locator, uiauto.Combine("click "+step.Click,
+			ui.WaitUntilExists(finder),
+			e.UI.LeftClick(finder),
+		))(ctx)
+	case "wait_for":
+		finder, locator := e.finder(step.WaitFor)
+		return common.TraceAction("wait", step.WaitFor, locator, ui.WaitUntilExists(finder))(ctx)
+	case "wait_gone":
+		finder, locator := e.finder(step.WaitGone)
+		return common.TraceAction("wait", step.WaitGone, locator, ui.WaitUntilGone(finder))(ctx)
+	case "type":
+		finder, locator := e.finder(step.Type.Into)
+		return common.TraceAction("type", step.Type.Into, locator, uiauto.Combine("type into "+step.Type.Into,
+			ui.WaitUntilExists(finder),
+			e.UI.LeftClick(finder),
+			kb.TypeAction(step.Type.Text),
+		))(ctx)
+	case "screenshot":
+		_, err := common.TakeScreenshot(ctx, e.S, step.Screenshot, e.S.OutDir())
+		return err
+	case "assert_text":
+		return e.assertText(ctx, *step.AssertText, step.TimeoutOr(scenariofile.DefaultTimeout))
+	case "run_diagnostic":
+		_, err := common.RunDiagnostic(ctx, e.UI, step.Diagnostic, e.DashboardPath, step.TimeoutOr(DiagnosticTimeout))
+		return err
+	case "sign_in":
+		username, password, err := common.GetProfileJSON(step.SignIn, e.ProfilePath)
+		if err != nil {
+			return errors.Wrapf(err, "no account %q in the profile", step.SignIn)
+		}
+		_, err = sign.Signin(ctx, e.S, e.BrowserType, e.UI, e.TConn, e.Browser, e.Path, username, password)
+		return err
+	}
+	return errors.Errorf("unknown action %q", step.Action())
+}
+
+// assertText polls the text of the element until it matches, the page may still be loading it
+func (e *Env) assertText(ctx context.Context, a scenariofile.AssertStep, timeout time.Duration) (err error) {
+	finder, locator := e.finder(a.Locator)
+	step := common.StartStep(ctx, "assert", a.Locator, locator)
+	defer func() { step.Done(err) }()
+	return testing.Poll(ctx, func(ctx context.Context) error {
+		step.Attempt()
+		info, err := e.UI.Info(ctx, finder)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %v", a.Locator)
+		}
+		if !a.Match(info.Name) {
+			return errors.Errorf("%v is %q, want %v", a.Locator, info.Name, a)
+		}
+		return nil
+	}, &testing.PollOptions{Interval: time.Second, Timeout: timeout})
+}
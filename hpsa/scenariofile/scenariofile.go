@@ -0,0 +1,300 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package scenariofile parses and validates the YAML scenario files of package scenario.
+// It does not depend on Tast, so the files are checked by go test on the host.
+package scenariofile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// DefaultTimeout is how long a step may wait for its element when it sets no timeout
+const DefaultTimeout = time.Minute
+
+// Scenario is a YAML file of steps run in order after HPSA shows the dashboard
+type Scenario struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Steps       []Step `yaml:"steps"`
+}
+
+// Step does one thing. Exactly one of its actions is set, Timeout applies to the waits of the action.
+type Step struct {
+	Click      string      `yaml:"click"`
+	WaitFor    string      `yaml:"wait_for"`
+	WaitGone   string      `yaml:"wait_gone"`
+	Type       *TypeStep   `yaml:"type"`
+	Screenshot string      `yaml:"screenshot"`
+	AssertText *AssertStep `yaml:"assert_text"`
+	Diagnostic string      `yaml:"run_diagnostic"`
+	// SignIn is the id of the account in profile.json.
+	SignIn  string   `yaml:"sign_in"`
+	Timeout Duration `yaml:"timeout"`
+}
+
+// TypeStep clicks the text field and types the text
+type TypeStep struct {
+	Into string `yaml:"into"`
+	Text string `yaml:"text"`
+}
+
+// AssertStep checks the text of the element. Exactly one of Exact, Contains and Regex is set.
+type AssertStep struct {
+	Locator  string `yaml:"locator"`
+	Exact    string `yaml:"exact"`
+	Contains string `yaml:"contains"`
+	Regex    string `yaml:"regex"`
+}
+
+// Match reports whether the text of the element matches
+func (a AssertStep) Match(text string) bool {
+	text = strings.TrimSpace(text)
+	switch {
+	case a.Exact != "":
+		return text == a.Exact
+	case a.Contains != "":
+		return strings.Contains(strings.ToLower(text), strings.ToLower(a.Contains))
+	case a.Regex != "":
+		re, err := regexp.Compile(a.Regex)
+		return err == nil && re.MatchString(text)
+	}
+	return false
+}
+
+func (a AssertStep) String() string {
+	switch {
+	case a.Exact != "":
+		return fmt.Sprintf("exact %q", a.Exact)
+	case a.Contains != "":
+		return fmt.Sprintf("contains %q", a.Contains)
+	}
+	return fmt.Sprintf("regex %q", a.Regex)
+}
+
+// Duration is a time.Duration written like "30s" in YAML
+type Duration time.Duration
+
+// UnmarshalYAML parses the duration with time.ParseDuration
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if v <= 0 {
+		return fmt.Errorf("timeout %q is not positive", s)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Action is the kind of action a step does, e.g. "click"
+func (s Step) Action() string {
+	actions := s.actions()
+	if len(actions) != 1 {
+		return ""
+	}
+	return actions[0]
+}
+
+func (s Step) actions() []string {
+	var actions []string
+	for _, a := range []struct {
+		name string
+		set  bool
+	}{
+		{"click", s.Click != ""},
+		{"wait_for", s.WaitFor != ""},
+		{"wait_gone", s.WaitGone != ""},
+		{"type", s.Type != nil},
+		{"screenshot", s.Screenshot != ""},
+		{"assert_text", s.AssertText != nil},
+		{"run_diagnostic", s.Diagnostic != ""},
+		{"sign_in", s.SignIn != ""},
+	} {
+		if a.set {
+			actions = append(actions, a.name)
+		}
+	}
+	return actions
+}
+
+// Locator is the element the step acts on, empty for screenshot, run_diagnostic and sign_in
+func (s Step) Locator() string {
+	switch {
+	case s.Click != "":
+		return s.Click
+	case s.WaitFor != "":
+		return s.WaitFor
+	case s.WaitGone != "":
+		return s.WaitGone
+	case s.Type != nil:
+		return s.Type.Into
+	case s.AssertText != nil:
+		return s.AssertText.Locator
+	}
+	return ""
+}
+
+// TimeoutOr is the timeout of the step, or def when it sets none
+func (s Step) TimeoutOr(def time.Duration) time.Duration {
+	if s.Timeout == 0 {
+		return def
+	}
+	return time.Duration(s.Timeout)
+}
+
+func (s Step) String() string {
+	if l := s.Locator(); l != "" {
+		return fmt.Sprintf("%v %v", s.Action(), l)
+	}
+	for _, v := range []string{s.Screenshot, s.Diagnostic, s.SignIn} {
+		if v != "" {
+			return fmt.Sprintf("%v %v", s.Action(), v)
+		}
+	}
+	return s.Action()
+}
+
+// Locator is an element of the locator files, the class and the index among the elements of the class
+type Locator struct {
+	Class string
+	Nth   int
+}
+
+// Locators are the elements of hpsa.json and dashboard.json by name
+type Locators map[string]Locator
+
+// ReadLocators reads the locator files, a name in a later file replaces the one in an earlier file.
+// The files have one list of name, class and nth under any key, e.g. "welcome" or "dashboard".
+func ReadLocators(paths ...string) (Locators, error) {
+	locators := make(Locators)
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("can not read the locators from %q: %v", path, err)
+		}
+		var lists map[string][]struct {
+			Name  string `json:"name"`
+			Class string `json:"class"`
+			NTH   int    `json:"nth"`
+		}
+		if err := json.Unmarshal(data, &lists); err != nil {
+			return nil, fmt.Errorf("bad locators in %q: %v", path, err)
+		}
+		for _, list := range lists {
+			for _, l := range list {
+				locators[l.Name] = Locator{Class: l.Class, Nth: l.NTH}
+			}
+		}
+	}
+	return locators, nil
+}
+
+// Parse parses and validates a scenario against the locators and the diagnostics which can run
+func Parse(data []byte, locators Locators, diagnostics []string) (*Scenario, error) {
+	var sc Scenario
+	if err := yaml.UnmarshalStrict(data, &sc); err != nil {
+		return nil, fmt.Errorf("failed to parse the scenario: %v", err)
+	}
+	if err := sc.Validate(locators, diagnostics); err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// Read reads a scenario from the path
+func Read(path string, locators Locators, diagnostics []string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can not read the scenario from %q: %v", path, err)
+	}
+	sc, err := Parse(data, locators, diagnostics)
+	if err != nil {
+		return nil, fmt.Errorf("bad scenario %q: %v", path, err)
+	}
+	return sc, nil
+}
+
+// Validate checks the scenario has a name and steps, every step sets exactly one action,
+// and every locator and diagnostic it names exists. It returns the first problem.
+func (sc *Scenario) Validate(locators Locators, diagnostics []string) error {
+	if sc.Name == "" {
+		return errors.New("the scenario has no name")
+	}
+	if len(sc.Steps) == 0 {
+		return fmt.Errorf("scenario %q has no steps", sc.Name)
+	}
+	knownDiagnostics := make(map[string]bool)
+	for _, d := range diagnostics {
+		knownDiagnostics[d] = true
+	}
+	screenshots := make(map[string]bool)
+	for i, step := range sc.Steps {
+		where := fmt.Sprintf("scenario %q step %d", sc.Name, i+1)
+		switch actions := step.actions(); len(actions) {
+		case 0:
+			return fmt.Errorf("%v sets no action", where)
+		case 1:
+		default:
+			return fmt.Errorf("%v sets %v, only one action is allowed", where, strings.Join(actions, " and "))
+		}
+		if l := step.Locator(); l != "" {
+			if _, ok := locators[l]; !ok {
+				return fmt.Errorf("%v names locator %q which is in no locator file", where, l)
+			}
+		} else if step.Type != nil || step.AssertText != nil {
+			return fmt.Errorf("%v has no locator", where)
+		}
+		switch {
+		case step.Type != nil && step.Type.Text == "":
+			return fmt.Errorf("%v types no text", where)
+		case step.AssertText != nil:
+			if err := validateAssert(*step.AssertText); err != nil {
+				return fmt.Errorf("%v: %v", where, err)
+			}
+		case step.Diagnostic != "" && !knownDiagnostics[step.Diagnostic]:
+			return fmt.Errorf("%v runs unknown diagnostic %q", where, step.Diagnostic)
+		case step.Screenshot != "":
+			if !strings.HasSuffix(step.Screenshot, ".png") || strings.ContainsAny(step.Screenshot, `/\`) {
+				return fmt.Errorf("%v screenshot %q is not a png file name", where, step.Screenshot)
+			}
+			if screenshots[step.Screenshot] {
+				return fmt.Errorf("%v overwrites screenshot %q", where, step.Screenshot)
+			}
+			screenshots[step.Screenshot] = true
+		}
+	}
+	return nil
+}
+
+func validateAssert(a AssertStep) error {
+	set := 0
+	for _, v := range []string{a.Exact, a.Contains, a.Regex} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("assert_text must set exactly one of exact, contains and regex")
+	}
+	if a.Regex != "" {
+		if _, err := regexp.Compile(a.Regex); err != nil {
+			return fmt.Errorf("assert_text has a bad regex: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package scenariofile
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// diagnostics are the names of scenario.Diagnostics, package scenario needs Tast
+var diagnostics = []string{"BatteryCheck", "CheckCPU", "CheckSystemMemory", "CheckConnectivity", "CheckStorage"}
+
+func TestDataScenarios(t *testing.T) {
+	locators, err := ReadLocators(filepath.Join("..", "data", "hpsa.json"), filepath.Join("..", "data", "dashboard.json"))
+	if err != nil {
+		t.Fatal("Failed to read the locators: ", err)
+	}
+	paths, err := filepath.Glob(filepath.Join("..", "data", "scenarios", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("No scenario in data/scenarios")
+	}
+	for _, path := range paths {
+		sc, err := Read(path, locators, diagnostics)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if want := strings.TrimSuffix(filepath.Base(path), ".yaml"); sc.Name != want {
+			t.Errorf("%v is named %q, want %q like the file", path, sc.Name, want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	const good = `
+name: good
+description: every action once
+steps:
+  - click: Run
+  - wait_for: Run
+    timeout: 30s
+  - wait_gone: Run
+  - type: {into: Field, text: hello}
+  - screenshot: one.png
+  - assert_text: {locator: Field, contains: hello}
+  - run_diagnostic: CheckCPU
+  - sign_in: "1"
+`
+	locators := Locators{"Run": {Class: "btn", Nth: 0}, "Field": {Class: "input", Nth: 1}}
+	sc, err := Parse([]byte(good), locators, diagnostics)
+	if err != nil {
+		t.Fatal("Failed to parse the good scenario: ", err)
+	}
+	var actions []string
+	for _, step := range sc.Steps {
+		actions = append(actions, step.Action())
+	}
+	if got, want := strings.Join(actions, " "), "click wait_for wait_gone type screenshot assert_text run_diagnostic sign_in"; got != want {
+		t.Errorf("Actions are %q, want %q", got, want)
+	}
+	if got := sc.Steps[1].TimeoutOr(DefaultTimeout); got != 30*time.Second {
+		t.Errorf("Timeout of the wait_for step is %v, want 30s", got)
+	}
+	if got := sc.Steps[0].TimeoutOr(DefaultTimeout); got != DefaultTimeout {
+		t.Errorf("Timeout of the click step is %v, want %v", got, DefaultTimeout)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		steps string
+		want  string
+	}{
+		{"unknown field", "  - clik: Run", "failed to parse"},
+		{"bad timeout", "  - click: Run\n    timeout: soon", "failed to parse"},
+		{"negative timeout", "  - click: Run\n    timeout: -1s", "not positive"},
+		{"no steps", "", "has no steps"},
+		{"no action", "  - timeout: 1s", "sets no action"},
+		{"two actions", "  - click: Run\n    wait_for: Run", "only one action"},
+		{"unknown locator", "  - click: Missing", "in no locator file"},
+		{"type without locator", "  - type: {text: hello}", "has no locator"},
+		{"type without text", "  - type: {into: Field}", "types no text"},
+		{"assert without text", "  - assert_text: {locator: Field}", "exactly one of"},
+		{"assert with two texts", "  - assert_text: {locator: Field, exact: a, contains: a}", "exactly one of"},
+		{"assert with bad regex", "  - assert_text: {locator: Field, regex: '('}", "bad regex"},
+		{"unknown diagnostic", "  - run_diagnostic: ComponentTest", "unknown diagnostic"},
+		{"screenshot not png", "  - screenshot: one.jpg", "not a png file name"},
+		{"screenshot in a directory", "  - screenshot: a/one.png", "not a png file name"},
+		{"screenshot twice", "  - screenshot: one.png\n  - screenshot: one.png", "overwrites screenshot"},
+	} {
+		data := "name: bad\nsteps:\n" + tc.steps + "\n"
+		if _, err := Parse([]byte(data), locators, diagnostics); err == nil {
+			t.Errorf("%v: Parse succeeded, want an error with %q", tc.name, tc.want)
+		} else if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%v: Parse failed with %q, want %q", tc.name, err, tc.want)
+		}
+	}
+	if _, err := Parse([]byte("steps:\n  - click: Run\n"), locators, diagnostics); err == nil || !strings.Contains(err.Error(), "has no name") {
+		t.Errorf("Parse of a scenario without name failed with %v, want no name", err)
+	}
+}
+
+func TestAssertMatch(t *testing.T) {
+	for _, tc := range []struct {
+		assert AssertStep
+		text   string
+		want   bool
+	}{
+		{AssertStep{Exact: "Passed"}, " Passed ", true},
+		{AssertStep{Exact: "Passed"}, "passed", false},
+		{AssertStep{Contains: "pass"}, "Test Passed", true},
+		{AssertStep{Contains: "fail"}, "Test Passed", false},
+		{AssertStep{Regex: `^\d+ GB$`}, "16 GB", true},
+		{AssertStep{Regex: `^\d+ GB$`}, "16 MB", false},
+	} {
+		if got := tc.assert.Match(tc.text); got != tc.want {
+			t.Errorf("%v matches %q = %v, want %v", tc.assert, tc.text, got, tc.want)
+		}
+	}
+}
@@ -19,7 +19,10 @@ import (
 )
 
 // Signin is a function to send username and password for HPID
-func Signin(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, tconn *chrome.TestConn, br *browser.Browser, path, username, password string) (string, error) {
+func Signin(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, tconn *chrome.TestConn, br *browser.Browser, path, username, password string) (tips string, err error) {
+	step := common.StartStep(ctx, "signin", "Signin", "")
+	step.Attempt()
+	defer func() { step.Done(err) }()
 	var createAccountOrSignInclass, _, _ = common.GetJSON(common.CreateAccountOrSignIn, path)
 
 	s.Logf("Asserting that mouse click works on the %v button in %v browser", common.CreateAccountOrSignIn, bt)
@@ -60,7 +63,10 @@ func Signin(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.C
 }
 
 // Signout is the function for sign out in HPSA
-func Signout(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, tconn *chrome.TestConn, br *browser.Browser, path string) error {
+func Signout(ctx context.Context, s *testing.State, bt browser.Type, ui *uiauto.Context, tconn *chrome.TestConn, br *browser.Browser, path string) (err error) {
+	step := common.StartStep(ctx, "signout", "Signout", "")
+	step.Attempt()
+	defer func() { step.Done(err) }()
 	var profileclass, _, _ = common.GetJSON(common.Profile, path)
 	var profileElement = nodewith.HasClass(profileclass).First()
 	s.Logf("Asserting that mouse click works on the %v button in %v browser", common.Profile, bt)
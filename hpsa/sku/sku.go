@@ -0,0 +1,213 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package sku loads the per model expectations of the HPSA dashboard.
+// It only depends on the standard library so the database can be validated on any Linux host.
+package sku
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SchemaVersion is the version of the expectations format this package reads
+const SchemaVersion = 1
+
+// Diagnostics are the diagnostic names a model can list, they match the dashboard button names
+var Diagnostics = []string{"BatteryCheck", "CheckCPU", "CheckSystemMemory", "CheckConnectivity", "ComponentTest", "CheckStorage"}
+
+// skuPattern matches an HP product number as stored in VPD, e.g. 4J8B4UA-ABA
+var skuPattern = regexp.MustCompile(`^[0-9A-Z]{5,10}(-[0-9A-Z]{3})?$`)
+
+// Database is every model HPSA is tested on
+type Database struct {
+	Version int     `json:"version"`
+	Models  []Model `json:"models"`
+}
+
+// Model is what the dashboard should show on one HP Chromebook model
+type Model struct {
+	// ModelName is the VPD model_name.
+	ModelName string `json:"modelName"`
+	// SKUs are the VPD sku_number values of the model. A model without SKUs matches on its name only.
+	SKUs []string `json:"skus"`
+	// Specs are the expected spec values keyed by section title and key.
+	// A value wrapped in slashes, e.g. /^8 GB/, is a regular expression.
+	Specs map[string]map[string]string `json:"specs"`
+	// Diagnostics tells for every diagnostic whether the model offers it.
+	Diagnostics map[string]bool `json:"diagnostics"`
+}
+
+// UnknownError is returned by Lookup when the DUT is not in the database
+type UnknownError struct {
+	SKU       string
+	ModelName string
+	Known     []string
+}
+
+func (e *UnknownError) Error() string {
+	if e.SKU == "" {
+		return fmt.Sprintf("unknown SKU, VPD has no sku_number and model_name %q has no SKU-less expectations; known models: %v", e.ModelName, strings.Join(e.Known, ", "))
+	}
+	return fmt.Sprintf("unknown SKU, no expectations for sku_number %q model_name %q, add it to the expectations file; known models: %v", e.SKU, e.ModelName, strings.Join(e.Known, ", "))
+}
+
+// Parse parses and validates an expectations database
+func Parse(data []byte) (*Database, error) {
+	var db Database
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&db); err != nil {
+		return nil, fmt.Errorf("can not parse the expectations: %v", err)
+	}
+	if err := db.Validate(); err != nil {
+		return nil, err
+	}
+	return &db, nil
+}
+
+// Read reads and validates the expectations database at path
+func Read(path string) (*Database, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can not read the expectations from %q: %v", path, err)
+	}
+	return Parse(data)
+}
+
+// Validate checks every model is well formed and no SKU is listed twice, Parse also rejects unknown fields
+func (db *Database) Validate() error {
+	if db.Version != SchemaVersion {
+		return fmt.Errorf("expectations version is %d, want %d", db.Version, SchemaVersion)
+	}
+	if len(db.Models) == 0 {
+		return fmt.Errorf("the expectations have no models")
+	}
+	names := make(map[string]bool)
+	skus := make(map[string]string)
+	known := make(map[string]bool)
+	for _, d := range Diagnostics {
+		known[d] = true
+	}
+	for i, m := range db.Models {
+		if m.ModelName == "" {
+			return fmt.Errorf("model %d has no modelName", i)
+		}
+		if names[m.ModelName] {
+			return fmt.Errorf("model %q is listed twice", m.ModelName)
+		}
+		names[m.ModelName] = true
+		for _, s := range m.SKUs {
+			if !skuPattern.MatchString(s) {
+				return fmt.Errorf("model %q has a malformed sku %q", m.ModelName, s)
+			}
+			if other, ok := skus[s]; ok {
+				return fmt.Errorf("sku %q is listed by both %q and %q", s, other, m.ModelName)
+			}
+			skus[s] = m.ModelName
+		}
+		for section, entries := range m.Specs {
+			for key, value := range entries {
+				if _, err := compileExpected(value); err != nil {
+					return fmt.Errorf("model %q spec %v/%v: %v", m.ModelName, section, key, err)
+				}
+			}
+		}
+		for d := range m.Diagnostics {
+			if !known[d] {
+				return fmt.Errorf("model %q lists an unknown diagnostic %q", m.ModelName, d)
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup finds the model of the DUT, by SKU first and by model name for models without SKUs.
+// An empty skuNumber, e.g. from a DUT without it in VPD, matches no SKU.
+// It returns an *UnknownError when nothing matches.
+func (db *Database) Lookup(skuNumber, modelName string) (*Model, error) {
+	skuNumber, modelName = strings.TrimSpace(skuNumber), strings.TrimSpace(modelName)
+	for i, m := range db.Models {
+		for _, s := range m.SKUs {
+			if strings.EqualFold(s, skuNumber) {
+				return &db.Models[i], nil
+			}
+		}
+	}
+	for i, m := range db.Models {
+		if len(m.SKUs) == 0 && strings.EqualFold(m.ModelName, modelName) {
+			return &db.Models[i], nil
+		}
+	}
+	var known []string
+	for _, m := range db.Models {
+		known = append(known, m.ModelName)
+	}
+	sort.Strings(known)
+	return nil, &UnknownError{SKU: skuNumber, ModelName: modelName, Known: known}
+}
+
+// CheckSpecs compares the specs shown on the dashboard, keyed by section title and key, with the model
+func (m *Model) CheckSpecs(got map[string]map[string]string) []string {
+	var problems []string
+	var sections []string
+	for section := range m.Specs {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	for _, section := range sections {
+		entries, ok := got[section]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("section %v is not shown", section))
+			continue
+		}
+		var keys []string
+		for key := range m.Specs[section] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			want := m.Specs[section][key]
+			value, ok := entries[key]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%v/%v is not shown", section, key))
+				continue
+			}
+			re, _ := compileExpected(want)
+			if !re.MatchString(value) {
+				problems = append(problems, fmt.Sprintf("%v/%v is %q, want %v", section, key, value, want))
+			}
+		}
+	}
+	return problems
+}
+
+// CheckDiagnostics compares the diagnostics offered on the dashboard with the model
+func (m *Model) CheckDiagnostics(offered map[string]bool) []string {
+	var problems []string
+	var diagnostics []string
+	for d := range m.Diagnostics {
+		diagnostics = append(diagnostics, d)
+	}
+	sort.Strings(diagnostics)
+	for _, d := range diagnostics {
+		if want := m.Diagnostics[d]; offered[d] != want {
+			problems = append(problems, fmt.Sprintf("diagnostic %v offered is %v, want %v", d, offered[d], want))
+		}
+	}
+	return problems
+}
+
+// compileExpected turns an expected value in a regular expression, plain values have to match exactly
+func compileExpected(value string) (*regexp.Regexp, error) {
+	if len(value) >= 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+		return regexp.Compile(value[1 : len(value)-1])
+	}
+	return regexp.Compile("^" + regexp.QuoteMeta(value) + "$")
+}
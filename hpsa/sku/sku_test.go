@@ -0,0 +1,109 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package sku
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadData(t *testing.T) {
+	db, err := Read(filepath.Join("..", "data", "sku_expectations.json"))
+	if err != nil {
+		t.Fatal("Failed to read the expectations: ", err)
+	}
+	for _, m := range db.Models {
+		for _, s := range m.SKUs {
+			if got, err := db.Lookup(s, ""); err != nil || got.ModelName != m.ModelName {
+				t.Errorf("Lookup(%q) = %v, %v, want %q", s, got, err, m.ModelName)
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data string
+		want string
+	}{
+		{"good", `{"version": 1, "models": [{"modelName": "A", "skus": ["4J8B4UA-ABA"], "specs": {"Memory": {"Total": "/^8 GB$/"}}, "diagnostics": {"CheckCPU": true}}]}`, ""},
+		{"no json", `{`, "can not parse"},
+		{"unknown field", `{"version": 1, "models": [{"modelName": "A", "warranty": {}}]}`, "can not parse"},
+		{"wrong version", `{"version": 2, "models": [{"modelName": "A"}]}`, "version is 2"},
+		{"no models", `{"version": 1, "models": []}`, "no models"},
+		{"no model name", `{"version": 1, "models": [{"skus": ["4J8B4UA-ABA"]}]}`, "has no modelName"},
+		{"model twice", `{"version": 1, "models": [{"modelName": "A"}, {"modelName": "A"}]}`, "listed twice"},
+		{"malformed sku", `{"version": 1, "models": [{"modelName": "A", "skus": ["4j8b4ua"]}]}`, "malformed sku"},
+		{"sku twice", `{"version": 1, "models": [{"modelName": "A", "skus": ["4J8B4UA-ABA"]}, {"modelName": "B", "skus": ["4J8B4UA-ABA"]}]}`, "listed by both"},
+		{"bad spec pattern", `{"version": 1, "models": [{"modelName": "A", "specs": {"Memory": {"Total": "/(/"}}}]}`, "Memory/Total"},
+		{"unknown diagnostic", `{"version": 1, "models": [{"modelName": "A", "diagnostics": {"CheckGPU": true}}]}`, "unknown diagnostic"},
+	} {
+		_, err := Parse([]byte(tc.data))
+		switch {
+		case tc.want == "" && err != nil:
+			t.Errorf("%v: Parse failed: %v", tc.name, err)
+		case tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)):
+			t.Errorf("%v: Parse returned %v, want an error containing %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	db := &Database{Version: SchemaVersion, Models: []Model{
+		{ModelName: "HP Chromebook 14a", SKUs: []string{"3V3J5UA-ABA"}},
+		{ModelName: "HP Chromebox G3"},
+	}}
+	for _, tc := range []struct {
+		name      string
+		skuNumber string
+		modelName string
+		want      string
+	}{
+		{"sku", "3V3J5UA-ABA", "whatever", "HP Chromebook 14a"},
+		{"sku with newline and other case", "3v3j5ua-aba\n", "", "HP Chromebook 14a"},
+		{"model without skus", "", "HP Chromebox G3\n", "HP Chromebox G3"},
+		{"unknown sku of a model with skus", "3V3J5UA-ABB", "HP Chromebook 14a", ""},
+		{"no sku in vpd", "", "HP Chromebook 14a", ""},
+		{"nothing in vpd", "", "", ""},
+	} {
+		m, err := db.Lookup(tc.skuNumber, tc.modelName)
+		if tc.want != "" {
+			if err != nil || m.ModelName != tc.want {
+				t.Errorf("%v: Lookup returned %v, %v, want %q", tc.name, m, err, tc.want)
+			}
+			continue
+		}
+		var unknown *UnknownError
+		if !errors.As(err, &unknown) {
+			t.Errorf("%v: Lookup returned %v, %v, want an *UnknownError", tc.name, m, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), "unknown SKU") || !strings.Contains(err.Error(), "HP Chromebook 14a, HP Chromebox G3") {
+			t.Errorf("%v: error %q does not name the unknown SKU and the known models", tc.name, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	m := &Model{
+		Specs:       map[string]map[string]string{"Memory": {"Total": "/^(8|16) GB$/"}, "Storage": {"Type": "eMMC"}},
+		Diagnostics: map[string]bool{"BatteryCheck": false, "CheckCPU": true},
+	}
+	got := m.CheckSpecs(map[string]map[string]string{"Memory": {"Total": "4 GB"}})
+	want := []string{`Memory/Total is "4 GB", want /^(8|16) GB$/`, "section Storage is not shown"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("CheckSpecs returned %q, want %q", got, want)
+	}
+	if got := m.CheckSpecs(map[string]map[string]string{"Memory": {"Total": "16 GB"}, "Storage": {"Type": "eMMC"}}); len(got) != 0 {
+		t.Errorf("CheckSpecs returned %q for matching specs", got)
+	}
+	got = m.CheckDiagnostics(map[string]bool{"BatteryCheck": true, "CheckCPU": true})
+	if want := "diagnostic BatteryCheck offered is true, want false"; len(got) != 1 || got[0] != want {
+		t.Errorf("CheckDiagnostics returned %q, want %q", got, want)
+	}
+}
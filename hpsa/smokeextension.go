@@ -30,12 +30,13 @@ func init() {
 	testing.AddTest(&testing.Test{
 		Func:         Smokeextension,
 		LacrosStatus: testing.LacrosVariantExists,
-		Desc:         "POC for HPSA Tast",
+		Desc:         "Signs in from the dashboard, scrolls the specifications and signs out",
 		Contacts:     []string{"xinyang.li@hp.com"},
 		BugComponent: "",
 		Data:         []string{"hpsa.json", "profile.json"},
-		Attr:         []string{"group:mainline"},
+		Attr:         []string{"group:hpsa", "hpsa_smoke"},
 		SoftwareDeps: []string{"chrome"},
+		Timeout:      8 * time.Minute,
 	})
 }
 
@@ -62,6 +63,8 @@ func Smokeextension(ctx context.Context, s *testing.State) {
 	cleanupCtx := ctx
 	ctx, cancel := ctxutil.Shorten(ctx, 10*time.Second)
 	defer cancel()
+	ctx, writeTrace := common.TraceTest(ctx, s)
+	defer writeTrace()
 	br, closeBrowser, err := browserfixt.SetUp(ctx, cr, browser.TypeAsh)
 	if err != nil {
 		s.Fatal("Failed to set up browser: ", err)
@@ -107,9 +110,16 @@ func Smokeextension(ctx context.Context, s *testing.State) {
 		s.Fatalf("Failed to find and click the %v button in %v: %v", common.Specifications, bt, err)
 	}
 
+	scroller, err := common.NewScroller(ctx, tconn, ui, common.ScrollByWheel)
+	if err != nil {
+		s.Fatal("Failed to create the scroller: ", err)
+	}
+	defer scroller.Close(cleanupCtx)
 	networtElement := nodewith.HasClass(networkclass).Nth(networknth)
 	specificationsList := nodewith.HasClass(specificationsListclass).First()
-	common.ScrollToElement(ctx, s, ui, specificationsList, networtElement)
+	if err := common.ScrollToElement(ctx, scroller, specificationsList, networtElement); err != nil {
+		s.Fatalf("Failed to scroll to element  %v : %v ", common.Network, err)
+	}
 
 	var specificationsCloseclass, _, _ = common.GetJSON(common.SpecificationsClose, path)
 	if _, err := common.ClickDashboardBtns(ctx, s, bt, ui, common.SpecificationsClose, specificationsCloseclass); err != nil {
@@ -0,0 +1,113 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const (
+	// fillDir holds the fill files, it is removed at the start of every fill in case an earlier run could not clean up
+	fillDir = "hpsa_fill"
+	// fillChunk is the size of one fill file, smaller files keep a single fallocate short
+	fillChunk = 4 << 30
+)
+
+// Target is the level to fill to, either the share in use or the free space left
+type Target struct {
+	Name string
+	// Used is the share of the space in use from 0 to 1.
+	Used float64
+	// Free is the space left in bytes, it is used when Used is 0.
+	Free int64
+}
+
+// fillBytes is how much to allocate to reach the target from the usage, 0 when it is reached already
+func (t Target) fillBytes(u Usage) (int64, error) {
+	var free int64
+	switch {
+	case t.Used > 0 && t.Used < 1:
+		free = u.Total - int64(t.Used*float64(u.Total))
+	case t.Used == 0 && t.Free > 0:
+		free = t.Free
+	default:
+		return 0, fmt.Errorf("bad target %+v", t)
+	}
+	if u.Free <= free {
+		return 0, nil
+	}
+	return u.Free - free, nil
+}
+
+// Filler fills a filesystem with allocated files
+type Filler struct {
+	dir       string
+	allocated int64
+}
+
+// Fill allocates files in the directory until the filesystem reaches the target.
+// The returned Filler has to be cleaned up even when Fill fails, it may have allocated a part.
+func Fill(root string, t Target) (*Filler, error) {
+	f := &Filler{dir: filepath.Join(root, fillDir)}
+	if err := f.Cleanup(); err != nil {
+		return f, err
+	}
+	u, err := Statfs(root)
+	if err != nil {
+		return f, err
+	}
+	need, err := t.fillBytes(u)
+	if err != nil {
+		return f, err
+	}
+	if need == 0 {
+		return f, nil
+	}
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return f, err
+	}
+	for i := 0; need > 0; i++ {
+		size := int64(fillChunk)
+		if need < size {
+			size = need
+		}
+		if err := f.allocate(filepath.Join(f.dir, fmt.Sprintf("fill%03d", i)), size); err != nil {
+			return f, err
+		}
+		need -= size
+	}
+	return f, nil
+}
+
+func (f *Filler) allocate(path string, size int64) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// fallocate takes the blocks without writing them, statfs sees them at once.
+	if err := syscall.Fallocate(int(file.Fd()), 0, 0, size); err != nil {
+		return fmt.Errorf("failed to allocate %v for %v: %v", FormatSize(size), path, err)
+	}
+	f.allocated += size
+	return file.Close()
+}
+
+// Allocated is how much the fill files take
+func (f *Filler) Allocated() int64 {
+	return f.allocated
+}
+
+// Cleanup removes the fill files, it can be called more than once
+func (f *Filler) Cleanup() error {
+	if err := os.RemoveAll(f.dir); err != nil {
+		return fmt.Errorf("failed to remove the fill files: %v", err)
+	}
+	f.allocated = 0
+	return nil
+}
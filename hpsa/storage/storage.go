@@ -0,0 +1,171 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package storage fills the stateful partition to a chosen level and checks the storage check of HPSA against statfs.
+package storage
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	// StatefulPartition is the partition HPSA reports, the user data is on it
+	StatefulPartition = "/mnt/stateful_partition"
+	// LowDiskThreshold is the free space below which cryptohome starts its cleanup and the low disk notification is shown
+	LowDiskThreshold = 1 << 30
+)
+
+// Usage is the space of a filesystem in bytes
+type Usage struct {
+	Total int64 `json:"total"`
+	// Free is the space left for an unprivileged user, Reserved is the space left only for root on top of it.
+	Free     int64 `json:"free"`
+	Reserved int64 `json:"reserved"`
+}
+
+// Used is the share of the space in use from 0 to 1, as df shows it
+func (u Usage) Used() float64 {
+	if u.Total == 0 {
+		return 0
+	}
+	return float64(u.Total-u.Free) / float64(u.Total)
+}
+
+func (u Usage) String() string {
+	return fmt.Sprintf("%.1f%% used, %v free of %v", u.Used()*100, FormatSize(u.Free), FormatSize(u.Total))
+}
+
+// Statfs reads the usage of the filesystem path is on
+func Statfs(path string) (Usage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return Usage{}, fmt.Errorf("failed to statfs %v: %v", path, err)
+	}
+	bsize := int64(st.Bsize)
+	return Usage{
+		Total:    int64(st.Blocks) * bsize,
+		Free:     int64(st.Bavail) * bsize,
+		Reserved: int64(st.Bfree-st.Bavail) * bsize,
+	}, nil
+}
+
+// sizeUnits are the units HPSA may show, GB is decimal and GiB binary
+var sizeUnits = map[string]float64{
+	"B":   1,
+	"KB":  1e3,
+	"MB":  1e6,
+	"GB":  1e9,
+	"TB":  1e12,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+	"TIB": 1 << 40,
+}
+
+// sizeRE matches a number with its separators and the unit after it.
+// Groups of thousands are separated by a period, a comma, an apostrophe or a no-break space depending on the locale.
+var sizeRE = regexp.MustCompile(`(?i)([0-9](?:[0-9.,'\x{a0}\x{202f}]*[0-9])?)\s*([KMGT]?I?B)\b`)
+
+// ParseSize parses the first size in the text, e.g. "58.3 GB free" or "1.024,5 GB", into bytes
+func ParseSize(text string) (int64, error) {
+	m := sizeRE.FindStringSubmatch(text)
+	if m == nil {
+		return 0, fmt.Errorf("no size in %q", text)
+	}
+	v, err := parseNumber(m[1])
+	if err != nil {
+		return 0, fmt.Errorf("bad size in %q: %v", text, err)
+	}
+	unit, ok := sizeUnits[strings.ToUpper(m[2])]
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q in %q", m[2], text)
+	}
+	return int64(math.Round(v * unit)), nil
+}
+
+// separatorRE splits the integer part into its groups of thousands
+var separatorRE = regexp.MustCompile(`[.,]`)
+
+// parseNumber parses a number written with the separators of any locale.
+// When both a period and a comma are used, the last one is the decimal separator and the other one groups thousands.
+// A repeated separator groups thousands, so does a single one after one to three digits and before exactly three,
+// e.g. "1,024". Any other single separator is the decimal separator, HPSA shows at most two decimals.
+func parseNumber(s string) (float64, error) {
+	s = strings.NewReplacer("'", "", "\u00a0", "", "\u202f", "").Replace(s)
+	intPart, frac := s, ""
+	if i := strings.LastIndexAny(s, ".,"); i >= 0 {
+		sep, rest := s[i:i+1], s[:i]
+		grouping := strings.Contains(rest, sep) ||
+			!strings.ContainsAny(rest, ".,") && len(s)-i-1 == 3 && len(rest) <= 3 && rest[0] != '0'
+		if !grouping {
+			intPart, frac = rest, s[i+1:]
+		}
+	}
+	if strings.Contains(intPart, ".") && strings.Contains(intPart, ",") {
+		return 0, fmt.Errorf("%q groups thousands with both a period and a comma", s)
+	}
+	groups := separatorRE.Split(intPart, -1)
+	for i, g := range groups {
+		if g == "" || i > 0 && len(g) != 3 || len(groups) > 1 && len(g) > 3 {
+			return 0, fmt.Errorf("%q has a bad group of thousands %q", s, g)
+		}
+	}
+	digits := strings.Join(groups, "")
+	if frac != "" {
+		digits += "." + frac
+	}
+	return strconv.ParseFloat(digits, 64)
+}
+
+// FormatSize formats bytes in GiB or MiB for the logs
+func FormatSize(b int64) string {
+	if b >= 1<<30 || b <= -(1<<30) {
+		return fmt.Sprintf("%.2f GiB", float64(b)/(1<<30))
+	}
+	return fmt.Sprintf("%.1f MiB", float64(b)/(1<<20))
+}
+
+// Tolerance is how far the HPSA numbers may be off statfs.
+// HPSA rounds to one decimal and the usage changes while the check runs, so the larger of the two applies.
+type Tolerance struct {
+	Bytes   int64
+	Percent float64
+}
+
+// DefaultTolerance allows half a GB or 1% of the total
+var DefaultTolerance = Tolerance{Bytes: 500 * 1000 * 1000, Percent: 1}
+
+func (t Tolerance) of(total int64) int64 {
+	p := int64(float64(total) * t.Percent / 100)
+	if p > t.Bytes {
+		return p
+	}
+	return t.Bytes
+}
+
+// Compare compares the total and free space HPSA shows with statfs and returns every difference.
+// The free space may be the one of the user or include the reserved blocks, either matches.
+func Compare(total, free int64, want Usage, tol Tolerance) []string {
+	var problems []string
+	limit := tol.of(want.Total)
+	if d := total - want.Total; abs(d) > limit {
+		problems = append(problems, fmt.Sprintf("total is %v, statfs has %v, off by %v", FormatSize(total), FormatSize(want.Total), FormatSize(d)))
+	}
+	if abs(free-want.Free) > limit && abs(free-want.Free-want.Reserved) > limit {
+		problems = append(problems, fmt.Sprintf("free is %v, statfs has %v and %v with the reserved blocks", FormatSize(free), FormatSize(want.Free), FormatSize(want.Free+want.Reserved)))
+	}
+	return problems
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
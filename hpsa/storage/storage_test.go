@@ -0,0 +1,59 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package storage
+
+import (
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want int64
+	}{
+		{"58.3 GB free", 58300000000},
+		{"58,3 GB free", 58300000000},
+		{"Total: 128 GB", 128000000000},
+		{"512MB", 512000000},
+		{"1.5 GiB", 3 << 29},
+		{"0.5 TB", 500000000000},
+		{"0,500 TB", 500000000000},
+		{"12,34 GB", 12340000000},
+		{"1,024 GB", 1024000000000},
+		{"1.024 GB", 1024000000000},
+		{"1,024.5 GB", 1024500000000},
+		{"1.024,5 GB", 1024500000000},
+		{"1'024.5 GB", 1024500000000},
+		{"1\u00a0024,5 GB", 1024500000000},
+		{"1\u202f024,5 GB", 1024500000000},
+		{"1,234,567 KB", 1234567000},
+		{"1.234.567,89 KB", 1234567890},
+		{"1234.567 MB", 1234567000},
+		{"58.3 GB free of 1,024.5 GB", 58300000000},
+	} {
+		got, err := ParseSize(tc.text)
+		if err != nil {
+			t.Errorf("ParseSize(%q) failed: %v", tc.text, err)
+		} else if got != tc.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestParseSizeErrors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"no size here",
+		"58.3",
+		"1,2,3 GB",
+		"1,2345.6 GB",
+		"1.234,567.8 GB",
+		"12345,678.9 GB",
+	} {
+		if got, err := ParseSize(text); err == nil {
+			t.Errorf("ParseSize(%q) = %d, want an error", text, got)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package stress
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+const (
+	// memoryLoadMB is the memory stressapptest keeps copying
+	memoryLoadMB = 512
+	// cpuLoadMB is the memory of the CPU load, small so it does not add memory pressure
+	cpuLoadMB = 64
+	// pressureFreeMB is the available memory the pressure load leaves
+	pressureFreeMB = 300
+)
+
+// StartLoad starts the background load and returns the function which stops it
+func StartLoad(ctx context.Context, load Load, duration time.Duration) (func() error, error) {
+	switch load {
+	case LoadNone:
+		return func() error { return nil }, nil
+	case LoadCPU:
+		// -W copies with the CPU-heavy routines and -C adds a CPU stress thread per core.
+		return startStressapptest(ctx, cpuLoadMB, duration, "-W", "-C", fmt.Sprint(runtime.NumCPU()))
+	case LoadMemory:
+		return startStressapptest(ctx, memoryLoadMB, duration)
+	case LoadPressure:
+		info, err := common.ReadMemInfo()
+		if err != nil {
+			return nil, err
+		}
+		mb := int(info["MemAvailable"]>>20) - pressureFreeMB
+		if mb <= 0 {
+			return nil, errors.Errorf("only %d MB available, the system is under pressure already", info["MemAvailable"]>>20)
+		}
+		return startStressapptest(ctx, mb, duration)
+	}
+	return nil, errors.Errorf("unknown load %q", load)
+}
+
+// startStressapptest copies mb of memory until the duration is over or the returned function is called.
+// extra are more stressapptest flags.
+func startStressapptest(ctx context.Context, mb int, duration time.Duration, extra ...string) (func() error, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	// stressapptest stops by itself after the duration, the cancel stops it early.
+	args := append([]string{"-M", fmt.Sprint(mb), "-s", fmt.Sprint(int(duration.Seconds()))}, extra...)
+	cmd := exec.CommandContext(ctx, "stressapptest", args...)
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, errors.Wrap(err, "failed to start stressapptest")
+	}
+	testing.ContextLogf(ctx, "Started stressapptest %v", args)
+	return func() error {
+		cancel()
+		// The process is killed by the cancel, its exit status tells nothing.
+		cmd.Wait()
+		return nil
+	}, nil
+}
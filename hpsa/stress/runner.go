@@ -0,0 +1,182 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package stress
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/bundles/cros/hpsa/metrics"
+	"chromiumos/tast/local/chrome"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/screenshot"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+const (
+	// suspendDuration is how long the DUT sleeps in a suspend disruption
+	suspendDuration = 10 * time.Second
+	// offlineDuration is how long the HPSA page is offline in a network disruption
+	offlineDuration = 30 * time.Second
+	// recoverTimeout is how long HPSA may take to show the dashboard after a disruption
+	recoverTimeout = 2 * time.Minute
+)
+
+// Runner runs the stress config on an HPSA which shows the dashboard
+type Runner struct {
+	cfg           Config
+	tconn         *chrome.TestConn
+	ui            *uiauto.Context
+	appID         string
+	dashboardPath string
+	dashboard     *nodewith.Finder
+	outDir        string
+}
+
+// NewRunner checks the config and gets the dashboard locators
+func NewRunner(cfg Config, tconn *chrome.TestConn, ui *uiauto.Context, appID, dashboardPath, outDir string) (*Runner, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	dashboard, err := common.Finder(common.DeviceName, dashboardPath, "dashboard")
+	if err != nil {
+		return nil, err
+	}
+	return &Runner{cfg: cfg, tconn: tconn, ui: ui, appID: appID, dashboardPath: dashboardPath, dashboard: dashboard, outDir: outDir}, nil
+}
+
+// Run runs the iterations until the config ends them or ctx is done, and returns a record for every diagnostic run.
+// A failed run does not stop the test, HPSA is brought back to the dashboard and the next run starts.
+// The error is only set when HPSA can not be brought back.
+func (r *Runner) Run(ctx context.Context) ([]Record, error) {
+	start := time.Now()
+	stopLoad, err := StartLoad(ctx, r.cfg.Load, r.remaining(ctx, start))
+	if err != nil {
+		return nil, err
+	}
+	defer stopLoad()
+
+	var records []Record
+	var disruption Disruption
+	for iteration := 1; r.cfg.Iterations <= 0 || iteration <= r.cfg.Iterations; iteration++ {
+		if r.cfg.Duration > 0 && time.Since(start) >= r.cfg.Duration {
+			break
+		}
+		for _, d := range r.cfg.Diagnostics {
+			// Keep the time to bring HPSA back when the run hangs.
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < r.cfg.RunTimeout+recoverTimeout {
+				testing.ContextLog(ctx, "Not enough time left for another run")
+				return records, nil
+			}
+			record := r.runOnce(ctx, iteration, d)
+			record.Disruption = disruption
+			disruption = ""
+			records = append(records, record)
+			testing.ContextLogf(ctx, "Iteration %d %v: passed %v in %v %v", iteration, d, record.Passed, record.Duration.Round(time.Second), record.Error)
+			if !record.Passed {
+				if err := r.recover(ctx); err != nil {
+					return records, errors.Wrapf(err, "failed to bring HPSA back after iteration %d", iteration)
+				}
+			}
+		}
+		if disruption = r.cfg.Disruption(iteration); disruption != "" {
+			testing.ContextLogf(ctx, "Injecting %v after iteration %d", disruption, iteration)
+			if err := r.disrupt(ctx, disruption); err != nil {
+				return records, errors.Wrapf(err, "failed to inject %v", disruption)
+			}
+		}
+	}
+	return records, nil
+}
+
+// remaining is the time the load has to last
+func (r *Runner) remaining(ctx context.Context, start time.Time) time.Duration {
+	if r.cfg.Duration > 0 {
+		return r.cfg.Duration
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline)
+	}
+	return time.Duration(r.cfg.Iterations*len(r.cfg.Diagnostics)) * r.cfg.RunTimeout
+}
+
+// runOnce runs the diagnostic and records the result and any exception popup
+func (r *Runner) runOnce(ctx context.Context, iteration int, diagnostic string) Record {
+	record := Record{Iteration: iteration, Diagnostic: diagnostic, Start: time.Now()}
+	elapsed, err := common.RunDiagnostic(ctx, r.ui, diagnostic, r.dashboardPath, r.cfg.RunTimeout)
+	record.Duration = elapsed
+	record.Passed = err == nil
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if shown, err := common.ExceptionShown(ctx, r.ui, r.dashboardPath); err == nil && shown {
+		record.Passed = false
+		name := fmt.Sprintf("stress_exception_%03d_%v.png", iteration, diagnostic)
+		if err := screenshot.Capture(ctx, filepath.Join(r.outDir, name)); err != nil {
+			testing.ContextLog(ctx, "Failed to take the exception screenshot: ", err)
+		}
+		record.Exception = name
+		if err := common.DismissException(ctx, r.ui, r.dashboardPath); err != nil {
+			testing.ContextLog(ctx, "Failed to dismiss the exception popup: ", err)
+		}
+	}
+	return record
+}
+
+// recover brings HPSA back to the dashboard, by the back button first and by a restart when that fails
+func (r *Runner) recover(ctx context.Context) error {
+	if found, err := r.ui.IsNodeFound(ctx, r.dashboard); err == nil && found {
+		return nil
+	}
+	for _, d := range r.cfg.Diagnostics {
+		if err := common.CloseDiagnostic(ctx, r.ui, d, r.dashboardPath); err == nil {
+			if err := r.ui.WithTimeout(10 * time.Second).WaitUntilExists(r.dashboard)(ctx); err == nil {
+				return nil
+			}
+		}
+	}
+	return r.restart(ctx)
+}
+
+func (r *Runner) restart(ctx context.Context) error {
+	if err := metrics.Close(ctx, r.tconn, r.ui, r.appID, r.dashboard); err != nil {
+		return err
+	}
+	_, err := metrics.Launch(ctx, r.tconn, r.ui, r.appID, r.dashboard, recoverTimeout)
+	return err
+}
+
+// disrupt injects the disruption and waits for HPSA to show the dashboard again
+func (r *Runner) disrupt(ctx context.Context, d Disruption) error {
+	switch d {
+	case DisruptRestart:
+		return r.restart(ctx)
+	case DisruptSuspend:
+		if err := common.Suspend(ctx, suspendDuration); err != nil {
+			return err
+		}
+	case DisruptNetwork:
+		nc, err := common.NewNetworkController(ctx)
+		if err != nil {
+			return err
+		}
+		defer nc.Close(ctx)
+		if err := nc.Apply(ctx, common.NetworkOffline); err != nil {
+			return err
+		}
+		// GoBigSleepLint Keep HPSA offline long enough for its requests to fail
+		testing.Sleep(ctx, offlineDuration)
+		if err := nc.Reset(ctx); err != nil {
+			return err
+		}
+	}
+	return r.ui.WithTimeout(recoverTimeout).WaitUntilExists(r.dashboard)(ctx)
+}
@@ -0,0 +1,253 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package stress runs HPSA diagnostics back to back, optionally under load and with disruptions between the runs.
+package stress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// RecordsFile is every run of the stress test written to OutDir
+	RecordsFile = "stress_records.json"
+	// SummaryFile is the summary of the runs written to OutDir
+	SummaryFile = "stress_summary.json"
+)
+
+// Load is the background system load during the runs
+type Load string
+
+const (
+	// LoadNone runs the diagnostics on an idle system
+	LoadNone Load = ""
+	// LoadCPU keeps every core busy
+	LoadCPU Load = "cpu"
+	// LoadMemory keeps copying memory with stressapptest
+	LoadMemory Load = "memory"
+	// LoadPressure takes almost all the available memory with stressapptest, so the system is reclaiming and may kill tabs
+	LoadPressure Load = "pressure"
+)
+
+// Disruption is injected between two iterations
+type Disruption string
+
+const (
+	// DisruptRestart closes and launches HPSA
+	DisruptRestart Disruption = "restart"
+	// DisruptSuspend suspends and resumes the DUT
+	DisruptSuspend Disruption = "suspend"
+	// DisruptNetwork takes the HPSA page offline for a while
+	DisruptNetwork Disruption = "network"
+)
+
+// Config is what the stress test runs.
+// It stops after Iterations iterations or after Duration, whichever comes first, a zero value is no limit.
+type Config struct {
+	// Diagnostics are run in order in every iteration.
+	Diagnostics []string
+	Iterations  int
+	Duration    time.Duration
+	Load        Load
+	// Disruptions are injected in turn, one after every DisruptEvery iterations.
+	Disruptions  []Disruption
+	DisruptEvery int
+	// RunTimeout is how long one diagnostic run may take.
+	RunTimeout time.Duration
+}
+
+// Validate checks the config can run and ends
+func (c Config) Validate() error {
+	if len(c.Diagnostics) == 0 {
+		return fmt.Errorf("no diagnostics to run")
+	}
+	if c.Iterations <= 0 && c.Duration <= 0 {
+		return fmt.Errorf("neither iterations nor duration is set, the test would not end")
+	}
+	if c.RunTimeout <= 0 {
+		return fmt.Errorf("run timeout is not set")
+	}
+	switch c.Load {
+	case LoadNone, LoadCPU, LoadMemory, LoadPressure:
+	default:
+		return fmt.Errorf("unknown load %q", c.Load)
+	}
+	for _, d := range c.Disruptions {
+		switch d {
+		case DisruptRestart, DisruptSuspend, DisruptNetwork:
+		default:
+			return fmt.Errorf("unknown disruption %q", d)
+		}
+	}
+	if len(c.Disruptions) > 0 && c.DisruptEvery <= 0 {
+		return fmt.Errorf("disruptions are set but DisruptEvery is %d", c.DisruptEvery)
+	}
+	return nil
+}
+
+// Disruption returns the disruption to inject after the iteration, counted from 1, or "" for none
+func (c Config) Disruption(iteration int) Disruption {
+	if len(c.Disruptions) == 0 || iteration%c.DisruptEvery != 0 {
+		return ""
+	}
+	return c.Disruptions[(iteration/c.DisruptEvery-1)%len(c.Disruptions)]
+}
+
+// Record is one diagnostic run
+type Record struct {
+	Iteration  int           `json:"iteration"`
+	Diagnostic string        `json:"diagnostic"`
+	Start      time.Time     `json:"start"`
+	Duration   time.Duration `json:"durationNs"`
+	Passed     bool          `json:"passed"`
+	Error      string        `json:"error,omitempty"`
+	// Exception is the screenshot of the exception popup shown after the run, empty when none was shown.
+	Exception string `json:"exception,omitempty"`
+	// Disruption is the disruption injected before the run, empty when none was.
+	Disruption Disruption `json:"disruption,omitempty"`
+}
+
+// Durations are the percentiles of the run durations
+type Durations struct {
+	P50 time.Duration `json:"p50Ns"`
+	P90 time.Duration `json:"p90Ns"`
+	P99 time.Duration `json:"p99Ns"`
+	Max time.Duration `json:"maxNs"`
+}
+
+// Stats are the results of a group of runs
+type Stats struct {
+	Runs        int     `json:"runs"`
+	Failures    int     `json:"failures"`
+	Exceptions  int     `json:"exceptions"`
+	FailureRate float64 `json:"failureRate"`
+	// Durations are of the passed runs only, a failed run stops at the failure.
+	Durations Durations `json:"durations"`
+}
+
+// Summary is the summary of a stress run
+type Summary struct {
+	Config        Config           `json:"config"`
+	Iterations    int              `json:"iterations"`
+	Elapsed       time.Duration    `json:"elapsedNs"`
+	Total         Stats            `json:"total"`
+	ByDiagnostic  map[string]Stats `json:"byDiagnostic"`
+	ByDisruption  map[string]Stats `json:"byDisruption"`
+	FailedReasons map[string]int   `json:"failedReasons"`
+}
+
+// Summarize groups the records by diagnostic and by the disruption before the run
+func Summarize(cfg Config, records []Record, elapsed time.Duration) Summary {
+	s := Summary{
+		Config:        cfg,
+		Elapsed:       elapsed,
+		Total:         stats(records),
+		ByDiagnostic:  make(map[string]Stats),
+		ByDisruption:  make(map[string]Stats),
+		FailedReasons: make(map[string]int),
+	}
+	byDiagnostic := make(map[string][]Record)
+	byDisruption := make(map[string][]Record)
+	for _, r := range records {
+		if r.Iteration > s.Iterations {
+			s.Iterations = r.Iteration
+		}
+		byDiagnostic[r.Diagnostic] = append(byDiagnostic[r.Diagnostic], r)
+		disruption := string(r.Disruption)
+		if disruption == "" {
+			disruption = "none"
+		}
+		byDisruption[disruption] = append(byDisruption[disruption], r)
+		if !r.Passed {
+			s.FailedReasons[reason(r.Error)]++
+		}
+	}
+	for d, rs := range byDiagnostic {
+		s.ByDiagnostic[d] = stats(rs)
+	}
+	for d, rs := range byDisruption {
+		s.ByDisruption[d] = stats(rs)
+	}
+	return s
+}
+
+func stats(records []Record) Stats {
+	st := Stats{Runs: len(records)}
+	var durations []time.Duration
+	for _, r := range records {
+		if r.Exception != "" {
+			st.Exceptions++
+		}
+		if !r.Passed {
+			st.Failures++
+			continue
+		}
+		durations = append(durations, r.Duration)
+	}
+	if st.Runs > 0 {
+		st.FailureRate = float64(st.Failures) / float64(st.Runs)
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	st.Durations = Durations{
+		P50: percentile(durations, 50),
+		P90: percentile(durations, 90),
+		P99: percentile(durations, 99),
+	}
+	if len(durations) > 0 {
+		st.Durations.Max = durations[len(durations)-1]
+	}
+	return st
+}
+
+// percentile is the nearest rank percentile of the sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// reason is the first line of the error without the wrapped causes, so the same failure is counted once
+func reason(err string) string {
+	if err == "" {
+		return "exception"
+	}
+	err = strings.SplitN(err, "\n", 2)[0]
+	return strings.SplitN(err, ": ", 2)[0]
+}
+
+func (st Stats) String() string {
+	return fmt.Sprintf("%d runs, %d failed (%.1f%%), %d exceptions, p50 %v p90 %v p99 %v max %v",
+		st.Runs, st.Failures, st.FailureRate*100, st.Exceptions,
+		st.Durations.P50.Round(time.Second), st.Durations.P90.Round(time.Second), st.Durations.P99.Round(time.Second), st.Durations.Max.Round(time.Second))
+}
+
+// Write writes the records and the summary to the directory
+func Write(dir string, records []Record, summary Summary) error {
+	if records == nil {
+		records = []Record{}
+	}
+	b, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, RecordsFile), b, 0644); err != nil {
+		return err
+	}
+	if b, err = json.MarshalIndent(summary, "", "  "); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, SummaryFile), b, 0644)
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package stress
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	good := Config{Diagnostics: []string{"CheckCPU"}, Iterations: 10, RunTimeout: time.Minute}
+	for _, tc := range []struct {
+		name   string
+		modify func(c *Config)
+		want   string
+	}{
+		{"good", func(c *Config) {}, ""},
+		{"duration only", func(c *Config) { c.Iterations, c.Duration = 0, time.Hour }, ""},
+		{"disruptions", func(c *Config) { c.Disruptions, c.DisruptEvery = []Disruption{DisruptRestart, DisruptSuspend}, 2 }, ""},
+		{"no diagnostics", func(c *Config) { c.Diagnostics = nil }, "no diagnostics"},
+		{"no end", func(c *Config) { c.Iterations = 0 }, "would not end"},
+		{"no run timeout", func(c *Config) { c.RunTimeout = 0 }, "run timeout"},
+		{"unknown load", func(c *Config) { c.Load = "gpu" }, "unknown load"},
+		{"unknown disruption", func(c *Config) { c.Disruptions, c.DisruptEvery = []Disruption{"reboot"}, 1 }, "unknown disruption"},
+		{"disruptions without interval", func(c *Config) { c.Disruptions = []Disruption{DisruptNetwork} }, "DisruptEvery is 0"},
+	} {
+		c := good
+		tc.modify(&c)
+		err := c.Validate()
+		switch {
+		case tc.want == "" && err != nil:
+			t.Errorf("%v: Validate failed: %v", tc.name, err)
+		case tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)):
+			t.Errorf("%v: Validate returned %v, want an error containing %q", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestDisruption(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		// want is the disruption after the iterations 1, 2, ...
+		want []Disruption
+	}{
+		{"none", Config{}, []Disruption{"", "", ""}},
+		{"every iteration in turn", Config{Disruptions: []Disruption{DisruptRestart, DisruptSuspend, DisruptNetwork}, DisruptEvery: 1},
+			[]Disruption{DisruptRestart, DisruptSuspend, DisruptNetwork, DisruptRestart}},
+		{"every second iteration", Config{Disruptions: []Disruption{DisruptRestart, DisruptSuspend}, DisruptEvery: 2},
+			[]Disruption{"", DisruptRestart, "", DisruptSuspend, "", DisruptRestart}},
+		{"one disruption every third iteration", Config{Disruptions: []Disruption{DisruptNetwork}, DisruptEvery: 3},
+			[]Disruption{"", "", DisruptNetwork, "", "", DisruptNetwork}},
+	} {
+		for i, want := range tc.want {
+			if got := tc.cfg.Disruption(i + 1); got != want {
+				t.Errorf("%v: Disruption(%d) = %q, want %q", tc.name, i+1, got, want)
+			}
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var ten []time.Duration
+	for i := 1; i <= 10; i++ {
+		ten = append(ten, time.Duration(i)*time.Second)
+	}
+	for _, tc := range []struct {
+		name   string
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{"empty", nil, 50, 0},
+		{"one", []time.Duration{time.Second}, 99, time.Second},
+		{"p50 of ten", ten, 50, 5 * time.Second},
+		{"p90 of ten", ten, 90, 9 * time.Second},
+		{"p99 of ten", ten, 99, 10 * time.Second},
+		{"p0 is the first", ten, 0, time.Second},
+		{"p50 of three", ten[:3], 50, 2 * time.Second},
+	} {
+		if got := percentile(tc.sorted, tc.p); got != tc.want {
+			t.Errorf("%v: percentile(%v) = %v, want %v", tc.name, tc.p, got, tc.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	cfg := Config{Diagnostics: []string{"CheckCPU", "BatteryCheck"}, Iterations: 3, RunTimeout: time.Minute,
+		Disruptions: []Disruption{DisruptRestart}, DisruptEvery: 1}
+	records := []Record{
+		{Iteration: 1, Diagnostic: "CheckCPU", Duration: 30 * time.Second, Passed: true},
+		{Iteration: 1, Diagnostic: "BatteryCheck", Duration: 10 * time.Second, Passed: true},
+		{Iteration: 2, Diagnostic: "CheckCPU", Duration: 5 * time.Second, Error: "the run button was not enabled again: context deadline exceeded", Disruption: DisruptRestart},
+		{Iteration: 2, Diagnostic: "BatteryCheck", Duration: 12 * time.Second, Passed: true, Exception: "exception_2.png", Disruption: DisruptRestart},
+		{Iteration: 3, Diagnostic: "CheckCPU", Duration: 40 * time.Second, Passed: false, Exception: "exception_3.png", Disruption: DisruptRestart},
+		{Iteration: 3, Diagnostic: "BatteryCheck", Duration: 2 * time.Second, Error: "the run button was not enabled again: timeout\nstack", Disruption: DisruptRestart},
+	}
+	s := Summarize(cfg, records, time.Hour)
+	if s.Iterations != 3 || s.Elapsed != time.Hour {
+		t.Errorf("Summarize has %d iterations in %v, want 3 in 1h", s.Iterations, s.Elapsed)
+	}
+	for _, tc := range []struct {
+		name       string
+		got        Stats
+		runs       int
+		failures   int
+		exceptions int
+		durations  Durations
+	}{
+		{"total", s.Total, 6, 3, 2, Durations{P50: 12 * time.Second, P90: 30 * time.Second, P99: 30 * time.Second, Max: 30 * time.Second}},
+		{"CheckCPU", s.ByDiagnostic["CheckCPU"], 3, 2, 1, Durations{P50: 30 * time.Second, P90: 30 * time.Second, P99: 30 * time.Second, Max: 30 * time.Second}},
+		{"BatteryCheck", s.ByDiagnostic["BatteryCheck"], 3, 1, 1, Durations{P50: 10 * time.Second, P90: 12 * time.Second, P99: 12 * time.Second, Max: 12 * time.Second}},
+		{"no disruption", s.ByDisruption["none"], 2, 0, 0, Durations{P50: 10 * time.Second, P90: 30 * time.Second, P99: 30 * time.Second, Max: 30 * time.Second}},
+		{"after a restart", s.ByDisruption[string(DisruptRestart)], 4, 3, 2, Durations{P50: 12 * time.Second, P90: 12 * time.Second, P99: 12 * time.Second, Max: 12 * time.Second}},
+	} {
+		if tc.got.Runs != tc.runs || tc.got.Failures != tc.failures || tc.got.Exceptions != tc.exceptions {
+			t.Errorf("%v: %d runs, %d failures, %d exceptions, want %d, %d, %d", tc.name, tc.got.Runs, tc.got.Failures, tc.got.Exceptions, tc.runs, tc.failures, tc.exceptions)
+		}
+		if want := float64(tc.failures) / float64(tc.runs); math.Abs(tc.got.FailureRate-want) > 1e-9 {
+			t.Errorf("%v: failure rate is %v, want %v", tc.name, tc.got.FailureRate, want)
+		}
+		if tc.got.Durations != tc.durations {
+			t.Errorf("%v: durations are %+v, want %+v", tc.name, tc.got.Durations, tc.durations)
+		}
+	}
+	wantReasons := map[string]int{"the run button was not enabled again": 2, "exception": 1}
+	if len(s.FailedReasons) != len(wantReasons) {
+		t.Errorf("Failed reasons are %v, want %v", s.FailedReasons, wantReasons)
+	}
+	for r, n := range wantReasons {
+		if s.FailedReasons[r] != n {
+			t.Errorf("Failed reason %q is counted %d times, want %d", r, s.FailedReasons[r], n)
+		}
+	}
+	if empty := Summarize(cfg, nil, 0); empty.Total.Runs != 0 || empty.Total.FailureRate != 0 || empty.Iterations != 0 {
+		t.Errorf("Summarize of no records is %+v, want zero stats", empty.Total)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package va
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"go.chromium.org/tast/core/errors"
+)
+
+// Script is a YAML file of scripted Virtual Agent conversations
+type Script struct {
+	Conversations []Conversation `yaml:"conversations"`
+}
+
+// Conversation is one scripted dialog, started from a fresh Virtual Agent popup
+type Conversation struct {
+	Name  string `yaml:"name"`
+	Steps []Step `yaml:"steps"`
+}
+
+// Step sends a message or clicks a suggested reply chip, then checks the bot replies.
+// Exactly one of Send and Chip is set.
+type Step struct {
+	Send   string    `yaml:"send"`
+	Chip   string    `yaml:"chip"`
+	Expect []Matcher `yaml:"expect"`
+}
+
+// Matcher matches one bot reply. Exactly one of its fields is set.
+type Matcher struct {
+	Exact    string `yaml:"exact"`
+	Contains string `yaml:"contains"`
+	Regex    string `yaml:"regex"`
+}
+
+// Match reports whether the reply text matches
+func (m Matcher) Match(text string) bool {
+	text = strings.TrimSpace(text)
+	switch {
+	case m.Exact != "":
+		return text == m.Exact
+	case m.Contains != "":
+		return strings.Contains(strings.ToLower(text), strings.ToLower(m.Contains))
+	case m.Regex != "":
+		re, err := regexp.Compile(m.Regex)
+		return err == nil && re.MatchString(text)
+	}
+	return false
+}
+
+func (m Matcher) String() string {
+	switch {
+	case m.Exact != "":
+		return fmt.Sprintf("exact %q", m.Exact)
+	case m.Contains != "":
+		return fmt.Sprintf("contains %q", m.Contains)
+	}
+	return fmt.Sprintf("regex %q", m.Regex)
+}
+
+// ParseScript parses and validates a conversation script
+func ParseScript(data []byte) (*Script, error) {
+	var script Script
+	if err := yaml.UnmarshalStrict(data, &script); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the conversation script")
+	}
+	if err := script.Validate(); err != nil {
+		return nil, err
+	}
+	return &script, nil
+}
+
+// ReadScript reads a conversation script from the path
+func ReadScript(path string) (*Script, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "can not read the conversation script from : %q ", path)
+	}
+	return ParseScript(data)
+}
+
+// Validate checks every conversation has a unique name and well formed steps
+func (s *Script) Validate() error {
+	if len(s.Conversations) == 0 {
+		return errors.New("the script has no conversations")
+	}
+	names := make(map[string]bool)
+	for _, c := range s.Conversations {
+		if c.Name == "" {
+			return errors.New("a conversation has no name")
+		}
+		if names[c.Name] {
+			return errors.Errorf("conversation %q is defined twice", c.Name)
+		}
+		names[c.Name] = true
+		if len(c.Steps) == 0 {
+			return errors.Errorf("conversation %q has no steps", c.Name)
+		}
+		for i, step := range c.Steps {
+			if (step.Send == "") == (step.Chip == "") {
+				return errors.Errorf("conversation %q step %d must set exactly one of send and chip", c.Name, i)
+			}
+			for j, m := range step.Expect {
+				set := 0
+				for _, v := range []string{m.Exact, m.Contains, m.Regex} {
+					if v != "" {
+						set++
+					}
+				}
+				if set != 1 {
+					return errors.Errorf("conversation %q step %d matcher %d must set exactly one of exact, contains and regex", c.Name, i, j)
+				}
+				if m.Regex != "" {
+					if _, err := regexp.Compile(m.Regex); err != nil {
+						return errors.Wrapf(err, "conversation %q step %d matcher %d has a bad regex", c.Name, i, j)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Find returns the conversation with the name
+func (s *Script) Find(name string) (Conversation, bool) {
+	for _, c := range s.Conversations {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Conversation{}, false
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package va
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseScript(t *testing.T) {
+	const good = `
+conversations:
+  - name: greeting
+    steps:
+      - send: "Hello"
+        expect:
+          - contains: "help"
+  - name: battery
+    steps:
+      - send: "My battery drains fast"
+        expect:
+          - regex: "(?i)battery"
+      - chip: "Yes"
+        expect:
+          - exact: "Let's run a battery check."
+`
+	script, err := ParseScript([]byte(good))
+	if err != nil {
+		t.Fatal("ParseScript failed: ", err)
+	}
+	c, ok := script.Find("battery")
+	if !ok {
+		t.Fatal("Find(battery) found nothing")
+	}
+	if len(c.Steps) != 2 || c.Steps[1].Chip != "Yes" || c.Steps[1].Expect[0].Exact != "Let's run a battery check." {
+		t.Errorf("battery conversation is %+v", c)
+	}
+	if _, ok := script.Find("missing"); ok {
+		t.Error("Find(missing) found a conversation")
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		yaml string
+		// want is part of the error message
+		want string
+	}{
+		{"bad yaml", "conversations: [", "failed to parse"},
+		{"unknown field", "conversations:\n  - name: a\n    step: []\n", "failed to parse"},
+		{"no conversations", "conversations: []\n", "no conversations"},
+		{"no name", "conversations:\n  - steps:\n      - send: hi\n", "has no name"},
+		{"duplicate name", "conversations:\n  - name: a\n    steps:\n      - send: hi\n  - name: a\n    steps:\n      - send: hi\n", "defined twice"},
+		{"no steps", "conversations:\n  - name: a\n", "has no steps"},
+		{"send and chip", "conversations:\n  - name: a\n    steps:\n      - send: hi\n        chip: Yes\n", "exactly one of send and chip"},
+		{"neither send nor chip", "conversations:\n  - name: a\n    steps:\n      - expect:\n          - exact: hi\n", "exactly one of send and chip"},
+		{"empty matcher", "conversations:\n  - name: a\n    steps:\n      - send: hi\n        expect:\n          - {}\n", "exactly one of exact, contains and regex"},
+		{"two matchers", "conversations:\n  - name: a\n    steps:\n      - send: hi\n        expect:\n          - exact: a\n            contains: b\n", "exactly one of exact, contains and regex"},
+		{"bad regex", "conversations:\n  - name: a\n    steps:\n      - send: hi\n        expect:\n          - regex: \"(\"\n", "bad regex"},
+	} {
+		_, err := ParseScript([]byte(tc.yaml))
+		if err == nil {
+			t.Errorf("%v: ParseScript succeeded, want an error with %q", tc.name, tc.want)
+		} else if !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%v: ParseScript error is %q, want %q in it", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	for _, tc := range []struct {
+		m    Matcher
+		text string
+		want bool
+	}{
+		{Matcher{Exact: "Hi there"}, "  Hi there\n", true},
+		{Matcher{Exact: "Hi there"}, "hi there", false},
+		{Matcher{Contains: "warranty"}, "Your Warranty ends soon", true},
+		{Matcher{Contains: "warranty"}, "Your battery is fine", false},
+		{Matcher{Regex: `^\d+ days left$`}, "12 days left", true},
+		{Matcher{Regex: `^\d+ days left$`}, "many days left", false},
+		{Matcher{}, "anything", false},
+	} {
+		if got := tc.m.Match(tc.text); got != tc.want {
+			t.Errorf("%v.Match(%q) = %v, want %v", tc.m, tc.text, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package va drives the HPSA Virtual Agent chat popup
+package va
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"chromiumos/tast/local/bundles/cros/hpsa/common"
+	"chromiumos/tast/local/chrome/uiauto"
+	"chromiumos/tast/local/chrome/uiauto/nodewith"
+	"chromiumos/tast/local/chrome/uiauto/role"
+	"chromiumos/tast/local/coords"
+	"chromiumos/tast/local/input"
+
+	"go.chromium.org/tast/core/errors"
+	"go.chromium.org/tast/core/testing"
+)
+
+const (
+	// replyTimeout is how long the bot has to start answering
+	replyTimeout = time.Minute
+	// replySettle is how long the transcript has to stay unchanged before the reply is complete
+	replySettle = 3 * time.Second
+)
+
+// acceptName is the button of the terms shown on the first start, as the screenshot tests click it
+var acceptName = regexp.MustCompile(`(?i)^\s*I ACCEPT\s*$`)
+
+// The popup has no locator of its own, its controls are found by role and its messages by where they are.
+var (
+	acceptButton = nodewith.Role(role.Button).NameRegex(acceptName).First()
+	inputBox     = nodewith.Role(role.TextField).First()
+)
+
+// Speaker is who sent a message
+type Speaker string
+
+const (
+	// User is a message typed or picked by the test
+	User Speaker = "user"
+	// Bot is a reply of the Virtual Agent
+	Bot Speaker = "bot"
+)
+
+// Turn is one message of the transcript
+type Turn struct {
+	Speaker Speaker `json:"speaker"`
+	Text    string  `json:"text"`
+}
+
+// VirtualAgent is the driver of the Virtual Agent popup
+type VirtualAgent struct {
+	ui      *uiauto.Context
+	kb      *input.KeyboardEventWriter
+	classes map[string]string
+	// sent are the messages of the test, they tell the user bubbles from the bot ones
+	sent map[string]bool
+}
+
+// New creates the driver with the locators from the dashboard json
+func New(ctx context.Context, ui *uiauto.Context, dashboardPath string) (*VirtualAgent, error) {
+	classes := make(map[string]string)
+	for _, name := range []string{common.VirtualAgent, common.VirtualAgentDown, common.VirtualAgentUp, common.VirtualAgentClose} {
+		class, _, err := common.GetJSONDashboard(name, dashboardPath)
+		if err != nil || class == "" {
+			return nil, errors.Wrapf(err, "can not get the json data for %v", name)
+		}
+		classes[name] = class
+	}
+	kb, err := input.Keyboard(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the keyboard")
+	}
+	return &VirtualAgent{ui: ui, kb: kb, classes: classes, sent: make(map[string]bool)}, nil
+}
+
+// Release closes the keyboard of the driver
+func (v *VirtualAgent) Release(ctx context.Context) error {
+	return v.kb.Close(ctx)
+}
+
+func (v *VirtualAgent) finder(name string) *nodewith.Finder {
+	return nodewith.HasClass(v.classes[name])
+}
+
+func (v *VirtualAgent) click(name string) uiauto.Action {
+	return common.TraceAction("click", name, common.Locator(v.classes[name], 0), uiauto.Combine(fmt.Sprintf("click the %v button", name),
+		v.ui.WithTimeout(replyTimeout).WaitUntilExists(v.finder(name).First()),
+		v.ui.LeftClick(v.finder(name).First()),
+	))
+}
+
+// Open opens the popup from the dashboard and accepts the terms when they are shown
+func (v *VirtualAgent) Open(ctx context.Context) error {
+	if err := v.click(common.VirtualAgent)(ctx); err != nil {
+		return errors.Wrap(err, "failed to open the Virtual Agent")
+	}
+	// The terms are only shown on the first start, wait for whichever comes first.
+	if err := testing.Poll(ctx, func(ctx context.Context) error {
+		if found, err := v.ui.IsNodeFound(ctx, acceptButton); err == nil && found {
+			return v.ui.LeftClick(acceptButton)(ctx)
+		}
+		if found, err := v.ui.IsNodeFound(ctx, inputBox); err == nil && found {
+			return nil
+		}
+		return errors.New("the Virtual Agent is still loading")
+	}, &testing.PollOptions{Interval: 2 * time.Second, Timeout: 2 * time.Minute}); err != nil {
+		return errors.Wrap(err, "failed to wait for the Virtual Agent")
+	}
+	return v.ui.WithTimeout(replyTimeout).WaitUntilExists(inputBox)(ctx)
+}
+
+// Expand expands the popup
+func (v *VirtualAgent) Expand(ctx context.Context) error {
+	return v.click(common.VirtualAgentUp)(ctx)
+}
+
+// Collapse collapses the popup
+func (v *VirtualAgent) Collapse(ctx context.Context) error {
+	return v.click(common.VirtualAgentDown)(ctx)
+}
+
+// Close closes the popup
+func (v *VirtualAgent) Close(ctx context.Context) error {
+	return v.click(common.VirtualAgentClose)(ctx)
+}
+
+// Send types the message in the text field of the popup and sends it with Enter
+func (v *VirtualAgent) Send(ctx context.Context, message string) error {
+	v.sent[strings.TrimSpace(message)] = true
+	return common.TraceAction("type", "VirtualAgentMessage", string(role.TextField), uiauto.Combine(fmt.Sprintf("send %q", message),
+		v.ui.WithTimeout(replyTimeout).WaitUntilExists(inputBox),
+		v.ui.LeftClick(inputBox),
+		v.kb.TypeAction(message),
+		v.kb.AccelAction("Enter"),
+	))(ctx)
+}
+
+// ClickChip clicks the suggested reply chip with the text, chips are buttons named by their text
+func (v *VirtualAgent) ClickChip(ctx context.Context, text string) error {
+	v.sent[strings.TrimSpace(text)] = true
+	chip := nodewith.Role(role.Button).Name(text).First()
+	return uiauto.Combine(fmt.Sprintf("click the %q chip", text),
+		v.ui.WithTimeout(replyTimeout).WaitUntilExists(chip),
+		v.ui.LeftClick(chip),
+	)(ctx)
+}
+
+// textNode is a text of the page with where it is shown
+type textNode struct {
+	text string
+	rect coords.Rect
+}
+
+// Transcript reads every message of the conversation in display order.
+// Messages are the texts between the close button and the text field of the popup,
+// the ones the test sent are the user turns and the others the bot turns.
+func (v *VirtualAgent) Transcript(ctx context.Context) ([]Turn, error) {
+	closeInfo, err := v.ui.Info(ctx, v.finder(common.VirtualAgentClose).First())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the close button of the popup")
+	}
+	inputInfo, err := v.ui.Info(ctx, inputBox)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find the text field of the popup")
+	}
+	infos, err := v.ui.NodesInfo(ctx, nodewith.Role(role.StaticText))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the texts")
+	}
+	var texts []textNode
+	for _, info := range infos {
+		texts = append(texts, textNode{info.Name, info.Location})
+	}
+	region := coords.NewRect(inputInfo.Location.Left, closeInfo.Location.Bottom(),
+		closeInfo.Location.Right()-inputInfo.Location.Left, inputInfo.Location.Top-closeInfo.Location.Bottom())
+	return transcript(texts, region, v.sent), nil
+}
+
+// transcript keeps the texts centered in the region in display order, the sent ones are the user turns
+func transcript(texts []textNode, region coords.Rect, sent map[string]bool) []Turn {
+	var in []textNode
+	for _, t := range texts {
+		c := t.rect.CenterPoint()
+		if strings.TrimSpace(t.text) == "" || c.X < region.Left || c.X >= region.Right() || c.Y < region.Top || c.Y >= region.Bottom() {
+			continue
+		}
+		in = append(in, t)
+	}
+	sort.SliceStable(in, func(i, j int) bool { return in[i].rect.Top < in[j].rect.Top })
+	turns := make([]Turn, len(in))
+	for i, t := range in {
+		text := strings.TrimSpace(t.text)
+		turns[i] = Turn{Speaker: Bot, Text: text}
+		if sent[text] {
+			turns[i].Speaker = User
+		}
+	}
+	return turns
+}
+
+// WaitForReplies waits until the bot answers after the first known turns and stops typing.
+// It returns the new bot turns.
+func (v *VirtualAgent) WaitForReplies(ctx context.Context, known int) ([]Turn, error) {
+	var replies []Turn
+	if err := testing.Poll(ctx, func(ctx context.Context) error {
+		turns, err := v.Transcript(ctx)
+		if err != nil {
+			return testing.PollBreak(err)
+		}
+		replies = botTurns(after(turns, known))
+		if len(replies) == 0 {
+			return errors.New("the bot has not replied yet")
+		}
+		return nil
+	}, &testing.PollOptions{Interval: time.Second, Timeout: replyTimeout}); err != nil {
+		return nil, err
+	}
+	// Replies come in several bubbles, wait until no new one shows up.
+	for {
+		if err := testing.Sleep(ctx, replySettle); err != nil {
+			return replies, err
+		}
+		turns, err := v.Transcript(ctx)
+		if err != nil {
+			return replies, err
+		}
+		latest := botTurns(after(turns, known))
+		if len(latest) == len(replies) {
+			return replies, nil
+		}
+		replies = latest
+	}
+}
+
+// Run plays the conversation and checks every reply matcher.
+// Matchers of a step have to match the new replies in order, other replies may sit between them.
+func (v *VirtualAgent) Run(ctx context.Context, c Conversation) error {
+	for i, step := range c.Steps {
+		turns, err := v.Transcript(ctx)
+		if err != nil {
+			return err
+		}
+		if step.Send != "" {
+			err = v.Send(ctx, step.Send)
+		} else {
+			err = v.ClickChip(ctx, step.Chip)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "conversation %q step %d", c.Name, i)
+		}
+		replies, err := v.WaitForReplies(ctx, len(turns))
+		if err != nil {
+			return errors.Wrapf(err, "conversation %q step %d got no reply", c.Name, i)
+		}
+		if err := MatchReplies(replies, step.Expect); err != nil {
+			return errors.Wrapf(err, "conversation %q step %d", c.Name, i)
+		}
+	}
+	return nil
+}
+
+// MatchReplies checks the matchers match the replies in order
+func MatchReplies(replies []Turn, matchers []Matcher) error {
+	next := 0
+	for _, r := range replies {
+		if next < len(matchers) && matchers[next].Match(r.Text) {
+			next++
+		}
+	}
+	if next < len(matchers) {
+		var texts []string
+		for _, r := range replies {
+			texts = append(texts, r.Text)
+		}
+		return errors.Errorf("no reply matches %v, got %q", matchers[next], texts)
+	}
+	return nil
+}
+
+func botTurns(turns []Turn) []Turn {
+	var bot []Turn
+	for _, t := range turns {
+		if t.Speaker == Bot {
+			bot = append(bot, t)
+		}
+	}
+	return bot
+}
+
+// after returns the turns after the first known ones, none when the transcript got shorter
+func after(turns []Turn, known int) []Turn {
+	if known >= len(turns) {
+		return nil
+	}
+	return turns[known:]
+}
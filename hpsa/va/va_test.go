@@ -0,0 +1,76 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package va
+
+import (
+	"reflect"
+	"testing"
+
+	"chromiumos/tast/local/coords"
+)
+
+func TestMatchReplies(t *testing.T) {
+	replies := []Turn{
+		{Bot, "Hi, I am the Virtual Agent."},
+		{Bot, "How can I help?"},
+		{Bot, "You can also ask about your warranty."},
+	}
+	for _, tc := range []struct {
+		name     string
+		matchers []Matcher
+		ok       bool
+	}{
+		{"none", nil, true},
+		{"in order", []Matcher{{Contains: "virtual agent"}, {Contains: "warranty"}}, true},
+		{"replies in between", []Matcher{{Exact: "How can I help?"}}, true},
+		{"out of order", []Matcher{{Contains: "warranty"}, {Contains: "help"}}, false},
+		{"one reply for two matchers", []Matcher{{Contains: "help"}, {Contains: "help"}}, false},
+		{"no match", []Matcher{{Regex: "(?i)battery"}}, false},
+	} {
+		if err := MatchReplies(replies, tc.matchers); (err == nil) != tc.ok {
+			t.Errorf("%v: MatchReplies() = %v, want ok %v", tc.name, err, tc.ok)
+		}
+	}
+}
+
+func TestBotTurnsAfter(t *testing.T) {
+	turns := []Turn{{Bot, "Hi"}, {User, "Hello"}, {Bot, "How can I help?"}, {Bot, "Ask me anything."}}
+	for _, tc := range []struct {
+		known int
+		want  []Turn
+	}{
+		{0, []Turn{{Bot, "Hi"}, {Bot, "How can I help?"}, {Bot, "Ask me anything."}}},
+		{2, []Turn{{Bot, "How can I help?"}, {Bot, "Ask me anything."}}},
+		{4, nil},
+		// The popup may redraw with fewer bubbles than were counted.
+		{6, nil},
+	} {
+		if got := botTurns(after(turns, tc.known)); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("botTurns(after(turns, %d)) = %v, want %v", tc.known, got, tc.want)
+		}
+	}
+}
+
+func TestTranscript(t *testing.T) {
+	region := coords.NewRect(100, 100, 300, 400)
+	texts := []textNode{
+		{"Battery check", coords.NewRect(10, 150, 80, 20)},
+		{"How can I help?", coords.NewRect(110, 160, 200, 20)},
+		{"Hi, I am the Virtual Agent.", coords.NewRect(110, 120, 200, 20)},
+		{" check my battery ", coords.NewRect(250, 200, 140, 20)},
+		{"  ", coords.NewRect(110, 240, 200, 20)},
+		{"Your battery is fine.", coords.NewRect(110, 260, 200, 20)},
+		{"Type a message", coords.NewRect(110, 510, 200, 20)},
+	}
+	want := []Turn{
+		{Bot, "Hi, I am the Virtual Agent."},
+		{Bot, "How can I help?"},
+		{User, "check my battery"},
+		{Bot, "Your battery is fine."},
+	}
+	if got := transcript(texts, region, map[string]bool{"check my battery": true}); !reflect.DeepEqual(got, want) {
+		t.Errorf("transcript() = %v, want %v", got, want)
+	}
+}
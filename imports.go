@@ -57,8 +57,17 @@ import (
 	_ "chromiumos/tast/local/bundles/cros/holdingspace"
 	_ "chromiumos/tast/local/bundles/cros/hps"
 	_ "chromiumos/tast/local/bundles/cros/hpsa"
+	_ "chromiumos/tast/local/bundles/cros/hpsa/a11yaudit"
 	_ "chromiumos/tast/local/bundles/cros/hpsa/common"
+	_ "chromiumos/tast/local/bundles/cros/hpsa/component"
+	_ "chromiumos/tast/local/bundles/cros/hpsa/metrics"
+	_ "chromiumos/tast/local/bundles/cros/hpsa/netenv"
+	_ "chromiumos/tast/local/bundles/cros/hpsa/scenario"
 	_ "chromiumos/tast/local/bundles/cros/hpsa/sign"
+	_ "chromiumos/tast/local/bundles/cros/hpsa/sku"
+	_ "chromiumos/tast/local/bundles/cros/hpsa/storage"
+	_ "chromiumos/tast/local/bundles/cros/hpsa/stress"
+	_ "chromiumos/tast/local/bundles/cros/hpsa/va"
 	_ "chromiumos/tast/local/bundles/cros/hwsec"
 	_ "chromiumos/tast/local/bundles/cros/inputs"
 	_ "chromiumos/tast/local/bundles/cros/instanttether"
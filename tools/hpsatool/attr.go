@@ -0,0 +1,98 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// tastGroups are the Tast groups the hpsa tests may be in with their sub-attributes.
+// Tast rejects a test with a group it does not know, or with an attribute which is not a sub-attribute of one of its groups.
+// group:hpsa keeps the hpsa tests out of mainline, Tast has to list it with the same sub-attributes in its groups.
+var tastGroups = map[string][]string{
+	"mainline": {"informational"},
+	"hpsa":     {"hpsa_smoke", "hpsa_full", "hpsa_l10n"},
+	"crosbolt": {"crosbolt_perbuild", "crosbolt_nightly", "crosbolt_weekly"},
+	"stress":   nil,
+}
+
+// reservedPrefixes are the attributes Tast sets itself, a test may not declare them
+var reservedPrefixes = []string{"name:", "bundle:", "dep:"}
+
+const groupPrefix = "group:"
+
+// checkAttr checks one attribute is a known group or a sub-attribute of one
+func checkAttr(attr string) error {
+	for _, p := range reservedPrefixes {
+		if strings.HasPrefix(attr, p) {
+			return fmt.Errorf("attr %q is set by Tast", attr)
+		}
+	}
+	if strings.HasPrefix(attr, groupPrefix) {
+		if _, ok := tastGroups[strings.TrimPrefix(attr, groupPrefix)]; !ok {
+			return fmt.Errorf("unknown group %q, want one of %v", attr, groupNames())
+		}
+		return nil
+	}
+	if len(subAttrGroups(attr)) == 0 {
+		return fmt.Errorf("attr %q is no group and no sub-attribute of one", attr)
+	}
+	return nil
+}
+
+// checkAttrs checks the attributes of one test the way Tast does.
+// Every attribute is known and every sub-attribute comes with its group.
+func checkAttrs(attrs []string) []string {
+	var problems []string
+	groups := make(map[string]bool)
+	for _, a := range attrs {
+		if strings.HasPrefix(a, groupPrefix) {
+			groups[a] = true
+		}
+	}
+	if len(groups) == 0 {
+		problems = append(problems, fmt.Sprintf("no group in %v, the test would never run", attrs))
+	}
+	for _, a := range attrs {
+		if err := checkAttr(a); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		if strings.HasPrefix(a, groupPrefix) {
+			continue
+		}
+		in := false
+		for _, g := range subAttrGroups(a) {
+			in = in || groups[g]
+		}
+		if !in {
+			problems = append(problems, fmt.Sprintf("attr %q needs one of the groups %v", a, subAttrGroups(a)))
+		}
+	}
+	return problems
+}
+
+// subAttrGroups returns the groups which have the sub-attribute
+func subAttrGroups(attr string) []string {
+	var groups []string
+	for g, subs := range tastGroups {
+		if contains(subs, attr) {
+			groups = append(groups, groupPrefix+g)
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+func groupNames() []string {
+	var names []string
+	for g := range tastGroups {
+		names = append(names, groupPrefix+g)
+	}
+	sort.Strings(names)
+	return names
+}
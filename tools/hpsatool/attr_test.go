@@ -0,0 +1,39 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+)
+
+func TestCheckAttrs(t *testing.T) {
+	for _, tc := range []struct {
+		attrs []string
+		ok    bool
+	}{
+		{[]string{"group:hpsa"}, true},
+		{[]string{"group:hpsa", "hpsa_smoke"}, true},
+		{[]string{"group:hpsa", "hpsa_full", "hpsa_l10n"}, true},
+		{[]string{"group:mainline", "informational"}, true},
+		{[]string{"group:crosbolt", "crosbolt_nightly"}, true},
+		{[]string{"group:stress"}, true},
+		{[]string{"group:hpsa", "group:stress"}, true},
+		{nil, false},
+		{[]string{"hpsa_smoke"}, false},
+		{[]string{"group:mainline", "hpsa_smoke"}, false},
+		{[]string{"group:hpsa", "informational"}, false},
+		{[]string{"group:hpsa", "hpsa_nightly"}, false},
+		{[]string{"group:stress", "hpsa_full"}, false},
+		{[]string{"group:hpsa", "crosbolt_nightly"}, false},
+		{[]string{"group:hpsa", "name:hpsa.Common"}, false},
+		{[]string{"group:hpsa", "bundle:cros"}, false},
+		{[]string{"group:hpsa", "dep:chrome"}, false},
+		{[]string{"group:unknown"}, false},
+	} {
+		if problems := checkAttrs(tc.attrs); (len(problems) == 0) != tc.ok {
+			t.Errorf("checkAttrs(%q) = %q, want ok %v", tc.attrs, problems, tc.ok)
+		}
+	}
+}
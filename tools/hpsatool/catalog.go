@@ -0,0 +1,130 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+)
+
+// Catalog is hpsa/catalog.json, the metadata every hpsa test declares.
+// Areas, Environments and Attributes are the allowed values with what they mean.
+type Catalog struct {
+	Areas        map[string]string `json:"areas"`
+	Environments map[string]string `json:"environments"`
+	Attributes   map[string]string `json:"attributes"`
+	Tests        []CatalogTest     `json:"tests"`
+}
+
+// CatalogTest is the metadata of one test, Name is the Func of its registration
+type CatalogTest struct {
+	Name  string `json:"name"`
+	Desc  string `json:"desc"`
+	Owner string `json:"owner"`
+	Area  string `json:"area"`
+	// Env is what the device or the lab needs for the test to run.
+	Env []string `json:"env"`
+	// Duration is how long one run of the test, or of one of its parameters, is expected to take.
+	Duration string   `json:"duration"`
+	Attr     []string `json:"attr"`
+}
+
+// ReadCatalog reads the catalog from the path
+func ReadCatalog(path string) (*Catalog, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Catalog
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("bad catalog %q: %v", path, err)
+	}
+	return &c, nil
+}
+
+// Test returns the test with the name, nil when the catalog has none
+func (c *Catalog) Test(name string) *CatalogTest {
+	for i := range c.Tests {
+		if c.Tests[i].Name == name {
+			return &c.Tests[i]
+		}
+	}
+	return nil
+}
+
+// ExpectedDuration parses Duration, e.g. "2h30m"
+func (t *CatalogTest) ExpectedDuration() (time.Duration, error) {
+	d, err := time.ParseDuration(t.Duration)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration %q is not positive", t.Duration)
+	}
+	return d, nil
+}
+
+// Validate checks every test is listed once and only uses the declared areas, environments and attributes,
+// and that Tast accepts the attributes
+func (c *Catalog) Validate() []string {
+	var problems []string
+	seen := make(map[string]bool)
+	for _, t := range c.Tests {
+		add := func(format string, args ...interface{}) {
+			problems = append(problems, fmt.Sprintf("catalog: %v: ", t.Name)+fmt.Sprintf(format, args...))
+		}
+		if t.Name == "" {
+			problems = append(problems, "catalog: a test has no name")
+			continue
+		}
+		if seen[t.Name] {
+			add("listed twice")
+		}
+		seen[t.Name] = true
+		if t.Desc == "" {
+			add("no desc")
+		}
+		if t.Owner == "" {
+			add("no owner")
+		}
+		if _, ok := c.Areas[t.Area]; !ok {
+			add("unknown area %q, want one of %v", t.Area, keys(c.Areas))
+		}
+		for _, e := range t.Env {
+			if _, ok := c.Environments[e]; !ok {
+				add("unknown env %q, want one of %v", e, keys(c.Environments))
+			}
+		}
+		if _, err := t.ExpectedDuration(); err != nil {
+			add("bad duration: %v", err)
+		}
+		for _, p := range checkAttrs(t.Attr) {
+			add("%v", p)
+		}
+		for _, a := range t.Attr {
+			if _, ok := c.Attributes[a]; !ok {
+				add("unknown attr %q, want one of %v", a, keys(c.Attributes))
+			}
+		}
+	}
+	for _, a := range keys(c.Attributes) {
+		if err := checkAttr(a); err != nil {
+			problems = append(problems, fmt.Sprintf("catalog: attributes: %v", err))
+		}
+	}
+	return problems
+}
+
+func keys(m map[string]string) []string {
+	var ks []string
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Check compares the catalog with the registrations of the bundle.
+// Every registration has a catalog entry and the other way round, the Desc and Attr are the ones of the catalog,
+// the owner is a contact and every timeout leaves room for the expected duration.
+// Tast has to accept the attributes of every parameter, the ones of the test with its ExtraAttr.
+func Check(c *Catalog, regs []Registration) []string {
+	problems := c.Validate()
+	registered := make(map[string]bool)
+	for _, r := range regs {
+		add := func(format string, args ...interface{}) {
+			problems = append(problems, fmt.Sprintf("%v: %v: ", r.Pos, r.Func)+fmt.Sprintf(format, args...))
+		}
+		for _, f := range r.Unreadable {
+			add("%v is not a literal, the checker can not read it", f)
+		}
+		if r.Func == "" {
+			continue
+		}
+		registered[r.Func] = true
+		t := c.Test(r.Func)
+		if t == nil {
+			add("not in the catalog")
+			continue
+		}
+		if r.Desc != t.Desc {
+			add("Desc is %q, the catalog has %q", r.Desc, t.Desc)
+		}
+		if !contains(r.Contacts, t.Owner) {
+			add("the owner %v is not in Contacts %v", t.Owner, r.Contacts)
+		}
+		if len(r.Params) == 0 {
+			for _, p := range checkAttrs(r.Attr) {
+				add("%v", p)
+			}
+		}
+		for _, param := range r.Params {
+			for _, p := range checkAttrs(r.ParamAttr(param)) {
+				add("%v: %v", param.Name, p)
+			}
+			for _, a := range param.ExtraAttr {
+				if _, ok := c.Attributes[a]; !ok {
+					add("%v: ExtraAttr %q is not in the catalog attributes", param.Name, a)
+				}
+			}
+		}
+		if !sameSet(r.Attr, t.Attr) {
+			add("Attr is %v, the catalog has %v", r.Attr, t.Attr)
+		}
+		want, err := t.ExpectedDuration()
+		if err != nil {
+			continue
+		}
+		if len(r.Params) == 0 && r.Timeout < want {
+			add("Timeout %v is shorter than the expected duration %v", r.Timeout, want)
+		}
+		for _, p := range r.Params {
+			timeout := r.Timeout
+			if p.Timeout != 0 {
+				timeout = p.Timeout
+			}
+			if timeout < want {
+				add("Timeout %v of %v is shorter than the expected duration %v", timeout, p.Name, want)
+			}
+		}
+	}
+	for _, t := range c.Tests {
+		if t.Name != "" && !registered[t.Name] {
+			problems = append(problems, fmt.Sprintf("catalog: %v: no testing.AddTest registers it", t.Name))
+		}
+	}
+	return problems
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sameSet(a, b []string) bool {
+	sorted := func(ss []string) string {
+		ss = append([]string(nil), ss...)
+		sort.Strings(ss)
+		return strings.Join(ss, "\x00")
+	}
+	return sorted(a) == sorted(b)
+}
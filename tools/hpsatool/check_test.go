@@ -0,0 +1,114 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCatalog is a valid catalog with one test
+func testCatalog() *Catalog {
+	return &Catalog{
+		Areas:        map[string]string{"dashboard": ""},
+		Environments: map[string]string{"hp_proxy": ""},
+		Attributes:   map[string]string{"group:hpsa": "", "hpsa_smoke": "", "hpsa_full": ""},
+		Tests: []CatalogTest{{
+			Name: "Hpsa01walkthrough", Desc: "Walks through", Owner: "owner@hp.com", Area: "dashboard",
+			Env: []string{"hp_proxy"}, Duration: "5m", Attr: []string{"group:hpsa", "hpsa_smoke"},
+		}},
+	}
+}
+
+// testRegistration matches testCatalog
+func testRegistration() Registration {
+	return Registration{
+		Pos: "hpsa01walkthrough.go:30", Func: "Hpsa01walkthrough", Desc: "Walks through",
+		Contacts: []string{"owner@hp.com"}, Attr: []string{"group:hpsa", "hpsa_smoke"}, Timeout: 10 * time.Minute,
+	}
+}
+
+// checkProblems compares the problems with the wanted ones, a wanted problem is a part of one
+func checkProblems(t *testing.T, name string, problems, want []string) {
+	t.Helper()
+	if len(problems) != len(want) {
+		t.Errorf("%v: got problems %q, want %d containing %q", name, problems, len(want), want)
+		return
+	}
+	for i, w := range want {
+		if !strings.Contains(problems[i], w) {
+			t.Errorf("%v: problem %q does not contain %q", name, problems[i], w)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		modify func(c *Catalog)
+		want   []string
+	}{
+		{"valid", func(c *Catalog) {}, nil},
+		{"no name", func(c *Catalog) { c.Tests[0].Name = "" }, []string{"a test has no name"}},
+		{"listed twice", func(c *Catalog) { c.Tests = append(c.Tests, c.Tests[0]) }, []string{"listed twice"}},
+		{"no desc and owner", func(c *Catalog) { c.Tests[0].Desc, c.Tests[0].Owner = "", "" }, []string{"no desc", "no owner"}},
+		{"unknown area", func(c *Catalog) { c.Tests[0].Area = "settings" }, []string{`unknown area "settings"`}},
+		{"unknown env", func(c *Catalog) { c.Tests[0].Env = []string{"wifi"} }, []string{`unknown env "wifi"`}},
+		{"bad duration", func(c *Catalog) { c.Tests[0].Duration = "soon" }, []string{"bad duration"}},
+		{"zero duration", func(c *Catalog) { c.Tests[0].Duration = "0s" }, []string{"not positive"}},
+		{"undeclared attr", func(c *Catalog) { c.Tests[0].Attr = []string{"group:hpsa", "hpsa_l10n"} },
+			[]string{`unknown attr "hpsa_l10n"`}},
+		{"attr Tast rejects", func(c *Catalog) {
+			c.Attributes["hpsa_extra"] = ""
+			c.Tests[0].Attr = []string{"group:hpsa", "hpsa_extra"}
+		}, []string{"no group and no sub-attribute", "attributes: attr \"hpsa_extra\""}},
+		{"sub-attribute without group", func(c *Catalog) { c.Tests[0].Attr = []string{"hpsa_smoke"} },
+			[]string{"no group", "needs one of the groups [group:hpsa]"}},
+	} {
+		c := testCatalog()
+		tc.modify(c)
+		checkProblems(t, tc.name, c.Validate(), tc.want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		modify func(c *Catalog, r *Registration)
+		want   []string
+	}{
+		{"match", func(c *Catalog, r *Registration) {}, nil},
+		{"unreadable", func(c *Catalog, r *Registration) { r.Unreadable = []string{"Desc"} }, []string{"Desc is not a literal"}},
+		{"not in the catalog", func(c *Catalog, r *Registration) { r.Func = "Hpsa99new" },
+			[]string{"Hpsa99new: not in the catalog", "Hpsa01walkthrough: no testing.AddTest registers it"}},
+		{"desc", func(c *Catalog, r *Registration) { r.Desc = "Walks" }, []string{`Desc is "Walks"`}},
+		{"owner", func(c *Catalog, r *Registration) { r.Contacts = []string{"other@hp.com"} }, []string{"the owner owner@hp.com is not in Contacts"}},
+		{"attr differs", func(c *Catalog, r *Registration) { r.Attr = []string{"group:hpsa", "hpsa_full"} }, []string{"Attr is [group:hpsa hpsa_full]"}},
+		{"attr order", func(c *Catalog, r *Registration) { r.Attr = []string{"hpsa_smoke", "group:hpsa"} }, nil},
+		{"mainline", func(c *Catalog, r *Registration) { r.Attr = []string{"group:mainline", "hpsa_smoke"} },
+			[]string{"needs one of the groups [group:hpsa]", "Attr is [group:mainline hpsa_smoke]"}},
+		{"timeout", func(c *Catalog, r *Registration) { r.Timeout = time.Minute }, []string{"Timeout 1m0s is shorter"}},
+		{"param timeout", func(c *Catalog, r *Registration) {
+			r.Params = []Param{{Name: "fast", Timeout: time.Minute}, {Name: "slow"}}
+		}, []string{"Timeout 1m0s of fast is shorter"}},
+		{"extra attr", func(c *Catalog, r *Registration) {
+			r.Attr = []string{"group:hpsa"}
+			c.Tests[0].Attr = r.Attr
+			r.Params = []Param{{Name: "smoke", ExtraAttr: []string{"hpsa_smoke"}}, {Name: "full", ExtraAttr: []string{"hpsa_full"}}}
+		}, nil},
+		{"extra attr Tast rejects", func(c *Catalog, r *Registration) {
+			r.Params = []Param{{Name: "perf", ExtraAttr: []string{"crosbolt_nightly"}}}
+		}, []string{"perf: attr \"crosbolt_nightly\" needs one of the groups [group:crosbolt]", "perf: ExtraAttr \"crosbolt_nightly\" is not in the catalog"}},
+		{"extra attr undeclared", func(c *Catalog, r *Registration) {
+			r.Params = []Param{{Name: "l10n", ExtraAttr: []string{"hpsa_l10n"}}}
+		}, []string{"l10n: ExtraAttr \"hpsa_l10n\" is not in the catalog"}},
+	} {
+		c := testCatalog()
+		r := testRegistration()
+		tc.modify(c, &r)
+		checkProblems(t, tc.name, Check(c, []Registration{r}), tc.want)
+	}
+}
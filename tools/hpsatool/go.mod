@@ -0,0 +1,3 @@
+module hpsatool
+
+go 1.17
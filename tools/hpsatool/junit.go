@@ -0,0 +1,227 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// junitFile is the JUnit XML the junit command writes
+const junitFile = "hpsa_junit.xml"
+
+// noArea is the suite of the tests which are not in the catalog
+const noArea = "uncataloged"
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Errors    int              `xml:"errors,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      string           `xml:"time,attr"`
+	Timestamp string           `xml:"timestamp,attr,omitempty"`
+	Props     *junitProperties `xml:"properties"`
+	Cases     []junitCase      `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string           `xml:"name,attr"`
+	Classname string           `xml:"classname,attr"`
+	Time      string           `xml:"time,attr"`
+	Props     *junitProperties `xml:"properties"`
+	Failure   *junitFailure    `xml:"failure"`
+	Error     *junitFailure    `xml:"error"`
+	Skipped   *junitSkipped    `xml:"skipped"`
+}
+
+type junitProperties struct {
+	List []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// JUnit converts the report to JUnit XML with one suite per area of the catalog.
+// Every Tast test is a test case, so each parameter, e.g. a language, is a case of its own.
+// Screenshot paths are written relative to outDir, the directory of the XML file.
+func (r *Report) JUnit(outDir string) ([]byte, error) {
+	root := junitSuites{Name: "hpsa"}
+	suites := make(map[string]*junitSuite)
+	durations := make(map[string]time.Duration)
+	starts := make(map[string]time.Time)
+	var total time.Duration
+	for _, t := range r.Tests {
+		area := t.Area
+		if area == "" {
+			area = noArea
+		}
+		s, ok := suites[area]
+		if !ok {
+			s = &junitSuite{Name: "hpsa." + area}
+			s.Props = environmentProperties(r.Environment)
+			suites[area] = s
+		}
+		c := r.junitCase(t, outDir)
+		s.Cases = append(s.Cases, c)
+		s.Tests++
+		switch {
+		case c.Error != nil:
+			s.Errors++
+		case c.Failure != nil:
+			s.Failures++
+		case c.Skipped != nil:
+			s.Skipped++
+		}
+		if !t.Start.IsZero() && (starts[area].IsZero() || t.Start.Before(starts[area])) {
+			starts[area] = t.Start
+		}
+		durations[area] += t.Duration
+		total += t.Duration
+	}
+	var areas []string
+	for area := range suites {
+		areas = append(areas, area)
+	}
+	sort.Strings(areas)
+	for _, area := range areas {
+		s := suites[area]
+		s.Time = seconds(durations[area])
+		if !starts[area].IsZero() {
+			s.Timestamp = starts[area].UTC().Format("2006-01-02T15:04:05")
+		}
+		root.Suites = append(root.Suites, *s)
+		root.Tests += s.Tests
+		root.Failures += s.Failures
+		root.Errors += s.Errors
+		root.Skipped += s.Skipped
+	}
+	root.Time = seconds(total)
+	b, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(b, '\n')...), nil
+}
+
+func (r *Report) junitCase(t TestReport, outDir string) junitCase {
+	c := junitCase{Name: strings.TrimPrefix(t.Name, "hpsa."), Classname: "hpsa." + t.Func, Time: seconds(t.Duration)}
+	var props []junitProperty
+	if t.Param != "" {
+		props = append(props, junitProperty{"param", t.Param})
+	}
+	if t.Environment != nil && t.Environment.Locale != "" {
+		props = append(props, junitProperty{"locale", t.Environment.Locale})
+	}
+	for _, s := range t.Screenshots {
+		name := "screenshot"
+		if s.Exception {
+			name = "exception_screenshot"
+		}
+		path := s.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(r.Results, path)
+		}
+		props = append(props, junitProperty{name, relativeLink(outDir, path)})
+	}
+	c.Props = properties(props)
+	switch t.Status {
+	case StatusSkipped:
+		c.Skipped = &junitSkipped{Message: t.SkipReason}
+	case StatusFailed:
+		var text strings.Builder
+		for _, e := range t.Errors {
+			fmt.Fprintf(&text, "%v\nat %v:%d\n%v\n", e.Reason, e.File, e.Line, e.Stack)
+		}
+		for _, st := range t.Steps {
+			if st.Failed() {
+				fmt.Fprintf(&text, "step %v %v at %v: %v\n", st.Kind, st.Name, st.Offset.Round(time.Millisecond), st.Result)
+			}
+		}
+		f := &junitFailure{Message: t.Errors[0].Reason, Type: "failure", Text: text.String()}
+		switch {
+		case t.Infrastructure():
+			// The run went wrong, not the test, CI shows errors apart from the failures.
+			f.Type = "infrastructure"
+			c.Error = f
+		case len(t.Exceptions()) > 0:
+			f.Type = "exception"
+			c.Failure = f
+		default:
+			c.Failure = f
+		}
+	case StatusIncomplete:
+		c.Error = &junitFailure{Message: "the test did not finish", Type: "incomplete"}
+	}
+	return c
+}
+
+func environmentProperties(env Environment) *junitProperties {
+	var props []junitProperty
+	for _, p := range []junitProperty{
+		{"hpsa_version", env.HPSAVersion},
+		{"chrome_version", env.ChromeVersion},
+		{"board", env.Board},
+		{"os_version", env.OSVersion},
+	} {
+		if p.Value != "" {
+			props = append(props, p)
+		}
+	}
+	return properties(props)
+}
+
+// properties wraps the list, nil leaves out the empty properties element
+func properties(list []junitProperty) *junitProperties {
+	if len(list) == 0 {
+		return nil
+	}
+	return &junitProperties{List: list}
+}
+
+// WriteJUnit writes the report as JUnit XML to the path, it creates the directory of the path
+func (r *Report) WriteJUnit(path string) error {
+	b, err := r.JUnit(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
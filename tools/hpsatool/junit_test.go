@@ -0,0 +1,190 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseJUnit parses the XML JUnit wrote
+func parseJUnit(t *testing.T, b []byte) junitSuites {
+	t.Helper()
+	if !strings.HasPrefix(string(b), xml.Header) {
+		t.Errorf("The XML does not start with the header: %.60q", b)
+	}
+	var root junitSuites
+	if err := xml.Unmarshal(b, &root); err != nil {
+		t.Fatal("The XML does not parse: ", err)
+	}
+	return root
+}
+
+func TestJUnitCounts(t *testing.T) {
+	start := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	failed := TestError{Reason: "The warranty date does not match", File: "hpsa17warranty.go", Line: 142}
+	lost := TestError{Reason: "Lost SSH connection: EOF"}
+	for _, tc := range []struct {
+		name string
+		test TestReport
+		// failures, errors and skipped are the counts of the suite, typ is the type of the failure or error.
+		failures, errors, skipped int
+		typ, message              string
+	}{
+		{"passed", TestReport{Status: StatusPassed}, 0, 0, 0, "", ""},
+		{"failed", TestReport{Status: StatusFailed, Errors: []TestError{failed}}, 1, 0, 0, "failure", failed.Reason},
+		{"exception", TestReport{Status: StatusFailed, Errors: []TestError{failed},
+			Screenshots: []Screenshot{{Name: "exception.png", Path: "exception.png", Exception: true}}}, 1, 0, 0, "exception", failed.Reason},
+		{"infrastructure", TestReport{Status: StatusFailed, Errors: []TestError{lost}}, 0, 1, 0, "infrastructure", lost.Reason},
+		{"infrastructure and check", TestReport{Status: StatusFailed, Errors: []TestError{lost, failed}}, 1, 0, 0, "failure", lost.Reason},
+		{"incomplete", TestReport{Status: StatusIncomplete}, 0, 1, 0, "incomplete", "the test did not finish"},
+		{"skipped", TestReport{Status: StatusSkipped, SkipReason: "missing SoftwareDeps: vpd"}, 0, 0, 1, "", "missing SoftwareDeps: vpd"},
+	} {
+		tr := tc.test
+		tr.Name, tr.Func, tr.Area, tr.Start, tr.Duration = "hpsa.Hpsa17warranty", "Hpsa17warranty", "l10n", start, 90*time.Second
+		r := &Report{Results: "results", Tests: []TestReport{tr}}
+		b, err := r.JUnit("results")
+		if err != nil {
+			t.Fatalf("%v: JUnit failed: %v", tc.name, err)
+		}
+		root := parseJUnit(t, b)
+		if root.Tests != 1 || root.Failures != tc.failures || root.Errors != tc.errors || root.Skipped != tc.skipped {
+			t.Errorf("%v: the run counts %d tests, %d failures, %d errors, %d skipped, want 1, %d, %d, %d",
+				tc.name, root.Tests, root.Failures, root.Errors, root.Skipped, tc.failures, tc.errors, tc.skipped)
+		}
+		if len(root.Suites) != 1 {
+			t.Errorf("%v: %d suites, want the l10n one", tc.name, len(root.Suites))
+			continue
+		}
+		s := root.Suites[0]
+		if s.Name != "hpsa.l10n" || s.Tests != 1 || s.Failures != tc.failures || s.Errors != tc.errors || s.Skipped != tc.skipped ||
+			s.Time != "90.000" || s.Timestamp != "2023-06-01T10:00:00" {
+			t.Errorf("%v: suite %+v does not match the counts of the run", tc.name, s)
+		}
+		c := s.Cases[0]
+		if c.Name != "Hpsa17warranty" || c.Classname != "hpsa.Hpsa17warranty" || c.Time != "90.000" {
+			t.Errorf("%v: case is %q of %q in %v", tc.name, c.Name, c.Classname, c.Time)
+		}
+		var typ, message string
+		switch {
+		case c.Failure != nil && c.Error != nil:
+			t.Errorf("%v: the case is a failure and an error", tc.name)
+		case c.Failure != nil:
+			typ, message = c.Failure.Type, c.Failure.Message
+		case c.Error != nil:
+			typ, message = c.Error.Type, c.Error.Message
+		case c.Skipped != nil:
+			message = c.Skipped.Message
+		}
+		if typ != tc.typ || message != tc.message {
+			t.Errorf("%v: case is %q with %q, want %q with %q", tc.name, typ, message, tc.typ, tc.message)
+		}
+	}
+}
+
+func TestJUnitCases(t *testing.T) {
+	r := &Report{
+		Results:     "results",
+		Environment: Environment{HPSAVersion: "3.1.2", Board: "octopus"},
+		Tests: []TestReport{
+			{Name: "hpsa.Hpsa17warranty.en_us", Func: "Hpsa17warranty", Param: "en_us", Area: "l10n", Status: StatusPassed,
+				Environment: &Environment{Locale: "en-US"}},
+			{Name: "hpsa.Hpsa17warranty.de_de", Func: "Hpsa17warranty", Param: "de_de", Area: "l10n", Status: StatusFailed,
+				Errors: []TestError{{Reason: "wrong date"}}, Environment: &Environment{Locale: "de-DE"}},
+			{Name: "hpsa.Hpsa99new", Func: "Hpsa99new", Status: StatusPassed},
+			{Name: "hpsa.Hpsa01walkthrough", Func: "Hpsa01walkthrough", Area: "dashboard", Status: StatusPassed},
+		},
+	}
+	b, err := r.JUnit("results")
+	if err != nil {
+		t.Fatal("JUnit failed: ", err)
+	}
+	root := parseJUnit(t, b)
+	var suites []string
+	for _, s := range root.Suites {
+		suites = append(suites, s.Name)
+	}
+	// Suites are sorted by area, a test which is not in the catalog has a suite of its own.
+	if want := []string{"hpsa.dashboard", "hpsa.l10n", "hpsa." + noArea}; !reflect.DeepEqual(suites, want) {
+		t.Fatalf("Suites are %q, want %q", suites, want)
+	}
+	if want := (&junitProperties{List: []junitProperty{{"hpsa_version", "3.1.2"}, {"board", "octopus"}}}); !reflect.DeepEqual(root.Suites[0].Props, want) {
+		t.Errorf("Suite properties are %+v, want %+v", root.Suites[0].Props, want)
+	}
+	l10n := root.Suites[1]
+	if l10n.Tests != 2 || l10n.Failures != 1 {
+		t.Errorf("The l10n suite has %d tests and %d failures, want a case per parameter and 1 failure", l10n.Tests, l10n.Failures)
+	}
+	for i, want := range []struct {
+		name          string
+		param, locale string
+		failed        bool
+	}{
+		{"Hpsa17warranty.en_us", "en_us", "en-US", false},
+		{"Hpsa17warranty.de_de", "de_de", "de-DE", true},
+	} {
+		c := l10n.Cases[i]
+		props := &junitProperties{List: []junitProperty{{"param", want.param}, {"locale", want.locale}}}
+		if c.Name != want.name || c.Classname != "hpsa.Hpsa17warranty" || !reflect.DeepEqual(c.Props, props) || (c.Failure != nil) != want.failed {
+			t.Errorf("Case %d is %+v, want %v with %+v failed %v", i, c, want.name, props, want.failed)
+		}
+	}
+}
+
+func TestJUnitScreenshots(t *testing.T) {
+	tr := TestReport{Name: "hpsa.Hpsa01walkthrough", Func: "Hpsa01walkthrough", Status: StatusPassed, Screenshots: []Screenshot{
+		{Name: "walk.png", Path: filepath.Join("tests", "hpsa.Hpsa01walkthrough", "walk.png")},
+		{Name: "exception.png", Path: "/artifacts/exception.png", Exception: true},
+	}}
+	r := &Report{Results: "/results", Tests: []TestReport{tr}}
+	for _, tc := range []struct {
+		outDir string
+		want   []junitProperty
+	}{
+		{"/results", []junitProperty{
+			{"screenshot", "tests/hpsa.Hpsa01walkthrough/walk.png"},
+			{"exception_screenshot", "../artifacts/exception.png"},
+		}},
+		{"/results/ci", []junitProperty{
+			{"screenshot", "../tests/hpsa.Hpsa01walkthrough/walk.png"},
+			{"exception_screenshot", "../../artifacts/exception.png"},
+		}},
+		{"/ci", []junitProperty{
+			{"screenshot", "../results/tests/hpsa.Hpsa01walkthrough/walk.png"},
+			{"exception_screenshot", "../artifacts/exception.png"},
+		}},
+	} {
+		b, err := r.JUnit(tc.outDir)
+		if err != nil {
+			t.Fatalf("JUnit(%q) failed: %v", tc.outDir, err)
+		}
+		c := parseJUnit(t, b).Suites[0].Cases[0]
+		if c.Props == nil || !reflect.DeepEqual(c.Props.List, tc.want) {
+			t.Errorf("JUnit(%q) has the properties %+v, want %+v", tc.outDir, c.Props, tc.want)
+		}
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	r := readFixture(t)
+	// The directory of -out does not exist yet.
+	path := filepath.Join(t.TempDir(), "ci", "junit", junitFile)
+	if err := r.WriteJUnit(path); err != nil {
+		t.Fatal("WriteJUnit failed: ", err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := parseJUnit(t, b)
+	if root.Tests != 5 || root.Failures != 1 || root.Errors != 2 || root.Skipped != 1 {
+		t.Errorf("The fixture counts %d tests, %d failures, %d errors, %d skipped, want 5, 1, 2, 1", root.Tests, root.Failures, root.Errors, root.Skipped)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// hpsatool runs on the host, outside the chroot, and only needs the standard library.
+// It is a module of its own, install it with cd tools/hpsatool && go install . and test it with go test there.
+//
+// Usage, from the repository root:
+//
+//	hpsatool check [-bundle hpsa] [-catalog hpsa/catalog.json]
+//	hpsatool report -results /tmp/tast/results/latest [-artifacts dir] [-out dir]
+//	hpsatool junit -results /tmp/tast/results/latest [-artifacts dir] [-out file]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// command is a subcommand of hpsatool, run returns the exit code
+type command struct {
+	name string
+	desc string
+	run  func(args []string) int
+}
+
+var commands = []command{
+	{"check", "checks hpsa/catalog.json against the testing.AddTest registrations", runCheck},
+	{"report", "builds a JSON summary and a static HTML report of a Tast results directory", runReport},
+	{"junit", "converts a Tast results directory to JUnit XML with a suite per area", runJUnit},
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		for _, c := range commands {
+			if c.name == os.Args[1] {
+				os.Exit(c.run(os.Args[2:]))
+			}
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Usage: hpsatool <command> [flags]")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8v %v\n", c.name, c.desc)
+	}
+	os.Exit(2)
+}
+
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	bundle := fs.String("bundle", "hpsa", "directory of the bundle")
+	catalog := fs.String("catalog", "", "catalog file, catalog.json in the bundle directory by default")
+	fs.Parse(args)
+	if *catalog == "" {
+		*catalog = filepath.Join(*bundle, "catalog.json")
+	}
+	c, err := ReadCatalog(*catalog)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	regs, err := ReadRegistrations(*bundle)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	problems := Check(c, regs)
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	fmt.Printf("%d tests match the catalog\n", len(regs))
+	return 0
+}
+
+func runReport(args []string) int {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	results := fs.String("results", "", "Tast results directory, e.g. /tmp/tast/results/latest")
+	artifacts := fs.String("artifacts", "", "copy of the screenshot directory of the DUT, /var/hpsa_test_pictures")
+	catalog := fs.String("catalog", "hpsa/catalog.json", "catalog file for the areas and descriptions, it may be missing")
+	out := fs.String("out", "", "directory of the report, the results directory by default")
+	embed := fs.Bool("embed", true, "inline the screenshots in the HTML report")
+	fs.Parse(args)
+	if *results == "" {
+		fmt.Fprintln(os.Stderr, "-results is required")
+		return 2
+	}
+	if *out == "" {
+		*out = *results
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	r, err := readReport(*results, *artifacts, *catalog)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := r.WriteJSON(filepath.Join(*out, reportJSONFile)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := r.WriteHTML(filepath.Join(*out, reportHTMLFile), *embed); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("%d tests, %d passed, %d failed, %d skipped, %d incomplete, report in %v\n",
+		r.Summary.Total, r.Summary.Passed, r.Summary.Failed, r.Summary.Skipped, r.Summary.Incomplete, filepath.Join(*out, reportHTMLFile))
+	return 0
+}
+
+func runJUnit(args []string) int {
+	fs := flag.NewFlagSet("junit", flag.ExitOnError)
+	results := fs.String("results", "", "Tast results directory, e.g. /tmp/tast/results/latest")
+	artifacts := fs.String("artifacts", "", "copy of the screenshot directory of the DUT, /var/hpsa_test_pictures")
+	catalog := fs.String("catalog", "hpsa/catalog.json", "catalog file for the areas, it may be missing")
+	out := fs.String("out", "", "JUnit XML file, hpsa_junit.xml in the results directory by default")
+	fs.Parse(args)
+	if *results == "" {
+		fmt.Fprintln(os.Stderr, "-results is required")
+		return 2
+	}
+	if *out == "" {
+		*out = filepath.Join(*results, junitFile)
+	}
+	r, err := readReport(*results, *artifacts, *catalog)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := r.WriteJUnit(*out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("%d test cases written to %v\n", r.Summary.Total, *out)
+	return 0
+}
+
+// readReport reads the results with the catalog when there is one
+func readReport(results, artifacts, catalog string) (*Report, error) {
+	var c *Catalog
+	if catalog != "" {
+		var err error
+		if c, err = ReadCatalog(catalog); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return ReadReport(results, artifacts, c)
+}
@@ -0,0 +1,245 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// defaultTimeout is the timeout Tast gives a test which sets none
+const defaultTimeout = 2 * time.Minute
+
+// Registration is what a testing.AddTest call of the bundle declares.
+// Fields the checker can not read as literals are left empty and noted in Unreadable.
+type Registration struct {
+	Pos      string
+	Func     string
+	Desc     string
+	Contacts []string
+	Attr     []string
+	Timeout  time.Duration
+	Params   []Param
+	// Unreadable are the fields which are not literals, e.g. a Desc built from a constant.
+	Unreadable []string
+}
+
+// Param is a parameter of the registration, Timeout is zero when it keeps the one of the test.
+// ExtraAttr are added to the Attr of the test for the parameter.
+type Param struct {
+	Name      string
+	Timeout   time.Duration
+	ExtraAttr []string
+}
+
+// ParamAttr returns the attributes of the parameter, the ones of the test with its ExtraAttr
+func (r *Registration) ParamAttr(p Param) []string {
+	return append(append([]string(nil), r.Attr...), p.ExtraAttr...)
+}
+
+// ReadRegistrations parses the Go files of the bundle directory and returns its testing.AddTest calls
+func ReadRegistrations(dir string) ([]Registration, error) {
+	fset := token.NewFileSet()
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var regs []Registration
+	for _, path := range files {
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || !isSelector(call.Fun, "testing", "AddTest") || len(call.Args) != 1 {
+				return true
+			}
+			lit := testLiteral(call.Args[0])
+			if lit == nil {
+				return true
+			}
+			regs = append(regs, readRegistration(fset, lit))
+			return false
+		})
+	}
+	return regs, nil
+}
+
+// testLiteral returns the literal of &testing.Test{...}
+func testLiteral(e ast.Expr) *ast.CompositeLit {
+	u, ok := e.(*ast.UnaryExpr)
+	if !ok || u.Op != token.AND {
+		return nil
+	}
+	lit, ok := u.X.(*ast.CompositeLit)
+	if !ok || !isSelector(lit.Type, "testing", "Test") {
+		return nil
+	}
+	return lit
+}
+
+func readRegistration(fset *token.FileSet, lit *ast.CompositeLit) Registration {
+	pos := fset.Position(lit.Pos())
+	r := Registration{Pos: fmt.Sprintf("%v:%d", filepath.Base(pos.Filename), pos.Line), Timeout: defaultTimeout}
+	unreadable := func(field string, ok bool) {
+		if !ok {
+			r.Unreadable = append(r.Unreadable, field)
+		}
+	}
+	for _, field := range fields(lit) {
+		var ok bool
+		switch field.name {
+		case "Func":
+			var id *ast.Ident
+			id, ok = field.value.(*ast.Ident)
+			if ok {
+				r.Func = id.Name
+			}
+		case "Desc":
+			r.Desc, ok = stringLit(field.value)
+		case "Contacts":
+			r.Contacts, ok = stringsLit(field.value)
+		case "Attr":
+			r.Attr, ok = stringsLit(field.value)
+		case "Timeout":
+			r.Timeout, ok = durationLit(field.value)
+		case "Params":
+			r.Params, ok = paramsLit(field.value)
+		default:
+			ok = true
+		}
+		unreadable(field.name, ok)
+	}
+	return r
+}
+
+type field struct {
+	name  string
+	value ast.Expr
+}
+
+func fields(lit *ast.CompositeLit) []field {
+	var fs []field
+	for _, e := range lit.Elts {
+		kv, ok := e.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		if key, ok := kv.Key.(*ast.Ident); ok {
+			fs = append(fs, field{key.Name, kv.Value})
+		}
+	}
+	return fs
+}
+
+func paramsLit(e ast.Expr) ([]Param, bool) {
+	lit, ok := e.(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+	var params []Param
+	for _, elt := range lit.Elts {
+		plit, ok := elt.(*ast.CompositeLit)
+		if !ok {
+			return nil, false
+		}
+		var p Param
+		for _, f := range fields(plit) {
+			switch f.name {
+			case "Name":
+				if p.Name, ok = stringLit(f.value); !ok {
+					return nil, false
+				}
+			case "Timeout":
+				if p.Timeout, ok = durationLit(f.value); !ok {
+					return nil, false
+				}
+			case "ExtraAttr":
+				if p.ExtraAttr, ok = stringsLit(f.value); !ok {
+					return nil, false
+				}
+			}
+		}
+		params = append(params, p)
+	}
+	return params, true
+}
+
+func stringLit(e ast.Expr) (string, bool) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+func stringsLit(e ast.Expr) ([]string, bool) {
+	lit, ok := e.(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+	var ss []string
+	for _, elt := range lit.Elts {
+		s, ok := stringLit(elt)
+		if !ok {
+			return nil, false
+		}
+		ss = append(ss, s)
+	}
+	return ss, true
+}
+
+// durationLit evaluates time.Minute, 30 * time.Minute and the like
+func durationLit(e ast.Expr) (time.Duration, bool) {
+	switch v := e.(type) {
+	case *ast.ParenExpr:
+		return durationLit(v.X)
+	case *ast.BasicLit:
+		if v.Kind != token.INT {
+			return 0, false
+		}
+		n, err := strconv.ParseInt(v.Value, 0, 64)
+		return time.Duration(n), err == nil
+	case *ast.SelectorExpr:
+		units := map[string]time.Duration{"Nanosecond": time.Nanosecond, "Microsecond": time.Microsecond,
+			"Millisecond": time.Millisecond, "Second": time.Second, "Minute": time.Minute, "Hour": time.Hour}
+		for name, unit := range units {
+			if isSelector(v, "time", name) {
+				return unit, true
+			}
+		}
+	case *ast.BinaryExpr:
+		x, okx := durationLit(v.X)
+		y, oky := durationLit(v.Y)
+		if !okx || !oky {
+			return 0, false
+		}
+		switch v.Op {
+		case token.MUL:
+			return x * y, true
+		case token.ADD:
+			return x + y, true
+		}
+	}
+	return 0, false
+}
+
+func isSelector(e ast.Expr, pkg, name string) bool {
+	sel, ok := e.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == pkg
+}
@@ -0,0 +1,105 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// bundleSource is a bundle file with a test of every kind of field the checker reads
+const bundleSource = `package hpsa
+
+import (
+	"time"
+
+	"go.chromium.org/tast/core/testing"
+)
+
+const desc = "Built from a constant"
+
+func init() {
+	testing.AddTest(&testing.Test{
+		Func:     Plain,
+		Desc:     "Checks the plain test",
+		Contacts: []string{"owner@hp.com", "other@hp.com"},
+		Attr:     []string{"group:hpsa", "hpsa_smoke"},
+	})
+	testing.AddTest(&testing.Test{
+		Func:    Params,
+		Desc:    "Checks the parameters",
+		Attr:    []string{"group:hpsa"},
+		Timeout: 2*time.Hour + 30*time.Minute,
+		Params: []testing.Param{{
+			Name:      "short",
+			ExtraAttr: []string{"hpsa_full"},
+		}, {
+			Name:      "long",
+			Timeout:   (3 * time.Hour),
+			ExtraAttr: []string{"hpsa_l10n"},
+		}},
+	})
+	testing.AddTest(&testing.Test{
+		Func: Unreadable,
+		Desc: desc,
+		Attr: []string{"group:hpsa"},
+	})
+}
+`
+
+func TestReadRegistrations(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "tests.go"), []byte(bundleSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Files which are not Go are left out.
+	if err := ioutil.WriteFile(filepath.Join(dir, "catalog.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	regs, err := ReadRegistrations(dir)
+	if err != nil {
+		t.Fatal("ReadRegistrations failed: ", err)
+	}
+	want := []Registration{{
+		Pos:      "tests.go:12",
+		Func:     "Plain",
+		Desc:     "Checks the plain test",
+		Contacts: []string{"owner@hp.com", "other@hp.com"},
+		Attr:     []string{"group:hpsa", "hpsa_smoke"},
+		Timeout:  defaultTimeout,
+	}, {
+		Pos:     "tests.go:18",
+		Func:    "Params",
+		Desc:    "Checks the parameters",
+		Attr:    []string{"group:hpsa"},
+		Timeout: 150 * time.Minute,
+		Params: []Param{
+			{Name: "short", ExtraAttr: []string{"hpsa_full"}},
+			{Name: "long", Timeout: 3 * time.Hour, ExtraAttr: []string{"hpsa_l10n"}},
+		},
+	}, {
+		Pos:        "tests.go:32",
+		Func:       "Unreadable",
+		Attr:       []string{"group:hpsa"},
+		Timeout:    defaultTimeout,
+		Unreadable: []string{"Desc"},
+	}}
+	if !reflect.DeepEqual(regs, want) {
+		t.Errorf("ReadRegistrations = %+v, want %+v", regs, want)
+	}
+	if got := regs[1].ParamAttr(regs[1].Params[1]); !reflect.DeepEqual(got, []string{"group:hpsa", "hpsa_l10n"}) {
+		t.Errorf("ParamAttr(long) = %q, want the test attributes with hpsa_l10n", got)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "broken.go"), []byte("package hpsa\nfunc {"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadRegistrations(dir); err == nil {
+		t.Error("ReadRegistrations succeeded on a file which does not parse")
+	}
+}
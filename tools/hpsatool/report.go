@@ -0,0 +1,136 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Files the report command writes
+const (
+	reportJSONFile = "hpsa_report.json"
+	reportHTMLFile = "hpsa_report.html"
+)
+
+// WriteJSON writes the report as JSON
+func (r *Report) WriteJSON(path string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// WriteHTML writes the report as one static HTML file.
+// With embed the screenshots are inlined so the file can be sent on its own, without it they are linked.
+func (r *Report) WriteHTML(path string, embed bool) error {
+	outDir := filepath.Dir(path)
+	funcs := template.FuncMap{
+		"duration": func(d time.Duration) string { return d.Round(100 * time.Millisecond).String() },
+		"time":     func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+		"image": func(s Screenshot) template.URL {
+			p := s.Path
+			if !filepath.IsAbs(p) {
+				p = filepath.Join(r.Results, p)
+			}
+			if embed {
+				if b, err := ioutil.ReadFile(p); err == nil {
+					return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(b))
+				}
+			}
+			return template.URL(relativeLink(outDir, p))
+		},
+		"link": func(rel string) string { return relativeLink(outDir, filepath.Join(r.Results, rel)) },
+	}
+	tmpl, err := template.New("report").Funcs(funcs).Parse(reportTemplate)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// relativeLink is the path from the directory of the report, so the report still works when the directories are copied together
+func relativeLink(outDir, path string) string {
+	if rel, err := filepath.Rel(outDir, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
+
+const reportTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>HPSA Tast report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; vertical-align: top; }
+.passed { color: #1a7f37; } .failed { color: #cf222e; } .skipped { color: #9a6700; } .incomplete { color: #8250df; }
+tr.fail td { background: #ffebe9; }
+details { margin: 0.5em 0; border: 1px solid #ddd; padding: 0.5em; }
+summary { cursor: pointer; font-weight: bold; }
+pre { white-space: pre-wrap; background: #f6f8fa; padding: 0.5em; }
+figure { display: inline-block; margin: 0.5em; }
+figure img { max-width: 480px; border: 1px solid #ccc; }
+figure.exception img { border: 3px solid #cf222e; }
+</style>
+</head>
+<body>
+<h1>HPSA Tast report</h1>
+<table>
+<tr><th>Results</th><td>{{.Results}}</td></tr>
+<tr><th>Run</th><td>{{time .Start}} to {{time .End}}</td></tr>
+<tr><th>Tests</th><td>{{.Summary.Total}}: <span class="passed">{{.Summary.Passed}} passed</span>, <span class="failed">{{.Summary.Failed}} failed</span>, <span class="skipped">{{.Summary.Skipped}} skipped</span>, <span class="incomplete">{{.Summary.Incomplete}} incomplete</span></td></tr>
+<tr><th>HPSA version</th><td>{{.Environment.HPSAVersion}}</td></tr>
+<tr><th>Chrome version</th><td>{{.Environment.ChromeVersion}}</td></tr>
+<tr><th>Board</th><td>{{.Environment.Board}}</td></tr>
+<tr><th>OS version</th><td>{{.Environment.OSVersion}}</td></tr>
+</table>
+
+<table>
+<tr><th>Test</th><th>Area</th><th>Status</th><th>Duration</th><th>Locale</th><th>Exceptions</th></tr>
+{{range .Tests}}<tr><td><a href="#{{.Name}}">{{.Name}}</a></td><td>{{.Area}}</td><td class="{{.Status}}">{{.Status}}</td><td>{{duration .Duration}}</td><td>{{with .Environment}}{{.Locale}}{{end}}</td><td>{{len .Exceptions}}</td></tr>
+{{end}}</table>
+
+{{range .Tests}}
+<details id="{{.Name}}"{{if or (eq .Status "failed") (eq .Status "incomplete")}} open{{end}}>
+<summary><span class="{{.Status}}">{{.Status}}</span> {{.Name}} ({{duration .Duration}})</summary>
+{{with .Desc}}<p>{{.}}</p>{{end}}
+{{with .SkipReason}}<p class="skipped">Skipped: {{.}}</p>{{end}}
+{{with .Environment}}<p>HPSA {{.HPSAVersion}}, Chrome {{.ChromeVersion}}, {{.Board}}, {{.Locale}}</p>{{end}}
+{{range .Errors}}<pre>{{.Reason}}
+at {{.File}}:{{.Line}}
+{{.Stack}}</pre>
+{{end}}
+{{with .Steps}}<h3>Steps</h3>
+<table>
+<tr><th>Offset</th><th>Duration</th><th>Kind</th><th>Step</th><th>Retries</th><th>Result</th></tr>
+{{range .}}<tr{{if .Failed}} class="fail"{{end}}><td>{{duration .Offset}}</td><td>{{duration .Duration}}</td><td>{{.Kind}}</td><td>{{.Name}}{{with .Locator}}<br><small>{{.}}</small>{{end}}</td><td>{{if .Retries}}{{.Retries}}{{end}}</td><td>{{.Result}}</td></tr>
+{{end}}</table>{{end}}
+{{with .Screenshots}}<h3>Screenshots</h3>
+{{range .}}<figure{{if .Exception}} class="exception"{{end}}><img src="{{image .}}" alt="{{.Name}}"><figcaption>{{.Name}}</figcaption></figure>
+{{end}}{{end}}
+{{with .FailLogs}}<h3>Fail logs</h3>
+<ul>{{range .}}<li><a href="{{link .}}">{{.}}</a></li>{{end}}</ul>{{end}}
+</details>
+{{end}}
+</body>
+</html>
+`
@@ -0,0 +1,89 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSON(t *testing.T) {
+	r := readFixture(t)
+	path := filepath.Join(t.TempDir(), reportJSONFile)
+	if err := r.WriteJSON(path); err != nil {
+		t.Fatal("WriteJSON failed: ", err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Report
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal("The JSON report does not parse: ", err)
+	}
+	if !reflect.DeepEqual(&got, r) {
+		t.Errorf("The JSON report reads back as %+v, want %+v", got, *r)
+	}
+	// Scripts read the report by these keys.
+	for _, key := range []string{`"summary"`, `"incomplete": 1`, `"status": "skipped"`, `"exception": true`, `"durationNs": 150000000000`} {
+		if !strings.Contains(string(data), key) {
+			t.Errorf("The JSON report has no %v", key)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	r := readFixture(t)
+	for _, tc := range []struct {
+		name  string
+		embed bool
+		want  []string
+	}{
+		{"embedded", true, []string{
+			`src="data:image/png;base64,` + base64.StdEncoding.EncodeToString([]byte("png")) + `"`,
+		}},
+		{"linked", false, []string{
+			`src="../testdata/results/tests/hpsa.Hpsa17warranty.en_us/warranty_en_us.png"`,
+		}},
+	} {
+		// The report is written next to testdata, the links to the fixture are relative to it.
+		dir, err := ioutil.TempDir(".", "report")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, reportHTMLFile)
+		if err := r.WriteHTML(path, tc.embed); err != nil {
+			t.Fatalf("%v: WriteHTML failed: %v", tc.name, err)
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		html := string(data)
+		for _, want := range append(tc.want,
+			"5: <span class=\"passed\">1 passed</span>, <span class=\"failed\">2 failed</span>, <span class=\"skipped\">1 skipped</span>, <span class=\"incomplete\">1 incomplete</span>",
+			"<td>3.1.2</td>",
+			`<details id="hpsa.Hpsa17warranty.en_us" open>`,
+			`<details id="hpsa.Hpsa23network.offline" open>`,
+			`<details id="hpsa.Hpsa01walkthrough">`,
+			"The warranty page shows 2023-13-01, not a date of en-US\nat hpsa17warranty.go:142",
+			"Skipped: missing SoftwareDeps: wifi",
+			`<tr class="fail"><td>10s</td><td>2s</td><td>wait</td><td>BatteryCheck<br><small>class &#34;battery&#34;</small></td><td></td><td>timed out</td></tr>`,
+			`<figure class="exception">`,
+			`<a href="../testdata/results/tests/hpsa.Hpsa01walkthrough/faillog/ui_tree.txt">`,
+		) {
+			if !strings.Contains(html, want) {
+				t.Errorf("%v: the HTML report has no %q", tc.name, want)
+			}
+		}
+	}
+}
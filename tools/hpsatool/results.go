@@ -0,0 +1,334 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Files the hpsa tests write to their OutDir, see hpsa/common/trace.go and hpsa/common/environment.go
+const (
+	traceFile       = "hpsa_trace.json"
+	environmentFile = "hpsa_env.json"
+)
+
+// tastResult is one entry of results.json in the Tast results directory
+type tastResult struct {
+	Name       string      `json:"name"`
+	Errors     []TestError `json:"errors"`
+	Start      time.Time   `json:"start"`
+	End        time.Time   `json:"end"`
+	SkipReason string      `json:"skipReason"`
+}
+
+// TestError is an error the test reported, with where it was reported
+type TestError struct {
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+	File   string    `json:"file"`
+	Line   int       `json:"line"`
+	Stack  string    `json:"stack"`
+}
+
+// Environment is what the test ran on, as written to hpsa_env.json
+type Environment struct {
+	HPSAVersion   string `json:"hpsaVersion"`
+	ChromeVersion string `json:"chromeVersion"`
+	Board         string `json:"board"`
+	OSVersion     string `json:"osVersion"`
+	Locale        string `json:"locale"`
+}
+
+// Step is one step of the timeline, Offset is from the start of the trace
+type Step struct {
+	Offset   time.Duration `json:"offsetNs"`
+	Duration time.Duration `json:"durationNs"`
+	Kind     string        `json:"kind"`
+	Name     string        `json:"name"`
+	Locator  string        `json:"locator,omitempty"`
+	Result   string        `json:"result"`
+	Retries  int           `json:"retries,omitempty"`
+}
+
+// Failed tells the step ended with an error
+func (s Step) Failed() bool {
+	return s.Result != "ok"
+}
+
+// Screenshot is a screenshot of the test. Path is relative to the results directory when the file is in it.
+type Screenshot struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Exception bool   `json:"exception"`
+}
+
+// Status is the outcome of a test
+type Status string
+
+const (
+	// StatusPassed is a test which reported no error
+	StatusPassed Status = "passed"
+	// StatusFailed is a test which reported an error
+	StatusFailed Status = "failed"
+	// StatusSkipped is a test Tast did not run, e.g. for a missing dependency
+	StatusSkipped Status = "skipped"
+	// StatusIncomplete is a test which started and never ended, e.g. the DUT rebooted or Tast was interrupted
+	StatusIncomplete Status = "incomplete"
+)
+
+// TestReport is everything the report shows of one test
+type TestReport struct {
+	// Name is the full Tast name, e.g. hpsa.Hpsa17warranty.en_us, Func and Param are its parts.
+	Name  string `json:"name"`
+	Func  string `json:"func"`
+	Param string `json:"param,omitempty"`
+	// Area and Desc come from the catalog.
+	Area        string        `json:"area,omitempty"`
+	Desc        string        `json:"desc,omitempty"`
+	Status      Status        `json:"status"`
+	SkipReason  string        `json:"skipReason,omitempty"`
+	Start       time.Time     `json:"start"`
+	End         time.Time     `json:"end"`
+	Duration    time.Duration `json:"durationNs"`
+	Environment *Environment  `json:"environment,omitempty"`
+	Errors      []TestError   `json:"errors,omitempty"`
+	Steps       []Step        `json:"steps,omitempty"`
+	Screenshots []Screenshot  `json:"screenshots,omitempty"`
+	// FailLogs are the UI dumps and other files faillog wrote, relative to the results directory.
+	FailLogs []string `json:"failLogs,omitempty"`
+}
+
+// Exceptions are the screenshots of HPSA exception popups
+func (t TestReport) Exceptions() []Screenshot {
+	var ss []Screenshot
+	for _, s := range t.Screenshots {
+		if s.Exception {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// infrastructureReasons start the errors Tast reports itself when the run, not the test, went wrong
+var infrastructureReasons = []string{
+	"[Fixture failure]",
+	"Test did not return on timeout",
+	"Test did not finish",
+	"Lost SSH connection",
+	"Failed to connect to DUT",
+}
+
+// Infrastructure tells every error of the test came from Tast or the DUT connection, not from a check of the test
+func (t TestReport) Infrastructure() bool {
+	if len(t.Errors) == 0 {
+		return false
+	}
+	for _, e := range t.Errors {
+		infra := false
+		for _, r := range infrastructureReasons {
+			infra = infra || strings.HasPrefix(strings.TrimSpace(e.Reason), r)
+		}
+		if !infra {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary counts the tests by status
+type Summary struct {
+	Total      int `json:"total"`
+	Passed     int `json:"passed"`
+	Failed     int `json:"failed"`
+	Skipped    int `json:"skipped"`
+	Incomplete int `json:"incomplete"`
+}
+
+// Report is a whole Tast run of hpsa tests
+type Report struct {
+	Results string    `json:"results"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	// Environment is the one of the first test which wrote it, Locale is left to the tests.
+	Environment Environment  `json:"environment"`
+	Summary     Summary      `json:"summary"`
+	Tests       []TestReport `json:"tests"`
+}
+
+// ReadReport reads the Tast results directory.
+// artifacts is a copy of the screenshot directory of the DUT, common.ScreenshotPath, it may be empty.
+// The catalog may be nil, the tests then have no area.
+func ReadReport(results, artifacts string, c *Catalog) (*Report, error) {
+	data, err := ioutil.ReadFile(filepath.Join(results, "results.json"))
+	if err != nil {
+		return nil, err
+	}
+	var trs []tastResult
+	if err := json.Unmarshal(data, &trs); err != nil {
+		return nil, err
+	}
+	r := &Report{Results: results}
+	for _, tr := range trs {
+		if !strings.HasPrefix(tr.Name, "hpsa.") {
+			continue
+		}
+		t := readTest(results, artifacts, tr, c)
+		r.Tests = append(r.Tests, t)
+		r.Summary.Total++
+		switch t.Status {
+		case StatusPassed:
+			r.Summary.Passed++
+		case StatusFailed:
+			r.Summary.Failed++
+		case StatusSkipped:
+			r.Summary.Skipped++
+		case StatusIncomplete:
+			r.Summary.Incomplete++
+		}
+		if !tr.Start.IsZero() && (r.Start.IsZero() || tr.Start.Before(r.Start)) {
+			r.Start = tr.Start
+		}
+		if tr.End.After(r.End) {
+			r.End = tr.End
+		}
+		if r.Environment == (Environment{}) && t.Environment != nil {
+			r.Environment = *t.Environment
+			r.Environment.Locale = ""
+		}
+	}
+	return r, nil
+}
+
+func readTest(results, artifacts string, tr tastResult, c *Catalog) TestReport {
+	t := TestReport{Name: tr.Name, Errors: tr.Errors, Start: tr.Start, End: tr.End, SkipReason: tr.SkipReason}
+	parts := strings.SplitN(tr.Name, ".", 3)
+	t.Func = parts[1]
+	if len(parts) == 3 {
+		t.Param = parts[2]
+	}
+	if c != nil {
+		if ct := c.Test(t.Func); ct != nil {
+			t.Area, t.Desc = ct.Area, ct.Desc
+		}
+	}
+	switch {
+	case tr.SkipReason != "":
+		t.Status = StatusSkipped
+	case len(tr.Errors) > 0:
+		t.Status = StatusFailed
+	case tr.End.IsZero():
+		// Tast writes End when the test returns, a result without it did not finish.
+		t.Status = StatusIncomplete
+	default:
+		t.Status = StatusPassed
+	}
+	if !tr.End.IsZero() {
+		t.Duration = tr.End.Sub(tr.Start)
+	}
+
+	// results.json has the output directory of the machine which ran Tast, the copy may be elsewhere.
+	outDir := filepath.Join(results, "tests", tr.Name)
+	var env Environment
+	if readJSON(filepath.Join(outDir, environmentFile), &env) == nil {
+		t.Environment = &env
+	}
+	t.Steps = readSteps(filepath.Join(outDir, traceFile))
+
+	seen := make(map[string]bool)
+	addScreenshot := func(name, path string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		if rel, err := filepath.Rel(results, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		} else if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		t.Screenshots = append(t.Screenshots, Screenshot{Name: name, Path: path, Exception: strings.Contains(strings.ToLower(name), "exception")})
+	}
+	// Most tests save the screenshots on the DUT, the trace tells which of them the test took.
+	for _, st := range t.Steps {
+		if st.Kind != "screenshot" || st.Failed() {
+			continue
+		}
+		for _, dir := range []string{outDir, artifacts} {
+			if dir == "" {
+				continue
+			}
+			if path := filepath.Join(dir, st.Name); fileExists(path) {
+				addScreenshot(st.Name, path)
+				break
+			}
+		}
+	}
+	filepath.Walk(outDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(results, path)
+		switch {
+		case strings.Contains(filepath.ToSlash(rel), "/faillog/"):
+			t.FailLogs = append(t.FailLogs, rel)
+		case strings.HasSuffix(path, ".png"):
+			addScreenshot(filepath.Base(path), path)
+		}
+		return nil
+	})
+	return t
+}
+
+// readSteps reads the steps from the trace-event JSON, nil when the test wrote no trace
+func readSteps(path string) []Step {
+	var trace struct {
+		TraceEvents []struct {
+			Name string `json:"name"`
+			Cat  string `json:"cat"`
+			Ts   int64  `json:"ts"`
+			Dur  int64  `json:"dur"`
+			Args struct {
+				Locator string `json:"locator"`
+				Result  string `json:"result"`
+				Retries int    `json:"retries"`
+			} `json:"args"`
+		} `json:"traceEvents"`
+	}
+	if readJSON(path, &trace) != nil {
+		return nil
+	}
+	var steps []Step
+	for _, e := range trace.TraceEvents {
+		steps = append(steps, Step{
+			Offset:   time.Duration(e.Ts) * time.Microsecond,
+			Duration: time.Duration(e.Dur) * time.Microsecond,
+			Kind:     e.Cat,
+			Name:     e.Name,
+			Locator:  e.Args.Locator,
+			Result:   e.Args.Result,
+			Retries:  e.Args.Retries,
+		})
+	}
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].Offset < steps[j].Offset })
+	return steps
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,178 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Fixtures of the tests, a Tast results directory and a copy of the screenshot directory of the DUT
+var (
+	resultsDir   = filepath.Join("testdata", "results")
+	artifactsDir = filepath.Join("testdata", "artifacts")
+)
+
+// readFixture reads the fixture results with a catalog of some of its tests
+func readFixture(t *testing.T) *Report {
+	t.Helper()
+	c := &Catalog{Tests: []CatalogTest{
+		{Name: "Hpsa01walkthrough", Desc: "Walks through the dashboard", Area: "dashboard"},
+		{Name: "Hpsa17warranty", Desc: "Checks the warranty dates", Area: "l10n"},
+		{Name: "Hpsa22suspendresume", Area: "diagnostics"},
+	}}
+	r, err := ReadReport(resultsDir, artifactsDir, c)
+	if err != nil {
+		t.Fatal("ReadReport failed: ", err)
+	}
+	return r
+}
+
+// testByName returns the test of the report with the name
+func testByName(t *testing.T, r *Report, name string) TestReport {
+	t.Helper()
+	for _, tr := range r.Tests {
+		if tr.Name == name {
+			return tr
+		}
+	}
+	t.Fatalf("%v is not in the report", name)
+	return TestReport{}
+}
+
+func TestReadReport(t *testing.T) {
+	r := readFixture(t)
+	if want := (Summary{Total: 5, Passed: 1, Failed: 2, Skipped: 1, Incomplete: 1}); r.Summary != want {
+		t.Errorf("Summary = %+v, want %+v", r.Summary, want)
+	}
+	start := time.Date(2023, 6, 1, 10, 0, 0, 0, time.UTC)
+	end := time.Date(2023, 6, 1, 10, 8, 1, 0, time.UTC)
+	// example.Pass is left out, its times too.
+	if !r.Start.Equal(start) || !r.End.Equal(end) {
+		t.Errorf("the run is from %v to %v, want from %v to %v", r.Start, r.End, start, end)
+	}
+	// The environment of the run is the one of the first test, without its locale.
+	if want := (Environment{HPSAVersion: "3.1.2", ChromeVersion: "114.0.5735.90", Board: "octopus", OSVersion: "15437.41.0"}); r.Environment != want {
+		t.Errorf("Environment = %+v, want %+v", r.Environment, want)
+	}
+
+	walk := testByName(t, r, "hpsa.Hpsa01walkthrough")
+	if walk.Func != "Hpsa01walkthrough" || walk.Param != "" || walk.Area != "dashboard" || walk.Desc != "Walks through the dashboard" {
+		t.Errorf("hpsa.Hpsa01walkthrough is %q %q in %q with %q", walk.Func, walk.Param, walk.Area, walk.Desc)
+	}
+	if walk.Duration != 150*time.Second {
+		t.Errorf("hpsa.Hpsa01walkthrough took %v, want 2m30s", walk.Duration)
+	}
+	if walk.Environment == nil || walk.Environment.Locale != "en-US" {
+		t.Errorf("hpsa.Hpsa01walkthrough ran on %+v, want the en-US environment", walk.Environment)
+	}
+	wantSteps := []Step{
+		{Offset: 10 * time.Second, Duration: 2 * time.Second, Kind: "wait", Name: "BatteryCheck", Locator: `class "battery"`, Result: "timed out"},
+		{Offset: 30 * time.Second, Duration: 1500 * time.Millisecond, Kind: "click", Name: "CheckCPU", Locator: `class "title hp-link"`, Result: "ok", Retries: 2},
+		{Offset: 90 * time.Second, Duration: 300 * time.Millisecond, Kind: "screenshot", Name: "HPSA_hpsa01walkthrough_component.png", Result: "ok"},
+		{Offset: 95 * time.Second, Duration: time.Millisecond, Kind: "screenshot", Name: "HPSA_hpsa01walkthrough_missing.png", Result: "failed to capture"},
+	}
+	if !reflect.DeepEqual(walk.Steps, wantSteps) {
+		t.Errorf("Steps = %+v, want them by offset %+v", walk.Steps, wantSteps)
+	}
+	if !walk.Steps[0].Failed() || walk.Steps[1].Failed() {
+		t.Error("Failed does not tell the timed out step from the ok one")
+	}
+	if want := []string{filepath.Join("tests", "hpsa.Hpsa01walkthrough", "faillog", "ui_tree.txt")}; !reflect.DeepEqual(walk.FailLogs, want) {
+		t.Errorf("FailLogs = %q, want %q", walk.FailLogs, want)
+	}
+
+	warranty := testByName(t, r, "hpsa.Hpsa17warranty.en_us")
+	if warranty.Func != "Hpsa17warranty" || warranty.Param != "en_us" || warranty.Area != "l10n" {
+		t.Errorf("hpsa.Hpsa17warranty.en_us is %q %q in %q", warranty.Func, warranty.Param, warranty.Area)
+	}
+	if len(warranty.Errors) != 1 || warranty.Errors[0].Line != 142 {
+		t.Errorf("hpsa.Hpsa17warranty.en_us has the errors %+v, want the one at line 142", warranty.Errors)
+	}
+	if network := testByName(t, r, "hpsa.Hpsa23network.offline"); network.Area != "" || network.Duration != 0 || network.Environment != nil {
+		t.Errorf("hpsa.Hpsa23network.offline is in %q, took %v on %+v, want no area, duration and environment", network.Area, network.Duration, network.Environment)
+	}
+
+	if _, err := ReadReport(filepath.Join("testdata", "missing"), "", nil); err == nil {
+		t.Error("ReadReport succeeded without results.json")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	r := readFixture(t)
+	for _, tc := range []struct {
+		name           string
+		status         Status
+		infrastructure bool
+	}{
+		{"hpsa.Hpsa01walkthrough", StatusPassed, false},
+		{"hpsa.Hpsa17warranty.en_us", StatusFailed, false},
+		{"hpsa.Hpsa22suspendresume.battery", StatusFailed, true},
+		{"hpsa.Hpsa23network.offline", StatusIncomplete, false},
+		{"hpsa.Hpsa24connectivity.wifi_off", StatusSkipped, false},
+	} {
+		tr := testByName(t, r, tc.name)
+		if tr.Status != tc.status {
+			t.Errorf("%v is %v, want %v", tc.name, tr.Status, tc.status)
+		}
+		if tr.Infrastructure() != tc.infrastructure {
+			t.Errorf("%v: Infrastructure() = %v, want %v", tc.name, tr.Infrastructure(), tc.infrastructure)
+		}
+	}
+	if skipped := testByName(t, r, "hpsa.Hpsa24connectivity.wifi_off"); skipped.SkipReason != "missing SoftwareDeps: wifi" {
+		t.Errorf("SkipReason = %q", skipped.SkipReason)
+	}
+}
+
+func TestInfrastructure(t *testing.T) {
+	infra := TestError{Reason: "  [Fixture failure] chromeLoggedIn: failed to start Chrome"}
+	check := TestError{Reason: "Failed to open the warranty page: Lost SSH connection"}
+	for _, tc := range []struct {
+		name   string
+		errors []TestError
+		want   bool
+	}{
+		{"no errors", nil, false},
+		{"infrastructure", []TestError{infra, {Reason: "Test did not finish"}}, true},
+		{"check", []TestError{check}, false},
+		{"both", []TestError{infra, check}, false},
+	} {
+		if got := (TestReport{Errors: tc.errors}).Infrastructure(); got != tc.want {
+			t.Errorf("%v: Infrastructure() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestScreenshots(t *testing.T) {
+	r := readFixture(t)
+	absArtifacts, err := filepath.Abs(artifactsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name string
+		want []Screenshot
+	}{
+		// The trace names the screenshots saved on the DUT, the failed one is left out, the ones in the output directory follow.
+		{"hpsa.Hpsa01walkthrough", []Screenshot{
+			{Name: "HPSA_hpsa01walkthrough_component.png", Path: filepath.Join(absArtifacts, "HPSA_hpsa01walkthrough_component.png")},
+			{Name: "hpsa01walkthrough_exception.png", Path: filepath.Join("tests", "hpsa.Hpsa01walkthrough", "hpsa01walkthrough_exception.png"), Exception: true},
+		}},
+		{"hpsa.Hpsa17warranty.en_us", []Screenshot{
+			{Name: "warranty_en_us.png", Path: filepath.Join("tests", "hpsa.Hpsa17warranty.en_us", "warranty_en_us.png")},
+		}},
+		{"hpsa.Hpsa24connectivity.wifi_off", nil},
+	} {
+		tr := testByName(t, r, tc.name)
+		if !reflect.DeepEqual(tr.Screenshots, tc.want) {
+			t.Errorf("%v: Screenshots = %+v, want %+v", tc.name, tr.Screenshots, tc.want)
+		}
+	}
+	if exceptions := testByName(t, r, "hpsa.Hpsa01walkthrough").Exceptions(); len(exceptions) != 1 || exceptions[0].Name != "hpsa01walkthrough_exception.png" {
+		t.Errorf("Exceptions = %+v, want the exception screenshot", exceptions)
+	}
+}